@@ -6,8 +6,9 @@ import (
 	"log"
 	"os"
 
+	"github.com/DraconDev/go-stripe-ms/internal/config"
 	"github.com/DraconDev/go-stripe-ms/internal/database"
-	"github.com/jackc/pgx/v5"
+	"github.com/DraconDev/go-stripe-ms/internal/database/migrations"
 	"github.com/joho/godotenv"
 )
 
@@ -25,18 +26,26 @@ func main() {
 
 	// Connect to database
 	ctx := context.Background()
-	conn, err := pgx.Connect(ctx, dbURL)
+	poolConfig, err := config.LoadDatabasePool().PoolConfig(dbURL)
+	if err != nil {
+		log.Fatalf("Invalid database configuration: %v", err)
+	}
+	pool, err := database.NewPool(ctx, poolConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer conn.Close(ctx)
+	defer pool.Close()
 
 	// Create repository
-	repo := database.NewRepository(conn)
+	repo := database.NewRepository(pool)
 
-	// Initialize tables (will create projects table)
-	if err := repo.InitializeTables(ctx); err != nil {
-		log.Fatalf("Failed to initialize tables: %v", err)
+	// Projects are only created on a current schema
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if err := migrator.CheckCurrent(ctx); err != nil {
+		log.Fatalf("%v (run `go run ./cmd/migrate up`)", err)
 	}
 
 	// Get project name from command line or use default
@@ -45,12 +54,31 @@ func main() {
 		projectName = os.Args[1]
 	}
 
+	// Optional webhook URL for billing event notifications
+	webhookURL := ""
+	if len(os.Args) > 2 {
+		webhookURL = os.Args[2]
+	}
+
+	// Optional Stripe account of the project, for projects that do not use the service account
+	stripeSecretKey, stripeWebhookSecret := "", ""
+	if len(os.Args) > 4 {
+		stripeSecretKey = os.Args[3]
+		stripeWebhookSecret = os.Args[4]
+	}
+
 	// Create a new project
-	project, err := repo.CreateProject(ctx, projectName, "")
+	project, err := repo.CreateProject(ctx, projectName, webhookURL)
 	if err != nil {
 		log.Fatalf("Failed to create project: %v", err)
 	}
 
+	if stripeWebhookSecret != "" {
+		if err := repo.UpdateProjectStripeSettings(ctx, project.ID, stripeSecretKey, stripeWebhookSecret); err != nil {
+			log.Fatalf("Failed to set Stripe account of project: %v", err)
+		}
+	}
+
 	// Display the project details
 	fmt.Println("✅ Project created successfully!")
 	fmt.Println()
@@ -58,9 +86,15 @@ func main() {
 	fmt.Printf("  ID:         %s\n", project.ID)
 	fmt.Printf("  Name:       %s\n", project.Name)
 	fmt.Printf("  API Key:    %s\n", project.APIKey)
+	fmt.Printf("  Webhook:    %s\n", project.WebhookURL)
+	fmt.Printf("  Signing:    %s\n", project.WebhookSigningSecret)
 	fmt.Printf("  Is Active:  %v\n", project.IsActive)
+	if stripeWebhookSecret != "" {
+		fmt.Printf("  Stripe:     /webhooks/stripe/%s\n", project.ID)
+	}
 	fmt.Println()
 	fmt.Println("🔑 Save this API key! You'll need it to authenticate requests.")
+	fmt.Println("🔏 Use the signing secret to verify the X-Webhook-Signature header of billing notifications.")
 	fmt.Println()
 	fmt.Println("Example usage:")
 	fmt.Printf("  curl -H \"X-API-Key: %s\" http://localhost:9000/health\n", project.APIKey)
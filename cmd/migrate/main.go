@@ -2,16 +2,47 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
+	"github.com/DraconDev/go-stripe-ms/internal/config"
 	"github.com/DraconDev/go-stripe-ms/internal/database"
-	"github.com/jackc/pgx/v5"
+	"github.com/DraconDev/go-stripe-ms/internal/database/migrations"
 	"github.com/joho/godotenv"
 )
 
+const usage = `Usage: go run ./cmd/migrate [--dry-run] <command>
+
+Commands:
+  up        apply all pending migrations
+  down N    roll back the last N applied migrations
+  redo      roll back the last applied migration and apply it again
+  status    list migrations and whether they are applied
+
+Flags (before or after the command):
+  --dry-run print the SQL of up, down or redo instead of running it
+`
+
+// commandArgs is the most arguments each command takes, counting the command itself
+var commandArgs = map[string]int{"up": 1, "down": 2, "redo": 1, "status": 1}
+
 func main() {
+	dryRun := flag.Bool("dry-run", false, "print the SQL instead of running it")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+
+	args := parseArgs()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	// Refuse anything unexpected before touching the database
+	if max, ok := commandArgs[args[0]]; ok && len(args) > max {
+		log.Fatalf("Unexpected arguments after %s: %v", args[0], args[max:])
+	}
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
@@ -25,172 +56,107 @@ func main() {
 
 	// Connect to database
 	ctx := context.Background()
-	conn, err := pgx.Connect(ctx, dbURL)
+	poolConfig, err := config.LoadDatabasePool().PoolConfig(dbURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Fatalf("Invalid database configuration: %v", err)
 	}
-	defer conn.Close(ctx)
-
-	fmt.Println("🔄 Starting database migration...")
-	fmt.Println()
-
-	// Step 1: Create projects table
-	fmt.Println("1. Creating projects table...")
-	_, err = conn.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS projects (
-			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			name VARCHAR(255) NOT NULL,
-			api_key VARCHAR(64) UNIQUE NOT NULL,
-			webhook_url TEXT,
-			is_active BOOLEAN DEFAULT true,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
-		)
-	`)
+	pool, err := database.NewPool(ctx, poolConfig)
 	if err != nil {
-		log.Fatalf("Failed to create projects table: %v", err)
+		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	fmt.Println("   ✅ Projects table created")
+	defer pool.Close()
 
-	// Step 2: Create default project
-	fmt.Println("2. Creating default project...")
-	repo := database.NewRepository(conn)
-
-	// Check if default project already exists
-	var projectCount int
-	err = conn.QueryRow(ctx, "SELECT COUNT(*) FROM projects").Scan(&projectCount)
+	migrator, err := migrations.New(pool)
 	if err != nil {
-		log.Fatalf("Failed to check projects: %v", err)
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if *dryRun {
+		migrator.SetDryRun(os.Stdout)
 	}
 
-	var defaultProjectID string
-	var apiKey string
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		report("Applied", applied, *dryRun)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Schema is up to date")
+		}
 
-	if projectCount == 0 {
-		project, err := repo.CreateProject(ctx, "Default Project", "")
+	case "down":
+		if len(args) < 2 {
+			log.Fatal("down needs the number of migrations to roll back, e.g. `down 1`")
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			log.Fatalf("Invalid number of migrations '%s'", args[1])
+		}
+		rolledBack, err := migrator.Down(ctx, n)
+		report("Rolled back", rolledBack, *dryRun)
 		if err != nil {
-			log.Fatalf("Failed to create default project: %v", err)
+			log.Fatalf("Rollback failed: %v", err)
 		}
-		defaultProjectID = project.ID.String()
-		apiKey = project.APIKey
-		fmt.Printf("   ✅ Default project created (ID: %s)\n", defaultProjectID)
-	} else {
-		// Get existing project
-		var id, key string
-		err = conn.QueryRow(ctx, "SELECT id, api_key FROM projects LIMIT 1").Scan(&id, &key)
+
+	case "redo":
+		redone, err := migrator.Redo(ctx)
 		if err != nil {
-			log.Fatalf("Failed to get existing project: %v", err)
+			log.Fatalf("Redo failed: %v", err)
+		}
+		if !*dryRun {
+			fmt.Printf("Redid %s\n", redone)
 		}
-		defaultProjectID = id
-		apiKey = key
-		fmt.Printf("   ℹ️  Using existing project (ID: %s)\n", defaultProjectID)
-	}
 
-	// Step 3: Add project_id to customers table (if not exists)
-	fmt.Println("3. Adding project_id to customers table...")
-	_, err = conn.Exec(ctx, `
-		ALTER TABLE customers 
-		ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE CASCADE
-	`)
-	if err != nil {
-		log.Fatalf("Failed to add project_id to customers: %v", err)
-	}
-	fmt.Println("   ✅ project_id column added to customers")
-
-	// Step 4: Update existing customers with default project
-	fmt.Println("4. Updating existing customers...")
-	result, err := conn.Exec(ctx, `
-		UPDATE customers 
-		SET project_id = $1 
-		WHERE project_id IS NULL
-	`, defaultProjectID)
-	if err != nil {
-		log.Fatalf("Failed to update customers: %v", err)
-	}
-	rowsAffected := result.RowsAffected()
-	fmt.Printf("   ✅ Updated %d customer records\n", rowsAffected)
-
-	// Step 5: Add project_id to subscriptions table (if not exists)
-	fmt.Println("5. Adding project_id to subscriptions table...")
-	_, err = conn.Exec(ctx, `
-		ALTER TABLE subscriptions 
-		ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE CASCADE
-	`)
-	if err != nil {
-		log.Fatalf("Failed to add project_id to subscriptions: %v", err)
-	}
-	fmt.Println("   ✅ project_id column added to subscriptions")
-
-	// Step 6: Update existing subscriptions with default project
-	fmt.Println("6. Updating existing subscriptions...")
-	result, err = conn.Exec(ctx, `
-		UPDATE subscriptions 
-		SET project_id = $1 
-		WHERE project_id IS NULL
-	`, defaultProjectID)
-	if err != nil {
-		log.Fatalf("Failed to update subscriptions: %v", err)
-	}
-	rowsAffected = result.RowsAffected()
-	fmt.Printf("   ✅ Updated %d subscription records\n", rowsAffected)
-
-	// Step 7: Drop old unique constraints and create new ones
-	fmt.Println("7. Updating constraints...")
-
-	// Drop old constraint on customers
-	_, _ = conn.Exec(ctx, `ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_user_id_key`)
-	_, err = conn.Exec(ctx, `
-		CREATE UNIQUE INDEX IF NOT EXISTS customers_project_user_unique 
-		ON customers(project_id, user_id)
-	`)
-	if err != nil {
-		log.Printf("   ⚠️  Warning: Could not create unique index on customers: %v", err)
-	} else {
-		fmt.Println("   ✅ Updated customers unique constraint")
-	}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Failed to get migration status: %v", err)
+		}
+		pending := 0
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied() {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			} else {
+				pending++
+			}
+			if status.Modified {
+				state += " (MODIFIED since applied)"
+			}
+			if status.Missing {
+				state += " (MISSING from this build)"
+			}
+			fmt.Printf("%04d_%-32s %s\n", status.Version, status.Name, state)
+		}
+		fmt.Printf("\n%d pending\n", pending)
 
-	// Drop old constraint on subscriptions
-	_, _ = conn.Exec(ctx, `DROP INDEX IF EXISTS subscriptions_user_id_product_id_key`)
-	_, err = conn.Exec(ctx, `
-		CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_project_user_product_unique 
-		ON subscriptions(project_id, user_id, product_id)
-	`)
-	if err != nil {
-		log.Printf("   ⚠️  Warning: Could not create unique index on subscriptions: %v", err)
-	} else {
-		fmt.Println("   ✅ Updated subscriptions unique constraint")
+	default:
+		flag.Usage()
+		os.Exit(2)
 	}
+}
 
-	// Step 8: Create indexes
-	fmt.Println("8. Creating indexes...")
-	indexes := []string{
-		`CREATE INDEX IF NOT EXISTS idx_projects_api_key ON projects(api_key)`,
-		`CREATE INDEX IF NOT EXISTS idx_customers_project_id ON customers(project_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_subscriptions_project_id ON subscriptions(project_id)`,
+// parseArgs parses the flags given before, between or after the positional arguments and returns
+// the positional ones. The flag package stops at the first positional argument, which would make
+// `down 1 --dry-run` roll back for real.
+func parseArgs() []string {
+	flag.Parse()
+
+	var args []string
+	for rest := flag.Args(); len(rest) > 0; rest = flag.Args() {
+		args = append(args, rest[0])
+		flag.CommandLine.Parse(rest[1:]) // Exits on an invalid flag
 	}
+	return args
+}
 
-	for _, idx := range indexes {
-		_, err = conn.Exec(ctx, idx)
-		if err != nil {
-			log.Printf("   ⚠️  Warning: Could not create index: %v", err)
-		}
+// report prints the migrations that were applied or rolled back
+func report(action string, done []*migrations.Migration, dryRun bool) {
+	if dryRun {
+		return
+	}
+	for _, migration := range done {
+		fmt.Printf("%s %s\n", action, migration)
 	}
-	fmt.Println("   ✅ Indexes created")
-
-	// Done!
-	fmt.Println()
-	fmt.Println("✅ Migration completed successfully!")
-	fmt.Println()
-	fmt.Println("═══════════════════════════════════════════════════════")
-	fmt.Println("🔑 YOUR API KEY (save this!):")
-	fmt.Println()
-	fmt.Printf("   %s\n", apiKey)
-	fmt.Println()
-	fmt.Println("═══════════════════════════════════════════════════════")
-	fmt.Println()
-	fmt.Println("Example usage:")
-	fmt.Printf("  curl -H \"X-API-Key: %s\" http://localhost:9000/health\n", apiKey)
-	fmt.Println()
-	fmt.Println("💡 Tip: You can use this same API key for all your projects!")
-	fmt.Println()
 }
@@ -7,8 +7,8 @@ import (
 
 	"github.com/DraconDev/go-stripe-ms/internal/config"
 	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/database/migrations"
 
-	"github.com/jackc/pgx/v5"
 )
 
 func main() {
@@ -19,18 +19,23 @@ func main() {
 	}
 
 	// Connect to database
-	conn, err := pgx.Connect(context.Background(), cfg.DatabaseURL)
+	poolConfig, err := cfg.GetDatabasePoolConfig()
+	if err != nil {
+		log.Fatalf("Invalid database configuration: %v", err)
+	}
+	pool, err := database.NewPool(context.Background(), poolConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer conn.Close(context.Background())
-
-	// Initialize database repository
-	repo := database.NewRepository(conn)
+	defer pool.Close()
 
-	// Create tables if they don't exist
-	if err := repo.InitializeTables(context.Background()); err != nil {
-		log.Fatalf("Failed to initialize tables: %v", err)
+	// Seed only a current schema
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if err := migrator.CheckCurrent(context.Background()); err != nil {
+		log.Fatalf("%v (run `go run ./cmd/migrate up`)", err)
 	}
 
 	// Insert test user
@@ -43,7 +48,7 @@ func main() {
 	
 	// Insert customer directly
 	customerID := "123e4567-e89b-12d3-a456-426614174000" // UUID for test
-	_, err = conn.Exec(context.Background(), `
+	_, err = pool.Exec(context.Background(), `
 		INSERT INTO customers (id, user_id, email, stripe_customer_id, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, NOW(), NOW())
 		ON CONFLICT (user_id) DO UPDATE SET
@@ -62,7 +67,7 @@ func main() {
 	stripeSubID := "sub_test_001"
 	status := "active"
 
-	_, err = conn.Exec(context.Background(), `
+	_, err = pool.Exec(context.Background(), `
 		INSERT INTO subscriptions (customer_id, user_id, product_id, price_id, stripe_subscription_id, status, current_period_start, current_period_end, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, NOW() - INTERVAL '15 days', NOW() + INTERVAL '15 days', NOW(), NOW())
 		ON CONFLICT (user_id, product_id) DO UPDATE SET
@@ -89,7 +94,7 @@ func main() {
 	fmt.Printf("  Status: %s\n", status)
 
 	// Query to show current data
-	rows, err := conn.Query(context.Background(), `
+	rows, err := pool.Query(context.Background(), `
 		SELECT 
 			c.user_id,
 			c.email,
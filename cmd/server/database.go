@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/DraconDev/go-stripe-ms/internal/config"
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/database/migrations"
+)
+
+// initDatabase initializes the database connection
+func initDatabase(cfg *config.Config) (*database.Repository, error) {
+	log.Printf("Using database URL: %s", cfg.DatabaseURL)
+
+	// Connect to the actual database through a pool, requests and workers query concurrently
+	poolConfig, err := cfg.GetDatabasePoolConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
+	}
+	pool, err := database.NewPool(context.Background(), poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// Refuse to start on an outdated schema, migrations are applied with cmd/migrate
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if err := migrator.CheckCurrent(context.Background()); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("%w (run `go run ./cmd/migrate up`)", err)
+	}
+
+	repo := database.NewRepository(pool)
+
+	log.Printf("Database pool initialized successfully (min %d, max %d connections)", poolConfig.MinConns, poolConfig.MaxConns)
+
+	return repo, nil
+}
@@ -1,21 +1,18 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/DraconDev/go-stripe-ms/internal/config"
 	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/dunning"
 	handlerSvc "github.com/DraconDev/go-stripe-ms/internal/handlers"
-	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+	"github.com/DraconDev/go-stripe-ms/internal/notifications"
 	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
-	"github.com/jackc/pgx/v5"
 	"github.com/joho/godotenv"
 )
 
@@ -26,6 +23,9 @@ type Server struct {
 	db             *database.Repository
 	apiServer      *handlerSvc.HTTPServer
 	webhookHandler *webhooks.StripeWebhookHandler
+	webhookQueue   *webhooks.Queue
+	dunningEval    *dunning.Evaluator
+	notifier       *notifications.Notifier
 }
 
 // NewServer creates a new HTTP-only server instance
@@ -41,36 +41,36 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 	// Initialize webhook handler
 	webhookHandler := webhooks.NewStripeWebhookHandler(db, cfg.StripeSecretKey, cfg.StripeWebhookSecret)
+	webhookHandler.SetSignatureTolerance(cfg.WebhookSignatureTolerance)
+
+	// Initialize outbound project webhook notifications
+	notifier := notifications.NewNotifier(db, cfg.ProjectWebhookMaxAttempts, cfg.ProjectWebhookBaseDelay)
+	webhookHandler.SetNotifier(notifier)
+
+	// Initialize the worker pool that processes queued Stripe events
+	webhookQueue := webhooks.NewQueue(webhookHandler, webhooks.QueueConfig{
+		Workers:     cfg.WebhookQueueWorkers,
+		MaxAttempts: cfg.WebhookQueueMaxAttempts,
+		BaseBackoff: cfg.WebhookQueueBaseBackoff,
+	})
+
+	// Admin event replays run through the same dispatch and retry policy as the queue
+	apiServer.SetEventReplayer(webhookQueue)
+
+	// Initialize the evaluator that moves failed subscription payments through dunning
+	dunningEval := dunning.NewEvaluator(db, notifier, cfg.DunningEvalInterval)
 
 	return &Server{
 		config:         cfg,
 		db:             db,
 		apiServer:      apiServer,
 		webhookHandler: webhookHandler,
+		webhookQueue:   webhookQueue,
+		dunningEval:    dunningEval,
+		notifier:       notifier,
 	}, nil
 }
 
-// initDatabase initializes the database connection
-func initDatabase(cfg *config.Config) (*database.Repository, error) {
-	log.Printf("Using database URL: %s", cfg.DatabaseURL)
-
-	// Connect to the actual database
-	conn, err := pgx.Connect(context.Background(), cfg.DatabaseURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
-	}
-
-	// Initialize database tables
-	repo := database.NewRepository(conn)
-	if err := repo.InitializeTables(context.Background()); err != nil {
-		return nil, fmt.Errorf("failed to initialize database tables: %w", err)
-	}
-
-	log.Println("Database connection initialized successfully")
-
-	return repo, nil
-}
-
 // StartHTTPServer starts the HTTP server with all endpoints
 func (s *Server) StartHTTPServer() error {
 	mux := http.NewServeMux()
@@ -97,43 +97,6 @@ func (s *Server) StartHTTPServer() error {
 	return nil
 }
 
-// setupAPIRoutes sets up all HTTP routes for the billing API
-func (s *Server) setupAPIRoutes(mux *http.ServeMux) {
-	// Initialize middleware
-	authMiddleware := middleware.NewAPIKeyAuth(s.db)
-
-	// Public endpoints (no authentication required)
-	mux.HandleFunc("/", s.apiServer.RootHandler)
-	mux.HandleFunc("/health", s.apiServer.HealthCheck)
-
-	// API Documentation endpoints (public)
-	mux.HandleFunc("/openapi.json", s.apiServer.OpenAPIHandler)
-	mux.HandleFunc("/docs", s.apiServer.DocsHandler)
-
-	// Webhook endpoint (authenticated by Stripe signature, not API key)
-	s.webhookHandler.SetupRoutes(mux)
-
-	// Protected API endpoints (require X-API-Key header)
-	// Protected endpoints (require API key)
-	mux.Handle("/api/v1/checkout/item", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.CreateItemCheckout)))
-	mux.Handle("/api/v1/checkout/cart", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.CreateCartCheckout)))
-	mux.Handle("/api/v1/checkout/subscription", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.CreateSubscriptionCheckout)))
-	mux.Handle("/api/v1/subscriptions/{user_id}/{product_id}", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.GetSubscriptionStatus)))
-	mux.Handle("/api/v1/portal", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.CreateCustomerPortal)))
-
-	// Admin endpoints (protected by same API key)
-	mux.Handle("/admin/products/register", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.RegisterProducts)))
-
-	// Debug endpoint (development only)
-	env := os.Getenv("ENVIRONMENT")
-	if env == "" {
-		env = "development"
-	}
-	if env != "production" {
-		mux.HandleFunc("/debug", s.apiServer.DebugHandler)
-	}
-}
-
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	log.Printf("Starting billing service (HTTP-only)")
@@ -144,48 +107,19 @@ func (s *Server) Start() error {
 	}
 
 	log.Println("HTTP server started successfully")
-	return nil
-}
 
-// Stop gracefully shuts down the server
-func (s *Server) Stop() error {
-	log.Println("Shutting down server...")
+	// Start processing queued Stripe events
+	s.webhookQueue.Start()
 
-	// Create context for graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// Start evaluating subscriptions in dunning
+	s.dunningEval.Start()
 
-	// Shutdown HTTP server
-	if s.httpServer != nil {
-		log.Println("Shutting down HTTP server...")
-		if err := s.httpServer.Shutdown(ctx); err != nil {
-			log.Printf("HTTP server shutdown error: %v", err)
-		}
-	}
+	// Start delivering project notifications, including those a previous run left undelivered
+	s.notifier.Start()
 
-	log.Println("Server shutdown complete")
 	return nil
 }
 
-// Run runs the server with graceful shutdown handling
-func (s *Server) Run() error {
-	// Start server
-	if err := s.Start(); err != nil {
-		return fmt.Errorf("failed to start server: %w", err)
-	}
-
-	// Set up signal handling for graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	// Wait for shutdown signal
-	<-quit
-	log.Println("Received shutdown signal")
-
-	// Graceful shutdown
-	return s.Stop()
-}
-
 func main() {
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// setupAPIRoutes sets up all HTTP routes for the billing API
+func (s *Server) setupAPIRoutes(mux *http.ServeMux) {
+	// Initialize middleware
+	authMiddleware := middleware.NewAPIKeyAuth(s.db)
+	adminAuth := middleware.NewAdminKeyAuth(s.config.APIKey)
+
+	// Public endpoints (no authentication required)
+	mux.HandleFunc("/", s.apiServer.RootHandler)
+	mux.HandleFunc("/health", s.apiServer.HealthCheck)
+	mux.HandleFunc("/metrics", s.apiServer.Metrics)
+
+	// API Documentation endpoints (public)
+	mux.HandleFunc("/openapi.json", s.apiServer.OpenAPIHandler)
+	mux.HandleFunc("/docs", s.apiServer.DocsHandler)
+
+	// Webhook endpoint (authenticated by Stripe signature, not API key)
+	s.webhookHandler.SetupRoutes(mux)
+
+	// Protected API endpoints (require X-API-Key header)
+	// Protected endpoints (require API key)
+	mux.Handle("/api/v1/checkout/item", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.CreateItemCheckout)))
+	mux.Handle("/api/v1/checkout/cart", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.CreateCartCheckout)))
+	mux.Handle("/api/v1/checkout/subscription", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.CreateSubscriptionCheckout)))
+	mux.Handle("/api/v1/subscriptions/{user_id}/{product_id}", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.GetSubscriptionStatus)))
+	mux.Handle("/api/v1/subscriptions/{user_id}/{product_id}/history", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.GetSubscriptionHistory)))
+	mux.Handle("/api/v1/orders/{user_id}", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.ListOrders)))
+	mux.Handle("/api/v1/invoices/{user_id}", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.ListInvoices)))
+	mux.Handle("/api/v1/customers/{user_id}", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.EraseCustomer)))
+	mux.Handle("/api/v1/customers/{user_id}/export", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.ExportCustomer)))
+	mux.Handle("/api/v1/portal", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.CreateCustomerPortal)))
+
+	// Admin endpoints (protected by same API key)
+	mux.Handle("/admin/products/register", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.RegisterProducts)))
+	mux.Handle("/admin/dunning", authMiddleware.Middleware(http.HandlerFunc(s.apiServer.DunningSettings)))
+
+	// Service-wide admin endpoints (require the service API key and X-Admin-User)
+	mux.Handle("/admin/events", adminAuth.Middleware(http.HandlerFunc(s.apiServer.ListEvents)))
+	mux.Handle("/admin/events/replay", adminAuth.Middleware(http.HandlerFunc(s.apiServer.ReplayEvents)))
+	mux.Handle("/admin/events/{event_id}", adminAuth.Middleware(http.HandlerFunc(s.apiServer.GetEvent)))
+	mux.Handle("/admin/events/{event_id}/replay", adminAuth.Middleware(http.HandlerFunc(s.apiServer.ReplayEvent)))
+	mux.Handle("/admin/events/{event_id}/ignore", adminAuth.Middleware(http.HandlerFunc(s.apiServer.IgnoreEvent)))
+
+	// Debug endpoint (development only)
+	env := os.Getenv("ENVIRONMENT")
+	if env == "" {
+		env = "development"
+	}
+	if env != "production" {
+		mux.HandleFunc("/debug", s.apiServer.DebugHandler)
+	}
+}
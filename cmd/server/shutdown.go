@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Stop gracefully shuts down the server
+func (s *Server) Stop() error {
+	log.Println("Shutting down server...")
+
+	// Create context for graceful shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Shutdown HTTP server
+	if s.httpServer != nil {
+		log.Println("Shutting down HTTP server...")
+		if err := s.httpServer.Shutdown(ctx); err != nil {
+			log.Printf("HTTP server shutdown error: %v", err)
+		}
+	}
+
+	// Drain in-flight Stripe event processing
+	if s.webhookQueue != nil {
+		log.Println("Draining webhook queue...")
+		if err := s.webhookQueue.Stop(ctx); err != nil {
+			log.Printf("Webhook queue shutdown error: %v", err)
+		}
+	}
+
+	// Stop the dunning evaluator
+	if s.dunningEval != nil {
+		if err := s.dunningEval.Stop(ctx); err != nil {
+			log.Printf("Dunning evaluator shutdown error: %v", err)
+		}
+	}
+
+	// Wait for in-flight project webhook deliveries; pending retries stay stored for the next start
+	if s.notifier != nil {
+		log.Println("Waiting for project webhook deliveries...")
+		if err := s.notifier.Close(ctx); err != nil {
+			log.Printf("Project webhook delivery shutdown error: %v", err)
+		}
+	}
+
+	// Close the database pool once nothing uses it anymore
+	if s.db != nil {
+		s.db.Close()
+	}
+
+	log.Println("Server shutdown complete")
+	return nil
+}
+
+// Run runs the server with graceful shutdown handling
+func (s *Server) Run() error {
+	// Start server
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+
+	// Set up signal handling for graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	// Wait for shutdown signal
+	<-quit
+	log.Println("Received shutdown signal")
+
+	// Graceful shutdown
+	return s.Stop()
+}
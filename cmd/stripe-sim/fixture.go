@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// fixture holds the IDs a scenario's events refer to. Customer, product and price come from
+// the local database so the server can match them; everything else is unique to the run.
+type fixture struct {
+	ProjectID  uuid.UUID
+	UserID     string
+	CustomerID string
+	ProductID  string
+	PriceID    string
+	Amount     int64
+	Currency   string
+
+	Suffix                 string
+	SubscriptionID         string
+	SubscriptionItemID     string
+	InvoiceID              string
+	InvoicePaymentIntentID string
+	CheckoutSessionID      string
+	OneTimePriceID         string
+	PaymentIntentID        string
+	ChargeID               string
+
+	Now         int64
+	PeriodStart int64
+	PeriodEnd   int64
+}
+
+// loadFixture picks the customer to simulate events for and the catalog price of its project
+func loadFixture(ctx context.Context, pool *pgxpool.Pool, projectID *uuid.UUID, userID string) (*fixture, error) {
+	f := &fixture{Currency: "usd"}
+	err := pool.QueryRow(ctx, `
+		SELECT project_id, user_id, stripe_customer_id
+		FROM customers
+		WHERE stripe_customer_id IS NOT NULL AND stripe_customer_id <> ''
+			AND ($1::uuid IS NULL OR project_id = $1)
+			AND ($2 = '' OR user_id = $2)
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, projectID, userID).Scan(&f.ProjectID, &f.UserID, &f.CustomerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("no customer with a Stripe customer ID found, create one with a checkout first or run cmd/seed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Prefer an active product of the customer's project so subscriptions resolve to a plan
+	err = pool.QueryRow(ctx, `
+		SELECT rp.stripe_product_id, rp.stripe_price_monthly, COALESCE(rp.monthly_amount, 0), COALESCE(rp.currency, 'usd')
+		FROM registered_products rp
+		WHERE rp.project_id = $1 AND rp.active AND COALESCE(rp.stripe_price_monthly, '') <> ''
+		ORDER BY rp.created_at DESC
+		LIMIT 1
+	`, f.ProjectID).Scan(&f.ProductID, &f.PriceID, &f.Amount, &f.Currency)
+	if errors.Is(err, pgx.ErrNoRows) {
+		f.ProductID, f.PriceID = "prod_sim", "price_sim_monthly"
+	} else if err != nil {
+		return nil, err
+	}
+	if f.Amount == 0 {
+		f.Amount = 2000
+	}
+
+	return f, nil
+}
+
+// loadProjectWebhookSecret returns the project's own webhook secret, empty if it uses the service account
+func loadProjectWebhookSecret(ctx context.Context, pool *pgxpool.Pool, projectID uuid.UUID) (string, error) {
+	var secret string
+	err := pool.QueryRow(ctx, `SELECT COALESCE(stripe_webhook_secret, '') FROM projects WHERE id = $1`, projectID).Scan(&secret)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return secret, err
+}
+
+// newFixture copies the database IDs and gives the objects of one scenario run unique IDs
+func newFixture(base *fixture, suffix string) *fixture {
+	f := *base
+	now := time.Now()
+	f.Suffix = suffix
+	f.SubscriptionID = "sub_sim_" + suffix
+	f.SubscriptionItemID = "si_sim_" + suffix
+	f.InvoiceID = "in_sim_" + suffix
+	f.InvoicePaymentIntentID = "pi_sim_inv_" + suffix
+	f.CheckoutSessionID = "cs_sim_" + suffix
+	f.OneTimePriceID = "price_sim_once_" + suffix
+	f.PaymentIntentID = "pi_sim_" + suffix
+	f.ChargeID = "ch_sim_" + suffix
+	f.Now = now.Unix()
+	f.PeriodStart = now.Unix()
+	f.PeriodEnd = now.AddDate(0, 1, 0).Unix()
+	return &f
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/config"
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	scenarioName := flag.String("scenario", "", "scenario to replay, or 'all'")
+	list := flag.Bool("list", false, "list the available scenarios")
+	endpoint := flag.String("url", "", "webhook endpoint (default http://localhost:$HTTP_PORT/webhooks/stripe)")
+	projectFlag := flag.String("project", "", "project ID to take the customer from; uses the project's endpoint if it has its own webhook secret")
+	userID := flag.String("user", "", "user ID of the customer (default: the newest customer with a Stripe ID)")
+	secretFlag := flag.String("secret", "", "webhook secret to sign with (default: STRIPE_WEBHOOK_SECRET)")
+	delay := flag.Duration("delay", 500*time.Millisecond, "pause between events so the queue processes them in order")
+	dryRun := flag.Bool("dry-run", false, "print the signed payloads instead of posting them")
+	flag.Parse()
+
+	if *list {
+		for _, s := range scenarios {
+			fmt.Printf("%-24s %s\n", s.Name, s.Description)
+		}
+		return
+	}
+
+	if *scenarioName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var selected []scenario
+	if *scenarioName == "all" {
+		selected = scenarios
+	} else if s, ok := findScenario(*scenarioName); ok {
+		selected = []scenario{s}
+	} else {
+		log.Fatalf("Unknown scenario '%s', run with -list to see the available scenarios", *scenarioName)
+	}
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL environment variable is required")
+	}
+
+	ctx := context.Background()
+	poolConfig, err := config.LoadDatabasePool().PoolConfig(dbURL)
+	if err != nil {
+		log.Fatalf("Invalid database configuration: %v", err)
+	}
+	pool, err := database.NewPool(ctx, poolConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	var projectID *uuid.UUID
+	if *projectFlag != "" {
+		id, err := uuid.Parse(*projectFlag)
+		if err != nil {
+			log.Fatalf("Invalid project ID '%s': %v", *projectFlag, err)
+		}
+		projectID = &id
+	}
+
+	base, err := loadFixture(ctx, pool, projectID, *userID)
+	if err != nil {
+		log.Fatalf("Failed to load fixture: %v", err)
+	}
+
+	// Events for a project with its own Stripe account go to its endpoint, signed with its secret
+	target := *endpoint
+	if target == "" {
+		port := os.Getenv("HTTP_PORT")
+		if port == "" {
+			port = "8080"
+		}
+		target = "http://localhost:" + port + "/webhooks/stripe"
+	}
+	secret := *secretFlag
+	projectSecret, err := loadProjectWebhookSecret(ctx, pool, base.ProjectID)
+	if err != nil {
+		log.Fatalf("Failed to load project: %v", err)
+	}
+	if projectSecret != "" {
+		target = strings.TrimSuffix(target, "/") + "/" + base.ProjectID.String()
+		if secret == "" {
+			secret = projectSecret
+		}
+	}
+	if secret == "" {
+		if secrets := webhooks.ParseSecrets(os.Getenv("STRIPE_WEBHOOK_SECRET")); len(secrets) > 0 {
+			secret = secrets[0]
+		}
+	}
+	if secret == "" && !*dryRun {
+		log.Fatal("STRIPE_WEBHOOK_SECRET or -secret is required to sign events")
+	}
+
+	fmt.Printf("Customer %s (user: %s, project: %s), product %s, price %s\n",
+		base.CustomerID, base.UserID, base.ProjectID, base.ProductID, base.PriceID)
+	fmt.Printf("Sending to %s\n", target)
+
+	runID := time.Now().Format("20060102150405")
+	sent := 0
+	for n, s := range selected {
+		f := newFixture(base, fmt.Sprintf("%s_%d", runID, n+1))
+		steps := s.Steps(f)
+		fmt.Printf("\n== %s: %s (%d events)\n", s.Name, s.Description, len(steps))
+
+		// Created timestamps increase by one second so the server sees the intended order
+		start := f.Now - int64(len(steps))
+		for i, st := range steps {
+			payload, err := render(f, st, i, start+int64(i))
+			if err != nil {
+				log.Fatalf("Failed to build %s: %v", st.EventType, err)
+			}
+
+			if *dryRun {
+				fmt.Printf("%s\n", payload)
+				continue
+			}
+			if err := post(target, secret, payload); err != nil {
+				log.Fatalf("Failed to send %s: %v", st.EventType, err)
+			}
+			fmt.Printf("  sent %s\n", st.EventType)
+			sent++
+			time.Sleep(*delay)
+		}
+	}
+
+	if !*dryRun {
+		fmt.Printf("\nSent %d events\n", sent)
+	}
+}
+
+// post signs the payload like Stripe does and sends it to the webhook endpoint
+func post(url, secret string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", webhooks.SignPayload(payload, secret, time.Now()))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("server responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
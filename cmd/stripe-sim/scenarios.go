@@ -0,0 +1,109 @@
+package main
+
+// step is one event of a scenario, rendered from a named object template
+type step struct {
+	EventType string
+	Template  string
+	With      map[string]interface{}
+}
+
+// scenario is a lifecycle replayed in order against the webhook endpoint
+type scenario struct {
+	Name        string
+	Description string
+	Steps       func(f *fixture) []step
+}
+
+const day = int64(24 * 60 * 60)
+
+var scenarios = []scenario{
+	{
+		Name:        "subscription-lifecycle",
+		Description: "trial, activation, cancellation at period end and deletion of a subscription",
+		Steps: func(f *fixture) []step {
+			trial := map[string]interface{}{"trial_start": f.PeriodStart, "trial_end": f.PeriodStart + 14*day}
+			return []step{
+				{"customer.subscription.created", "subscription", merge(trial, map[string]interface{}{"status": "trialing"})},
+				{"customer.subscription.updated", "subscription", merge(trial, map[string]interface{}{"status": "active"})},
+				{"customer.subscription.updated", "subscription", merge(trial, map[string]interface{}{
+					"status": "active", "cancel_at_period_end": true, "canceled_at": f.Now,
+				})},
+				{"customer.subscription.deleted", "subscription", merge(trial, map[string]interface{}{
+					"status": "canceled", "canceled_at": f.Now, "ended_at": f.Now,
+				})},
+			}
+		},
+	},
+	{
+		Name:        "checkout",
+		Description: "a completed one-time checkout session",
+		Steps: func(f *fixture) []step {
+			return []step{
+				{"checkout.session.completed", "checkout_session", nil},
+			}
+		},
+	},
+	{
+		Name:        "invoice-recovery",
+		Description: "an active subscription whose invoice fails twice, enters dunning and is then paid",
+		Steps: func(f *fixture) []step {
+			return []step{
+				{"customer.subscription.created", "subscription", map[string]interface{}{"status": "active"}},
+				{"invoice.finalized", "invoice", map[string]interface{}{"status": "open", "amount_remaining": f.Amount}},
+				{"invoice.payment_failed", "invoice", map[string]interface{}{
+					"status": "open", "amount_remaining": f.Amount, "attempt_count": 1, "next_payment_attempt": f.Now + 3*day,
+				}},
+				{"customer.subscription.updated", "subscription", map[string]interface{}{"status": "past_due"}},
+				{"invoice.payment_failed", "invoice", map[string]interface{}{
+					"status": "open", "amount_remaining": f.Amount, "attempt_count": 2, "next_payment_attempt": f.Now + 5*day,
+				}},
+				{"invoice.payment_succeeded", "invoice", map[string]interface{}{
+					"status": "paid", "attempt_count": 3, "amount_paid": f.Amount, "amount_remaining": 0, "paid_at": f.Now,
+				}},
+				{"invoice.paid", "invoice", map[string]interface{}{
+					"status": "paid", "attempt_count": 3, "amount_paid": f.Amount, "amount_remaining": 0, "paid_at": f.Now,
+				}},
+				{"customer.subscription.updated", "subscription", map[string]interface{}{"status": "active"}},
+			}
+		},
+	},
+	{
+		Name:        "refund",
+		Description: "a one-time checkout refunded in two parts until fully refunded",
+		Steps: func(f *fixture) []step {
+			half := f.Amount / 2
+			first := map[string]interface{}{"id": "re_sim_" + f.Suffix + "_1", "amount": half}
+			second := map[string]interface{}{"id": "re_sim_" + f.Suffix + "_2", "amount": f.Amount - half}
+			return []step{
+				{"checkout.session.completed", "checkout_session", nil},
+				{"charge.refunded", "charge", map[string]interface{}{
+					"amount_refunded": half, "refunds": []map[string]interface{}{first},
+				}},
+				{"charge.refunded", "charge", map[string]interface{}{
+					"amount_refunded": f.Amount, "refunded": true, "refunds": []map[string]interface{}{second, first},
+				}},
+			}
+		},
+	},
+}
+
+// findScenario returns the scenario with the given name
+func findScenario(name string) (scenario, bool) {
+	for _, s := range scenarios {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return scenario{}, false
+}
+
+// merge returns a new map with the values of all maps, later maps winning
+func merge(maps ...map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, m := range maps {
+		for key, value := range m {
+			merged[key] = value
+		}
+	}
+	return merged
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// Object templates render the data.object of an event. They are executed with a templateData,
+// so every ID comes from the fixture and step specific values come from .With.
+var objectTemplates = template.Must(template.New("objects").Parse(`
+{{define "subscription"}}{
+	"id": "{{.SubscriptionID}}",
+	"object": "subscription",
+	"customer": "{{.CustomerID}}",
+	"status": "{{.With.status}}",
+	"items": {"object": "list", "data": [{
+		"id": "{{.SubscriptionItemID}}",
+		"object": "subscription_item",
+		"quantity": 1,
+		"price": {"id": "{{.PriceID}}", "object": "price", "product": "{{.ProductID}}", "unit_amount": {{.Amount}}, "currency": "{{.Currency}}"}
+	}]},
+	"current_period_start": {{.PeriodStart}},
+	"current_period_end": {{.PeriodEnd}},
+	"trial_start": {{or .With.trial_start 0}},
+	"trial_end": {{or .With.trial_end 0}},
+	"cancel_at_period_end": {{or .With.cancel_at_period_end false}},
+	"canceled_at": {{or .With.canceled_at 0}},
+	"ended_at": {{or .With.ended_at 0}},
+	"livemode": false
+}{{end}}
+
+{{define "invoice"}}{
+	"id": "{{.InvoiceID}}",
+	"object": "invoice",
+	"number": "SIM-{{.Suffix}}",
+	"customer": "{{.CustomerID}}",
+	"subscription": "{{.SubscriptionID}}",
+	"payment_intent": "{{.InvoicePaymentIntentID}}",
+	"billing_reason": "subscription_cycle",
+	"status": "{{.With.status}}",
+	"amount_due": {{.Amount}},
+	"amount_paid": {{or .With.amount_paid 0}},
+	"amount_remaining": {{.With.amount_remaining}},
+	"currency": "{{.Currency}}",
+	"attempt_count": {{or .With.attempt_count 0}},
+	"next_payment_attempt": {{or .With.next_payment_attempt 0}},
+	"period_start": {{.PeriodStart}},
+	"period_end": {{.PeriodEnd}},
+	"status_transitions": {"paid_at": {{or .With.paid_at 0}}},
+	"livemode": false
+}{{end}}
+
+{{define "checkout_session"}}{
+	"id": "{{.CheckoutSessionID}}",
+	"object": "checkout.session",
+	"mode": "payment",
+	"customer": "{{.CustomerID}}",
+	"payment_intent": "{{.PaymentIntentID}}",
+	"payment_status": "paid",
+	"status": "complete",
+	"amount_total": {{.Amount}},
+	"currency": "{{.Currency}}",
+	"metadata": {"payment_type": "item", "product_id": "{{.ProductID}}", "user_id": "{{.UserID}}"},
+	"line_items": {"object": "list", "data": [{
+		"id": "li_sim_{{.Suffix}}",
+		"object": "item",
+		"description": "Simulated purchase",
+		"quantity": 1,
+		"amount_total": {{.Amount}},
+		"currency": "{{.Currency}}",
+		"price": {"id": "{{.OneTimePriceID}}", "object": "price", "product": "{{.ProductID}}", "unit_amount": {{.Amount}}, "currency": "{{.Currency}}"}
+	}]},
+	"livemode": false
+}{{end}}
+
+{{define "charge"}}{
+	"id": "{{.ChargeID}}",
+	"object": "charge",
+	"customer": "{{.CustomerID}}",
+	"payment_intent": "{{.PaymentIntentID}}",
+	"amount": {{.Amount}},
+	"amount_refunded": {{.With.amount_refunded}},
+	"refunded": {{or .With.refunded false}},
+	"currency": "{{.Currency}}",
+	"status": "succeeded",
+	"refunds": {"object": "list", "data": [{{range $i, $refund := .With.refunds}}{{if $i}},{{end}}{
+		"id": "{{$refund.id}}",
+		"object": "refund",
+		"amount": {{$refund.amount}},
+		"charge": "{{$.ChargeID}}",
+		"payment_intent": "{{$.PaymentIntentID}}",
+		"currency": "{{$.Currency}}",
+		"reason": "requested_by_customer",
+		"status": "succeeded",
+		"created": {{$.Created}}
+	}{{end}}]},
+	"livemode": false
+}{{end}}
+`))
+
+// templateData is what an object template is executed with
+type templateData struct {
+	*fixture
+	Created int64
+	With    map[string]interface{}
+}
+
+// event is the envelope Stripe posts to webhook endpoints
+type event struct {
+	ID         string    `json:"id"`
+	Object     string    `json:"object"`
+	APIVersion string    `json:"api_version"`
+	Created    int64     `json:"created"`
+	Livemode   bool      `json:"livemode"`
+	Type       string    `json:"type"`
+	Data       eventData `json:"data"`
+}
+
+type eventData struct {
+	Object json.RawMessage `json:"object"`
+}
+
+// render builds the JSON payload of a step, created at the given unix time
+func render(f *fixture, s step, index int, created int64) ([]byte, error) {
+	var object bytes.Buffer
+	data := templateData{fixture: f, Created: created, With: s.With}
+	if err := objectTemplates.ExecuteTemplate(&object, s.Template, data); err != nil {
+		return nil, fmt.Errorf("error rendering %s template: %w", s.Template, err)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, object.Bytes()); err != nil {
+		return nil, fmt.Errorf("%s template produced invalid JSON: %w", s.Template, err)
+	}
+
+	return json.Marshal(event{
+		ID:         fmt.Sprintf("evt_sim_%s_%02d", f.Suffix, index+1),
+		Object:     "event",
+		APIVersion: "2020-08-27",
+		Created:    created,
+		Type:       s.EventType,
+		Data:       eventData{Object: compact.Bytes()},
+	})
+}
@@ -1,20 +1,39 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Config holds all application configuration
 type Config struct {
 	// Database
-	DatabaseURL string
+	DatabaseURL  string
+	DatabasePool DatabasePool
 
 	// Stripe Configuration
 	StripeSecretKey     string
-	StripeWebhookSecret string
+	StripeWebhookSecret string // Comma separated to allow rotating secrets
+
+	// Maximum age of a signed webhook payload
+	WebhookSignatureTolerance time.Duration
+
+	// Inbound Stripe event processing queue
+	WebhookQueueWorkers     int
+	WebhookQueueMaxAttempts int
+	WebhookQueueBaseBackoff time.Duration
+
+	// Interval of the dunning evaluator
+	DunningEvalInterval time.Duration
+
+	// Outbound project webhook delivery
+	ProjectWebhookMaxAttempts int
+	ProjectWebhookBaseDelay   time.Duration
 
 	// API Key for authentication
 	APIKey string
@@ -30,12 +49,27 @@ type Config struct {
 func LoadConfig() (*Config, error) {
 	cfg := &Config{
 		// Database
-		DatabaseURL: getEnvOrError("DATABASE_URL"),
+		DatabaseURL:  getEnvOrError("DATABASE_URL"),
+		DatabasePool: LoadDatabasePool(),
 
 		// Stripe
 		StripeSecretKey:     getEnvOrError("STRIPE_SECRET_KEY"),
 		StripeWebhookSecret: getEnvOrError("STRIPE_WEBHOOK_SECRET"),
 
+		WebhookSignatureTolerance: time.Duration(getEnvAsInt("STRIPE_WEBHOOK_TOLERANCE_SECONDS", 300)) * time.Second,
+
+		// Webhook queue
+		WebhookQueueWorkers:     getEnvAsInt("WEBHOOK_QUEUE_WORKERS", 4),
+		WebhookQueueMaxAttempts: getEnvAsInt("WEBHOOK_QUEUE_MAX_ATTEMPTS", 8),
+		WebhookQueueBaseBackoff: time.Duration(getEnvAsInt("WEBHOOK_QUEUE_BASE_BACKOFF_SECONDS", 5)) * time.Second,
+
+		// Dunning
+		DunningEvalInterval: time.Duration(getEnvAsInt("DUNNING_EVAL_INTERVAL_SECONDS", 300)) * time.Second,
+
+		// Project webhooks
+		ProjectWebhookMaxAttempts: getEnvAsInt("PROJECT_WEBHOOK_MAX_ATTEMPTS", 5),
+		ProjectWebhookBaseDelay:   time.Duration(getEnvAsInt("PROJECT_WEBHOOK_BASE_DELAY_SECONDS", 2)) * time.Second,
+
 		// API Key
 		APIKey: getEnvOrError("PAYMENT_MS_API_KEY"),
 
@@ -79,14 +113,55 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return intValue
 }
 
-// GetDatabasePoolConfig returns pgx pool configuration
-func (c *Config) GetDatabasePoolConfig() map[string]interface{} {
-	return map[string]interface{}{
-		"connString":        c.DatabaseURL,
-		"minConns":          5,
-		"maxConns":          25,
-		"maxConnLifetime":   time.Hour,
-		"maxConnIdleTime":   time.Minute * 30,
-		"healthCheckPeriod": time.Minute * 5,
+// DatabasePool holds the database connection pool settings
+type DatabasePool struct {
+	MinConns          int32
+	MaxConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+}
+
+// LoadDatabasePool loads the connection pool settings from environment variables.
+// It is used on its own by the command line tools that only need a database.
+func LoadDatabasePool() DatabasePool {
+	return DatabasePool{
+		MinConns:          int32(getEnvAsInt("DATABASE_MIN_CONNS", 5)),
+		MaxConns:          int32(getEnvAsInt("DATABASE_MAX_CONNS", 25)),
+		MaxConnLifetime:   time.Duration(getEnvAsInt("DATABASE_MAX_CONN_LIFETIME_SECONDS", 3600)) * time.Second,
+		MaxConnIdleTime:   time.Duration(getEnvAsInt("DATABASE_MAX_CONN_IDLE_SECONDS", 1800)) * time.Second,
+		HealthCheckPeriod: time.Duration(getEnvAsInt("DATABASE_HEALTH_CHECK_PERIOD_SECONDS", 300)) * time.Second,
 	}
 }
+
+// PoolConfig builds the pgx pool configuration for a database URL
+func (p DatabasePool) PoolConfig(databaseURL string) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %w", err)
+	}
+	if p.MaxConns > 0 {
+		poolConfig.MaxConns = p.MaxConns
+	}
+	if p.MinConns > poolConfig.MaxConns {
+		return nil, fmt.Errorf("database min connections (%d) exceed max connections (%d)", p.MinConns, poolConfig.MaxConns)
+	}
+	poolConfig.MinConns = p.MinConns
+
+	// Zero durations keep the pgxpool defaults
+	if p.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = p.MaxConnLifetime
+	}
+	if p.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = p.MaxConnIdleTime
+	}
+	if p.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = p.HealthCheckPeriod
+	}
+	return poolConfig, nil
+}
+
+// GetDatabasePoolConfig returns the pgx pool configuration of the service database
+func (c *Config) GetDatabasePoolConfig() (*pgxpool.Config, error) {
+	return c.DatabasePool.PoolConfig(c.DatabaseURL)
+}
@@ -0,0 +1,159 @@
+package customers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// ErrStripeCustomerNotDeleted is returned by EraseCustomer when Stripe failed to delete the customer
+var ErrStripeCustomerNotDeleted = errors.New("stripe customer could not be deleted")
+
+// Service links the customers of a project's users to Stripe customers
+type Service struct {
+	db     database.RepositoryInterface
+	stripe StripeAPI
+}
+
+// NewService creates a customer service creating Stripe customers through api
+func NewService(db database.RepositoryInterface, api StripeAPI) *Service {
+	return &Service{db: db, stripe: api}
+}
+
+// FindOrCreateStripeCustomer returns the Stripe customer ID of a project's user, creating the
+// Stripe customer on first use.
+//
+// The customer row is committed before Stripe is called, so a request that fails after creating
+// the Stripe customer leaves a row without a Stripe ID. The next request recovers it: the Stripe
+// customer is looked up by its metadata, and creating it again reuses the idempotency key derived
+// from the row, so Stripe returns the customer it already created. Concurrent first-time requests
+// for the same user are serialized with an advisory lock and create a single Stripe customer.
+func (s *Service) FindOrCreateStripeCustomer(ctx context.Context, projectID uuid.UUID, userID, email string) (string, error) {
+	customer, err := s.db.EnsureCustomer(ctx, projectID, userID, email)
+	if err != nil {
+		return "", fmt.Errorf("failed to store customer: %w", err)
+	}
+	if customer.StripeCustomerID != "" {
+		return customer.StripeCustomerID, nil
+	}
+
+	var stripeCustomerID string
+	err = s.db.WithTx(ctx, func(tx database.RepositoryInterface) error {
+		if err := tx.LockCustomer(ctx, projectID, userID); err != nil {
+			return fmt.Errorf("failed to lock customer: %w", err)
+		}
+
+		// Another request may have linked the Stripe customer while this one waited for the lock
+		locked, err := tx.GetCustomerByUserID(ctx, projectID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get customer: %w", err)
+		}
+		if locked.StripeCustomerID != "" {
+			stripeCustomerID = locked.StripeCustomerID
+			return nil
+		}
+
+		// The Stripe calls are idempotent, so they are safe to repeat if the transaction is retried
+		stripeCustomer, err := s.findOrCreateInStripe(locked, email)
+		if err != nil {
+			return err
+		}
+		if err := tx.UpdateCustomerStripeID(ctx, projectID, userID, stripeCustomer.ID); err != nil {
+			return fmt.Errorf("failed to store Stripe customer ID: %w", err)
+		}
+		stripeCustomerID = stripeCustomer.ID
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return stripeCustomerID, nil
+}
+
+// EraseCustomer erases the personal data stored about a project's user, see database.EraseCustomer.
+// With deleteStripeCustomer the user's Stripe customer is deleted first, which cancels its
+// subscriptions in Stripe; they end locally once the customer.deleted event arrives. It reports
+// whether a Stripe customer was deleted and returns database.ErrNotFound if the user has no customer.
+func (s *Service) EraseCustomer(ctx context.Context, projectID uuid.UUID, userID string, deleteStripeCustomer bool) (bool, error) {
+	var stripeCustomerDeleted bool
+	err := s.db.WithTx(ctx, func(tx database.RepositoryInterface) error {
+		// Keeps a concurrent checkout from creating a Stripe customer for the user meanwhile
+		if err := tx.LockCustomer(ctx, projectID, userID); err != nil {
+			return fmt.Errorf("failed to lock customer: %w", err)
+		}
+
+		customer, err := tx.GetCustomerByUserID(ctx, projectID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get customer: %w", err)
+		}
+
+		// Deleting an already deleted Stripe customer succeeds, so this is safe to repeat on a retry
+		stripeCustomerDeleted = false
+		if deleteStripeCustomer && customer.StripeCustomerID != "" {
+			if err := s.stripe.DeleteCustomer(customer.StripeCustomerID); err != nil {
+				return fmt.Errorf("%w: %v", ErrStripeCustomerNotDeleted, err)
+			}
+			stripeCustomerDeleted = true
+		}
+
+		if err := tx.EraseCustomer(ctx, customer.ID); err != nil {
+			return fmt.Errorf("failed to erase customer: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("Erased customer data of a user of project %s (Stripe customer deleted: %v)", projectID, stripeCustomerDeleted)
+	return stripeCustomerDeleted, nil
+}
+
+// findOrCreateInStripe returns the Stripe customer of a customer row without a Stripe ID,
+// recovering one created by an earlier request that failed before storing it.
+//
+// The customer is created without an email, which is set by a separate update: the idempotent create
+// must send the same parameters every time, while a retry may come with a different email.
+func (s *Service) findOrCreateInStripe(customer *database.Customer, email string) (*stripe.Customer, error) {
+	if email != "" {
+		existing, err := s.stripe.FindCustomer(customer.ProjectID, customer.UserID, email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search Stripe customers: %w", err)
+		}
+		if existing != nil {
+			log.Printf("Recovered Stripe customer %s for user %s", existing.ID, customer.UserID)
+			return existing, nil
+		}
+	}
+
+	params := &stripe.CustomerParams{}
+	params.AddMetadata(metadataProjectID, customer.ProjectID.String())
+	params.AddMetadata(metadataUserID, customer.UserID)
+	params.SetIdempotencyKey(idempotencyKey(customer))
+
+	created, err := s.stripe.CreateCustomer(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Stripe customer: %w", err)
+	}
+	log.Printf("Created Stripe customer %s for user %s", created.ID, customer.UserID)
+
+	if email != "" && created.Email != email {
+		created, err = s.stripe.UpdateCustomer(created.ID, &stripe.CustomerParams{Email: stripe.String(email)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set email of Stripe customer: %w", err)
+		}
+	}
+	return created, nil
+}
+
+// idempotencyKey identifies the creation of a row's Stripe customer. It changes with the row's
+// update time, so a row whose Stripe customer was deleted gets a new customer instead of the old one.
+func idempotencyKey(customer *database.Customer) string {
+	return fmt.Sprintf("customer-%s-%d", customer.ID, customer.UpdatedAt.UnixMicro())
+}
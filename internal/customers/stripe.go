@@ -0,0 +1,70 @@
+package customers
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/client"
+)
+
+// Metadata keys linking a Stripe customer to the project's user it was created for
+const (
+	metadataProjectID = "project_id"
+	metadataUserID    = "user_id"
+)
+
+// StripeAPI is the part of the Stripe API the customer service uses
+type StripeAPI interface {
+	// CreateCustomer creates a Stripe customer; params carry the idempotency key
+	CreateCustomer(params *stripe.CustomerParams) (*stripe.Customer, error)
+	// UpdateCustomer updates a Stripe customer
+	UpdateCustomer(stripeCustomerID string, params *stripe.CustomerParams) (*stripe.Customer, error)
+	// FindCustomer returns the Stripe customer created for a project's user, nil if there is none
+	FindCustomer(projectID uuid.UUID, userID, email string) (*stripe.Customer, error)
+	// DeleteCustomer deletes a Stripe customer; a customer that no longer exists is not an error
+	DeleteCustomer(stripeCustomerID string) error
+}
+
+// stripeAPI calls Stripe with a secret key
+type stripeAPI struct {
+	client *client.API
+}
+
+// NewStripeAPI creates a StripeAPI for the Stripe account of secretKey
+func NewStripeAPI(secretKey string) StripeAPI {
+	return &stripeAPI{client: client.New(secretKey, nil)}
+}
+
+// CreateCustomer creates a Stripe customer
+func (a *stripeAPI) CreateCustomer(params *stripe.CustomerParams) (*stripe.Customer, error) {
+	return a.client.Customers.New(params)
+}
+
+// UpdateCustomer updates a Stripe customer
+func (a *stripeAPI) UpdateCustomer(stripeCustomerID string, params *stripe.CustomerParams) (*stripe.Customer, error) {
+	return a.client.Customers.Update(stripeCustomerID, params)
+}
+
+// FindCustomer looks through the Stripe customers with the user's email for one whose metadata
+// names the project's user
+func (a *stripeAPI) FindCustomer(projectID uuid.UUID, userID, email string) (*stripe.Customer, error) {
+	iter := a.client.Customers.List(&stripe.CustomerListParams{Email: stripe.String(email)})
+	for iter.Next() {
+		customer := iter.Customer()
+		if customer.Metadata[metadataProjectID] == projectID.String() && customer.Metadata[metadataUserID] == userID {
+			return customer, nil
+		}
+	}
+	return nil, iter.Err()
+}
+
+// DeleteCustomer deletes a Stripe customer, which also cancels its subscriptions
+func (a *stripeAPI) DeleteCustomer(stripeCustomerID string) error {
+	_, err := a.client.Customers.Del(stripeCustomerID, nil)
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+		return nil
+	}
+	return err
+}
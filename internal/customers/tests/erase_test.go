@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DraconDev/go-stripe-ms/internal/customers"
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+)
+
+func TestEraseCustomer(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, err := testDB.Repo.CreateProject(ctx, "Customer Erasure Project", "")
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+
+		api := newFakeStripe("erased")
+		service := customers.NewService(testDB.Repo, api)
+		stripeCustomerID, err := service.FindOrCreateStripeCustomer(ctx, project.ID, "user_erased", "erased@example.com")
+		if err != nil {
+			t.Fatalf("Failed to create Stripe customer: %v", err)
+		}
+
+		t.Run("StripeFailureErasesNothing", func(t *testing.T) {
+			api.failDelete = true
+			defer func() { api.failDelete = false }()
+
+			if _, err := service.EraseCustomer(ctx, project.ID, "user_erased", true); !errors.Is(err, customers.ErrStripeCustomerNotDeleted) {
+				t.Fatalf("Expected ErrStripeCustomerNotDeleted, got %v", err)
+			}
+			customer, err := testDB.Repo.GetCustomerByUserID(ctx, project.ID, "user_erased")
+			if err != nil || customer.Email != "erased@example.com" {
+				t.Errorf("Expected the customer to be left alone, got %+v (%v)", customer, err)
+			}
+		})
+
+		t.Run("ErasesAndDeletesStripeCustomer", func(t *testing.T) {
+			deleted, err := service.EraseCustomer(ctx, project.ID, "user_erased", true)
+			if err != nil || !deleted {
+				t.Fatalf("Failed to erase customer: deleted=%v err=%v", deleted, err)
+			}
+			if len(api.deleted) != 1 || api.deleted[0] != stripeCustomerID {
+				t.Errorf("Expected Stripe customer %s to be deleted, got %v", stripeCustomerID, api.deleted)
+			}
+
+			if _, err := testDB.Repo.GetCustomerByUserID(ctx, project.ID, "user_erased"); !errors.Is(err, database.ErrNotFound) {
+				t.Errorf("Expected the user ID to be gone, got %v", err)
+			}
+			// The row stays linked so the customer.deleted event from Stripe can end its subscriptions
+			erased, err := testDB.Repo.GetCustomerByStripeID(ctx, stripeCustomerID)
+			if err != nil {
+				t.Fatalf("Failed to get erased customer: %v", err)
+			}
+			if erased.UserID != database.ErasedUserID(erased.ID) || erased.Email != "" || erased.ErasedAt == nil {
+				t.Errorf("Expected an anonymized customer, got %+v", erased)
+			}
+		})
+
+		t.Run("UnknownUser", func(t *testing.T) {
+			if _, err := service.EraseCustomer(ctx, project.ID, "user_erased", false); !errors.Is(err, database.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound erasing an erased user again, got %v", err)
+			}
+		})
+	})
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// fakeStripe mimics the Stripe customer API: a repeated idempotency key returns the customer
+// created with it, unless the parameters differ, and customers can be found by their metadata
+// unless searchable is off
+type fakeStripe struct {
+	mu         sync.Mutex
+	creates    int // CreateCustomer calls
+	customers  []*stripe.Customer
+	byKey      map[string]*stripe.Customer
+	keyEmails  map[string]string // Email sent with each idempotency key
+	searchable bool
+	prefix     string   // Keeps the customer IDs of fakes sharing a database apart
+	deleted    []string // DeleteCustomer calls
+	failDelete bool
+}
+
+func newFakeStripe(prefix string) *fakeStripe {
+	return &fakeStripe{byKey: make(map[string]*stripe.Customer), keyEmails: make(map[string]string), searchable: true, prefix: prefix}
+}
+
+func (f *fakeStripe) CreateCustomer(params *stripe.CustomerParams) (*stripe.Customer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.creates++
+	if params.IdempotencyKey == nil {
+		return nil, errors.New("missing idempotency key")
+	}
+	if customer, ok := f.byKey[*params.IdempotencyKey]; ok {
+		if f.keyEmails[*params.IdempotencyKey] != stripe.StringValue(params.Email) {
+			return nil, errors.New("keys for idempotent requests can only be used with the same parameters they were first used with")
+		}
+		copied := *customer
+		return &copied, nil
+	}
+
+	customer := &stripe.Customer{
+		ID:       fmt.Sprintf("cus_%s_%d", f.prefix, len(f.customers)+1),
+		Email:    stripe.StringValue(params.Email),
+		Metadata: params.Metadata,
+	}
+	f.customers = append(f.customers, customer)
+	f.byKey[*params.IdempotencyKey] = customer
+	f.keyEmails[*params.IdempotencyKey] = customer.Email
+	copied := *customer
+	return &copied, nil
+}
+
+func (f *fakeStripe) UpdateCustomer(stripeCustomerID string, params *stripe.CustomerParams) (*stripe.Customer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, customer := range f.customers {
+		if customer.ID == stripeCustomerID {
+			if params.Email != nil {
+				customer.Email = *params.Email
+			}
+			copied := *customer
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("no such customer: %s", stripeCustomerID)
+}
+
+func (f *fakeStripe) FindCustomer(projectID uuid.UUID, userID, email string) (*stripe.Customer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.searchable {
+		return nil, nil
+	}
+	for _, customer := range f.customers {
+		if customer.Email == email && customer.Metadata["project_id"] == projectID.String() && customer.Metadata["user_id"] == userID {
+			return customer, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeStripe) DeleteCustomer(stripeCustomerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failDelete {
+		return errors.New("stripe unavailable")
+	}
+	f.deleted = append(f.deleted, stripeCustomerID)
+	return nil
+}
+
+// crashingRepository fails storing Stripe customer IDs while crash is set, like a request
+// dying between creating the Stripe customer and committing its ID
+type crashingRepository struct {
+	database.RepositoryInterface
+	crash bool
+}
+
+func (r *crashingRepository) WithTx(ctx context.Context, fn func(database.RepositoryInterface) error) error {
+	return r.RepositoryInterface.WithTx(ctx, func(tx database.RepositoryInterface) error {
+		return fn(&crashingRepository{RepositoryInterface: tx, crash: r.crash})
+	})
+}
+
+func (r *crashingRepository) UpdateCustomerStripeID(ctx context.Context, projectID uuid.UUID, userID, stripeCustomerID string) error {
+	if r.crash {
+		return errors.New("connection lost")
+	}
+	return r.RepositoryInterface.UpdateCustomerStripeID(ctx, projectID, userID, stripeCustomerID)
+}
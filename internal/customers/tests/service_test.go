@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/DraconDev/go-stripe-ms/internal/customers"
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestFindOrCreateStripeCustomer(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, err := testDB.Repo.CreateProject(ctx, "Customer Service Project", "")
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+
+		t.Run("CreatesAndStoresStripeCustomer", func(t *testing.T) {
+			api := newFakeStripe("created")
+			service := customers.NewService(testDB.Repo, api)
+
+			stripeCustomerID, err := service.FindOrCreateStripeCustomer(ctx, project.ID, "user_new", "new@example.com")
+			if err != nil {
+				t.Fatalf("Failed to find or create Stripe customer: %v", err)
+			}
+			if stripeCustomerID != "cus_created_1" {
+				t.Errorf("Expected the created Stripe customer, got %s", stripeCustomerID)
+			}
+
+			customer, err := testDB.Repo.GetCustomerByUserID(ctx, project.ID, "user_new")
+			if err != nil {
+				t.Fatalf("Failed to get customer: %v", err)
+			}
+			if customer.StripeCustomerID != stripeCustomerID {
+				t.Errorf("Expected stored Stripe customer %s, got %s", stripeCustomerID, customer.StripeCustomerID)
+			}
+
+			created := api.customers[0]
+			if created.Metadata["project_id"] != project.ID.String() || created.Metadata["user_id"] != "user_new" {
+				t.Errorf("Expected the Stripe customer to name the project's user, got metadata %v", created.Metadata)
+			}
+
+			// Later requests use the stored customer
+			again, err := service.FindOrCreateStripeCustomer(ctx, project.ID, "user_new", "new@example.com")
+			if err != nil || again != stripeCustomerID {
+				t.Errorf("Expected the stored Stripe customer %s, got %s (%v)", stripeCustomerID, again, err)
+			}
+			if api.creates != 1 {
+				t.Errorf("Expected one Stripe customer creation, got %d", api.creates)
+			}
+		})
+
+		t.Run("ConcurrentFirstRequests", func(t *testing.T) {
+			api := newFakeStripe("racing")
+			api.searchable = false
+			service := customers.NewService(testDB.Repo, api)
+
+			const workers = 8
+			results := make(chan string, workers)
+			errs := make(chan error, workers)
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					stripeCustomerID, err := service.FindOrCreateStripeCustomer(ctx, project.ID, "user_racing", "racing@example.com")
+					if err != nil {
+						errs <- err
+						return
+					}
+					results <- stripeCustomerID
+				}()
+			}
+			wg.Wait()
+			close(results)
+			close(errs)
+
+			for err := range errs {
+				t.Errorf("Concurrent request failed: %v", err)
+			}
+			for stripeCustomerID := range results {
+				if stripeCustomerID != "cus_racing_1" {
+					t.Errorf("Expected every request to get cus_racing_1, got %s", stripeCustomerID)
+				}
+			}
+			if api.creates != 1 {
+				t.Errorf("Expected the lock to allow a single Stripe customer creation, got %d", api.creates)
+			}
+		})
+
+		t.Run("RecoversHalfCreatedCustomer", func(t *testing.T) {
+			api := newFakeStripe("crashed")
+			api.searchable = false
+			repo := &crashingRepository{RepositoryInterface: testDB.Repo, crash: true}
+			service := customers.NewService(repo, api)
+
+			if _, err := service.FindOrCreateStripeCustomer(ctx, project.ID, "user_crashed", "crashed@example.com"); err == nil {
+				t.Fatal("Expected the simulated crash to fail the request")
+			}
+			customer, err := testDB.Repo.GetCustomerByUserID(ctx, project.ID, "user_crashed")
+			if err != nil {
+				t.Fatalf("Expected the customer row to be kept, got %v", err)
+			}
+			if customer.StripeCustomerID != "" {
+				t.Fatalf("Expected no stored Stripe customer after the crash, got %s", customer.StripeCustomerID)
+			}
+
+			// The retry reuses the idempotency key, so Stripe returns the customer it already created
+			repo.crash = false
+			stripeCustomerID, err := service.FindOrCreateStripeCustomer(ctx, project.ID, "user_crashed", "crashed@example.com")
+			if err != nil {
+				t.Fatalf("Failed to recover customer: %v", err)
+			}
+			if stripeCustomerID != "cus_crashed_1" || len(api.customers) != 1 {
+				t.Errorf("Expected the first Stripe customer to be reused, got %s and %d customers", stripeCustomerID, len(api.customers))
+			}
+		})
+
+		t.Run("RetryWithAnotherEmail", func(t *testing.T) {
+			api := newFakeStripe("reemailed")
+			api.searchable = false
+			repo := &crashingRepository{RepositoryInterface: testDB.Repo, crash: true}
+			service := customers.NewService(repo, api)
+
+			if _, err := service.FindOrCreateStripeCustomer(ctx, project.ID, "user_reemailed", "first@example.com"); err == nil {
+				t.Fatal("Expected the simulated crash to fail the request")
+			}
+
+			// The idempotent create must not depend on the email, or Stripe rejects the reused key
+			repo.crash = false
+			stripeCustomerID, err := service.FindOrCreateStripeCustomer(ctx, project.ID, "user_reemailed", "second@example.com")
+			if err != nil {
+				t.Fatalf("Failed to recover customer with another email: %v", err)
+			}
+			if stripeCustomerID != "cus_reemailed_1" || len(api.customers) != 1 {
+				t.Errorf("Expected the first Stripe customer to be reused, got %s and %d customers", stripeCustomerID, len(api.customers))
+			}
+			if email := api.customers[0].Email; email != "second@example.com" {
+				t.Errorf("Expected the Stripe customer email to be updated, got %q", email)
+			}
+		})
+
+		t.Run("RecoversStripeCustomerByMetadata", func(t *testing.T) {
+			api := newFakeStripe("orphaned")
+			service := customers.NewService(testDB.Repo, api)
+
+			// A Stripe customer created for the user by a request that never stored it
+			orphan, err := api.CreateCustomer(&stripe.CustomerParams{
+				Params: stripe.Params{
+					IdempotencyKey: stripe.String("earlier-request"),
+					Metadata:       map[string]string{"project_id": project.ID.String(), "user_id": "user_orphaned"},
+				},
+				Email: stripe.String("orphaned@example.com"),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create orphaned Stripe customer: %v", err)
+			}
+			if _, err := testDB.Repo.EnsureCustomer(ctx, project.ID, "user_orphaned", "orphaned@example.com"); err != nil {
+				t.Fatalf("Failed to create customer: %v", err)
+			}
+
+			stripeCustomerID, err := service.FindOrCreateStripeCustomer(ctx, project.ID, "user_orphaned", "orphaned@example.com")
+			if err != nil {
+				t.Fatalf("Failed to recover customer: %v", err)
+			}
+			if stripeCustomerID != orphan.ID || api.creates != 1 {
+				t.Errorf("Expected orphaned Stripe customer %s to be linked without creating another, got %s after %d creations",
+					orphan.ID, stripeCustomerID, api.creates)
+			}
+		})
+	})
+}
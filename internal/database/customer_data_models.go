@@ -0,0 +1,23 @@
+package database
+
+import (
+	"github.com/google/uuid"
+)
+
+// CustomerExport is everything stored about one user of a project, as returned by ExportCustomerData
+type CustomerExport struct {
+	Customer            *Customer            `json:"customer"`
+	Subscriptions       []*Subscription      `json:"subscriptions"`
+	SubscriptionItems   []*SubscriptionItem  `json:"subscription_items"`
+	SubscriptionHistory []*SubscriptionEvent `json:"subscription_history"`
+	Orders              []*Order             `json:"orders"`
+	Invoices            []*Invoice           `json:"invoices"`
+	Refunds             []*Refund            `json:"refunds"`
+	Disputes            []*Dispute           `json:"disputes"`
+}
+
+// ErasedUserID is the pseudonymous user ID an erased customer's records are kept under.
+// It is derived from the customer row, so the original user ID cannot be recovered from it.
+func ErasedUserID(customerID uuid.UUID) string {
+	return "erased_" + customerID.String()
+}
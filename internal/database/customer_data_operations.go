@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// EraseCustomer anonymizes the personal data of a customer in one transaction. Its email, name and
+// payment method are cleared, and the customer and all of its billing records move to ErasedUserID,
+// so the records stay available for accounting without identifying the user. Hosted invoice links,
+// outbound notifications about the user, delivered or pending, and the bodies of all stored Stripe
+// events about its Stripe customer are removed as well; events not applied yet are ignored, as their
+// redacted bodies can no longer be applied. It returns ErrNotFound if the customer does not exist.
+func (r *Repository) EraseCustomer(ctx context.Context, customerID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var projectID uuid.UUID
+	var userID string
+	var stripeCustomerID *string
+	err = tx.QueryRow(ctx, `
+		SELECT project_id, user_id, stripe_customer_id FROM customers WHERE id = $1 FOR UPDATE
+	`, customerID).Scan(&projectID, &userID, &stripeCustomerID)
+	if err != nil {
+		return err
+	}
+	erasedUserID := ErasedUserID(customerID)
+
+	statements := []string{
+		`UPDATE customers
+		SET user_id = $2, email = '', name = NULL, default_payment_method = NULL,
+			erased_at = COALESCE(erased_at, NOW()), updated_at = NOW()
+		WHERE id = $1`,
+		`UPDATE subscriptions SET user_id = $2, updated_at = NOW() WHERE customer_id = $1`,
+		`UPDATE subscription_events SET user_id = $2
+		WHERE subscription_id IN (SELECT id FROM subscriptions WHERE customer_id = $1)`,
+		`UPDATE orders SET user_id = $2, updated_at = NOW() WHERE customer_id = $1`,
+		`UPDATE invoices SET user_id = $2, hosted_invoice_url = NULL, invoice_pdf = NULL, updated_at = NOW()
+		WHERE customer_id = $1`,
+		`UPDATE refunds SET user_id = $2, updated_at = NOW() WHERE customer_id = $1`,
+		`UPDATE disputes SET user_id = $2, updated_at = NOW() WHERE customer_id = $1`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(ctx, statement, customerID, erasedUserID); err != nil {
+			return err
+		}
+	}
+
+	for _, table := range []string{"webhook_deliveries", "pending_notifications"} {
+		_, err = tx.Exec(ctx, `
+			DELETE FROM `+table+` WHERE project_id = $1 AND payload #>> '{data,user_id}' = $2
+		`, projectID, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// An event held by a queue worker keeps its status, the worker finishes it with the body it loaded
+	if stripeCustomerID != nil {
+		_, err = tx.Exec(ctx, `
+			UPDATE stripe_events
+			SET payload = jsonb_set(payload, '{data,object}', jsonb_build_object(
+					'id', payload #> '{data,object,id}',
+					'object', payload #> '{data,object,object}',
+					'redacted', true
+				)),
+				status = CASE WHEN status IN ($2, $3) OR (status = $4 AND locked_until >= NOW()) THEN status ELSE $3 END,
+				locked_until = CASE WHEN status = $4 AND locked_until >= NOW() THEN locked_until END,
+				updated_at = NOW()
+			WHERE COALESCE(payload #>> '{data,object,customer,id}', payload #>> '{data,object,customer}') = $1
+				OR (payload #>> '{data,object,object}' = 'customer' AND payload #>> '{data,object,id}' = $1)
+		`, *stripeCustomerID, EventStatusProcessed, EventStatusIgnored, EventStatusProcessing)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ExportCustomerData collects everything stored about a project's user.
+// It returns ErrNotFound if the user has no customer in the project.
+func (r *Repository) ExportCustomerData(ctx context.Context, projectID uuid.UUID, userID string) (*CustomerExport, error) {
+	customer, err := r.GetCustomerByUserID(ctx, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	export := &CustomerExport{Customer: customer}
+
+	if export.Subscriptions, err = collectRows(ctx, r.db, ScanSubscription, `
+		SELECT `+subscriptionColumns+`
+		FROM subscriptions
+		WHERE customer_id = $1
+		ORDER BY created_at, id
+	`, customer.ID); err != nil {
+		return nil, err
+	}
+	if export.SubscriptionItems, err = collectRows(ctx, r.db, ScanSubscriptionItem, `
+		SELECT si.id, si.subscription_id, si.stripe_subscription_item_id, si.price_id, si.product_id,
+			si.quantity, si.created_at, si.updated_at
+		FROM subscription_items si
+		JOIN subscriptions s ON s.id = si.subscription_id
+		WHERE s.customer_id = $1
+		ORDER BY si.created_at, si.stripe_subscription_item_id
+	`, customer.ID); err != nil {
+		return nil, err
+	}
+	if export.SubscriptionHistory, err = collectRows(ctx, r.db, ScanSubscriptionEvent, `
+		SELECT `+subscriptionEventColumns+`
+		FROM subscription_events
+		WHERE project_id = $1 AND user_id = $2
+		ORDER BY recorded_at, id
+	`, projectID, userID); err != nil {
+		return nil, err
+	}
+	if export.Orders, err = r.GetOrdersByUserID(ctx, projectID, userID); err != nil {
+		return nil, err
+	}
+	if export.Invoices, err = collectRows(ctx, r.db, ScanInvoice, `
+		SELECT `+invoiceColumns+`
+		FROM invoices
+		WHERE customer_id = $1
+		ORDER BY period_end DESC, created_at DESC, id
+	`, customer.ID); err != nil {
+		return nil, err
+	}
+	if export.Refunds, err = collectRows(ctx, r.db, ScanRefund, `
+		SELECT `+refundColumns+`
+		FROM refunds
+		WHERE customer_id = $1
+		ORDER BY created_at, id
+	`, customer.ID); err != nil {
+		return nil, err
+	}
+	if export.Disputes, err = collectRows(ctx, r.db, ScanDispute, `
+		SELECT `+disputeColumns+`
+		FROM disputes
+		WHERE customer_id = $1
+		ORDER BY created_at, id
+	`, customer.ID); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// collectRows runs a query and scans every row it returns with scan
+func collectRows[T any](ctx context.Context, db querier, scan func(pgx.Row) (*T, error), sql string, args ...any) ([]*T, error) {
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collected := []*T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		collected = append(collected, item)
+	}
+
+	return collected, rows.Err()
+}
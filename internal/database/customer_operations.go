@@ -2,44 +2,43 @@ package database
 
 import (
 	"context"
-	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
-// FindOrCreateStripeCustomer finds an existing customer or creates a new one
-func (r *Repository) FindOrCreateStripeCustomer(ctx context.Context, projectID uuid.UUID, userID, email string) (string, error) {
-	// First, try to find existing customer
-	var existingCustomerID string
-	err := r.db.QueryRow(ctx, `
-		SELECT stripe_customer_id 
-		FROM customers 
-		WHERE project_id = $1 AND user_id = $2
-	`, projectID, userID).Scan(&existingCustomerID)
+const customerColumns = `id, project_id, user_id, email, stripe_customer_id, name, default_payment_method, erased_at, created_at, updated_at`
 
-	if err == nil && existingCustomerID != "" {
-		log.Printf("Found existing customer for user %s: %s", userID, existingCustomerID)
-		return existingCustomerID, nil
+// EnsureCustomer returns the customer of a project's user, creating it without a Stripe customer
+// if there is none yet. The Stripe customer is linked later with UpdateCustomerStripeID.
+func (r *Repository) EnsureCustomer(ctx context.Context, projectID uuid.UUID, userID, email string) (*Customer, error) {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO customers (id, project_id, user_id, email, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (project_id, user_id) DO NOTHING
+	`, uuid.New(), projectID, userID, email)
+	if err != nil {
+		return nil, err
 	}
 
-	// If not found, create new customer in Stripe (this would be done by the calling service)
-	// For now, we'll create a placeholder entry that will be updated when Stripe customer is created
-	customerID := uuid.New()
-	_, err = r.db.Exec(ctx, `
-		INSERT INTO customers (id, project_id, user_id, email, stripe_customer_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (project_id, user_id) DO UPDATE SET
-			email = EXCLUDED.email,
-			updated_at = EXCLUDED.updated_at
-	`, customerID, projectID, userID, email, nil, time.Now(), time.Now())
+	return r.GetCustomerByUserID(ctx, projectID, userID)
+}
 
-	if err != nil {
-		return "", err
+// LockCustomer takes an advisory lock on a project's user until the transaction ends, so concurrent
+// first-time requests for the same user create one Stripe customer. It must be called within WithTx.
+func (r *Repository) LockCustomer(ctx context.Context, projectID uuid.UUID, userID string) error {
+	if _, inTx := r.db.(pgx.Tx); !inTx {
+		return ErrLockOutsideTx
 	}
 
-	// Return the UUID of the created customer as a string
-	return customerID.String(), nil
+	_, err := r.db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, customerLockKey(projectID, userID))
+	return err
+}
+
+// customerLockKey identifies a project's user for LockCustomer
+func customerLockKey(projectID uuid.UUID, userID string) string {
+	return "customer:" + projectID.String() + ":" + userID
 }
 
 // UpdateCustomerStripeID updates the Stripe customer ID for an existing customer
@@ -55,8 +54,88 @@ func (r *Repository) UpdateCustomerStripeID(ctx context.Context, projectID uuid.
 // GetCustomerByStripeID retrieves customer by Stripe customer ID
 func (r *Repository) GetCustomerByStripeID(ctx context.Context, stripeCustomerID string) (*Customer, error) {
 	return ScanCustomer(r.db.QueryRow(ctx, `
-		SELECT id, project_id, user_id, email, stripe_customer_id, created_at, updated_at
+		SELECT `+customerColumns+`
 		FROM customers 
 		WHERE stripe_customer_id = $1
 	`, stripeCustomerID))
 }
+
+// GetProjectCustomerByStripeID retrieves a project's customer by Stripe customer ID
+func (r *Repository) GetProjectCustomerByStripeID(ctx context.Context, projectID uuid.UUID, stripeCustomerID string) (*Customer, error) {
+	return ScanCustomer(r.db.QueryRow(ctx, `
+		SELECT `+customerColumns+`
+		FROM customers
+		WHERE project_id = $1 AND stripe_customer_id = $2
+	`, projectID, stripeCustomerID))
+}
+
+// UpdateCustomerDetails writes the email, name and default payment method carried by a customer.updated event.
+// An empty email keeps the stored one. Events older than the last applied one and customers whose data
+// was erased are skipped; it reports whether the update was applied.
+func (r *Repository) UpdateCustomerDetails(ctx context.Context, customerID uuid.UUID, email, name, defaultPaymentMethod string, eventAt time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE customers
+		SET email = COALESCE(NULLIF($1, ''), email), name = NULLIF($2, ''), default_payment_method = NULLIF($3, ''),
+			last_event_at = $4, updated_at = NOW()
+		WHERE id = $5 AND (last_event_at IS NULL OR last_event_at <= $4) AND erased_at IS NULL
+	`, email, name, defaultPaymentMethod, eventAt, customerID)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// DetachStripeCustomer clears the Stripe ID of a customer deleted in Stripe and ends its subscriptions
+// in one transaction. It returns the Stripe IDs of the subscriptions that were ended.
+func (r *Repository) DetachStripeCustomer(ctx context.Context, customerID uuid.UUID, eventAt time.Time) ([]string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		UPDATE subscriptions
+		SET status = $1, ended_at = COALESCE(ended_at, $2), updated_at = NOW()
+		WHERE customer_id = $3 AND status NOT IN `+terminalStatusList+`
+		RETURNING stripe_subscription_id
+	`, SubscriptionStatusCanceled, eventAt, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	ended := []string{}
+	for rows.Next() {
+		var stripeSubID string
+		if err := rows.Scan(&stripeSubID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ended = append(ended, stripeSubID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE customers
+		SET stripe_customer_id = NULL, default_payment_method = NULL, last_event_at = $1, updated_at = NOW()
+		WHERE id = $2
+	`, eventAt, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ended, tx.Commit(ctx)
+}
+
+// GetCustomerByUserID retrieves a customer by User ID
+func (r *Repository) GetCustomerByUserID(ctx context.Context, projectID uuid.UUID, userID string) (*Customer, error) {
+	return ScanCustomer(r.db.QueryRow(ctx, `
+		SELECT `+customerColumns+`
+		FROM customers 
+		WHERE project_id = $1 AND user_id = $2
+	`, projectID, userID))
+}
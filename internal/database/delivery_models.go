@@ -0,0 +1,66 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Outbound webhook delivery statuses
+const (
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusRetrying  = "retrying"
+	DeliveryStatusFailed    = "failed"
+)
+
+// WebhookDelivery records one attempt to deliver an event to a project's webhook URL
+type WebhookDelivery struct {
+	ID           uuid.UUID `json:"id"`
+	ProjectID    uuid.UUID `json:"project_id"`
+	EventID      uuid.UUID `json:"event_id"`
+	EventType    string    `json:"event_type"`
+	URL          string    `json:"url"`
+	Payload      []byte    `json:"payload"`
+	Attempt      int       `json:"attempt"`
+	Status       string    `json:"status"`
+	ResponseCode int       `json:"response_code,omitempty"` // 0 when no response was received
+	LatencyMS    int64     `json:"latency_ms"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ScanWebhookDelivery scans a database row into a WebhookDelivery struct
+func ScanWebhookDelivery(row pgx.Row) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	var responseCode sql.NullInt32
+	var deliveryError sql.NullString
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.ProjectID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.URL,
+		&delivery.Payload,
+		&delivery.Attempt,
+		&delivery.Status,
+		&responseCode,
+		&delivery.LatencyMS,
+		&deliveryError,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if responseCode.Valid {
+		delivery.ResponseCode = int(responseCode.Int32)
+	}
+	if deliveryError.Valid {
+		delivery.Error = deliveryError.String
+	}
+
+	return &delivery, nil
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// RecordWebhookDelivery stores the outcome of one outbound webhook delivery attempt
+func (r *Repository) RecordWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	var responseCode interface{}
+	if delivery.ResponseCode != 0 {
+		responseCode = delivery.ResponseCode
+	}
+
+	return r.db.QueryRow(ctx, `
+		INSERT INTO webhook_deliveries (
+			project_id, event_id, event_type, url, payload, attempt,
+			status, response_code, latency_ms, error, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+		RETURNING id, created_at
+	`, delivery.ProjectID, delivery.EventID, delivery.EventType, delivery.URL, delivery.Payload,
+		delivery.Attempt, delivery.Status, responseCode, delivery.LatencyMS, nullString(delivery.Error),
+	).Scan(&delivery.ID, &delivery.CreatedAt)
+}
+
+// ListWebhookDeliveries lists the most recent delivery attempts for a project
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, projectID uuid.UUID, limit int) ([]*WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, project_id, event_id, event_type, url, payload, attempt,
+			status, response_code, latency_ms, error, created_at
+		FROM webhook_deliveries
+		WHERE project_id = $1
+		ORDER BY created_at DESC, attempt DESC
+		LIMIT $2
+	`, projectID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		delivery, err := ScanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DunningSubscription is a subscription in an open dunning state together with its project's settings
+type DunningSubscription struct {
+	Subscription
+	GraceDays  int
+	MaxRetries int
+}
+
+// StartDunning records a failed payment of a subscription. A subscription outside an open dunning
+// state enters grace, starting at failedAt; otherwise only its retry count is raised to attempts.
+// A failure no later than a payment of one of the subscription's invoices is stale and ignored.
+// It reports whether the subscription entered grace.
+func (r *Repository) StartDunning(ctx context.Context, stripeSubID string, failedAt time.Time, attempts int64) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE subscriptions s
+		SET dunning_state = $1, dunning_started_at = $2, dunning_retries = $3, updated_at = NOW()
+		WHERE s.stripe_subscription_id = $4 AND (s.dunning_state IS NULL OR s.dunning_state = $5)
+			AND NOT EXISTS (
+				SELECT 1 FROM invoices i
+				WHERE i.stripe_subscription_id = s.stripe_subscription_id AND i.paid_at >= $2
+			)
+	`, DunningStateGrace, failedAt, attempts, stripeSubID, DunningStateRecovered)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() > 0 {
+		return true, nil
+	}
+
+	_, err = r.db.Exec(ctx, `
+		UPDATE subscriptions s
+		SET dunning_retries = GREATEST(s.dunning_retries, $1), updated_at = NOW()
+		WHERE s.stripe_subscription_id = $2
+			AND NOT EXISTS (
+				SELECT 1 FROM invoices i
+				WHERE i.stripe_subscription_id = s.stripe_subscription_id AND i.paid_at >= $3
+			)
+	`, attempts, stripeSubID, failedAt)
+	return false, err
+}
+
+// TransitionDunningState moves a subscription from one dunning state to another.
+// It reports false if the subscription was no longer in the from state.
+func (r *Repository) TransitionDunningState(ctx context.Context, stripeSubID, from, to string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE subscriptions
+		SET dunning_state = $1, updated_at = NOW()
+		WHERE stripe_subscription_id = $2 AND dunning_state = $3
+	`, to, stripeSubID, from)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListDunningSubscriptions lists subscriptions in grace or retrying with their project's dunning settings
+func (r *Repository) ListDunningSubscriptions(ctx context.Context) ([]*DunningSubscription, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT s.id, s.project_id, s.customer_id, s.user_id, s.product_id, s.price_id,
+			s.stripe_subscription_id, s.status, s.current_period_start, s.current_period_end,
+			s.last_event_at, s.trial_start, s.trial_end, s.cancel_at_period_end, s.canceled_at, s.ended_at,
+			s.pause_behavior, s.pause_resumes_at, s.dunning_state, s.dunning_started_at, s.dunning_retries,
+			s.created_at, s.updated_at, p.dunning_grace_days, p.dunning_max_retries
+		FROM subscriptions s
+		JOIN projects p ON p.id = s.project_id
+		WHERE s.dunning_state IN ($1, $2)
+	`, DunningStateGrace, DunningStateRetrying)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*DunningSubscription
+	for rows.Next() {
+		var graceDays, maxRetries int
+		sub, err := ScanSubscription(scanTail(rows, &graceDays, &maxRetries))
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, &DunningSubscription{
+			Subscription: *sub,
+			GraceDays:    graceDays,
+			MaxRetries:   maxRetries,
+		})
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// rowWithTail lets a ScanX function read a row that carries extra trailing columns
+type rowWithTail struct {
+	row  pgx.Row
+	tail []any
+}
+
+// Scan scans the leading columns into dest and the trailing ones into the tail
+func (r rowWithTail) Scan(dest ...any) error {
+	return r.row.Scan(append(dest, r.tail...)...)
+}
+
+// scanTail wraps a row so the columns after those read by a ScanX function go into tail
+func scanTail(row pgx.Row, tail ...any) pgx.Row {
+	return rowWithTail{row: row, tail: tail}
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/puddle/v2"
+)
+
+// SQLSTATEs Postgres reports when a write violates a constraint
+const (
+	sqlStateUniqueViolation    = "23505"
+	sqlStateExclusionViolation = "23P01"
+)
+
+// Errors returned by repository operations. They wrap the underlying pgx or Postgres error,
+// so errors.Is also matches pgx.ErrNoRows and errors.As still finds a *pgconn.PgError.
+var (
+	// ErrNotFound is returned when the requested row does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write violates a unique constraint or conflicts with a concurrent transaction
+	ErrConflict = errors.New("conflict")
+	// ErrUnavailable is returned when the database cannot be reached or is not accepting work
+	ErrUnavailable = errors.New("database unavailable")
+)
+
+// errNoRows is the error of an operation that found no row to return or update
+var errNoRows = &repositoryError{kind: ErrNotFound, err: pgx.ErrNoRows}
+
+// repositoryError classifies a database error as one of the repository errors
+type repositoryError struct {
+	kind error
+	err  error
+}
+
+func (e *repositoryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *repositoryError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// classifyError wraps err in the repository error it corresponds to; other errors are returned unchanged
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var classified *repositoryError
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	if kind := errorKind(err); kind != nil {
+		return &repositoryError{kind: kind, err: err}
+	}
+	return err
+}
+
+// errorKind returns the repository error err corresponds to, nil if it is none of them
+func errorKind(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == sqlStateUniqueViolation, pgErr.Code == sqlStateExclusionViolation,
+			pgErr.Code == sqlStateSerializationFailure, pgErr.Code == sqlStateDeadlockDetected:
+			return ErrConflict
+		// Connection exceptions, insufficient resources and server shutdown
+		case strings.HasPrefix(pgErr.Code, "08"), strings.HasPrefix(pgErr.Code, "53"), strings.HasPrefix(pgErr.Code, "57P"):
+			return ErrUnavailable
+		}
+		return nil
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &connectErr), errors.As(err, &netErr), pgconn.Timeout(err),
+		errors.Is(err, context.DeadlineExceeded), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, puddle.ErrClosedPool):
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// classifyingQuerier classifies the errors of every query run through it,
+// so repository operations return the repository errors without handling them one by one
+type classifyingQuerier struct {
+	querier
+}
+
+func (q classifyingQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := q.querier.Exec(ctx, sql, args...)
+	return tag, classifyError(err)
+}
+
+func (q classifyingQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := q.querier.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return classifyingRows{rows}, nil
+}
+
+func (q classifyingQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return classifyingRow{q.querier.QueryRow(ctx, sql, args...)}
+}
+
+func (q classifyingQuerier) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := q.querier.Begin(ctx)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return classifyingTx{Tx: tx}, nil
+}
+
+// classifyingTx is a transaction whose queries and commit return the repository errors
+type classifyingTx struct {
+	pgx.Tx
+}
+
+func (tx classifyingTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return classifyingQuerier{tx.Tx}.Exec(ctx, sql, args...)
+}
+
+func (tx classifyingTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return classifyingQuerier{tx.Tx}.Query(ctx, sql, args...)
+}
+
+func (tx classifyingTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return classifyingQuerier{tx.Tx}.QueryRow(ctx, sql, args...)
+}
+
+func (tx classifyingTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return classifyingQuerier{tx.Tx}.Begin(ctx)
+}
+
+func (tx classifyingTx) Commit(ctx context.Context) error {
+	return classifyError(tx.Tx.Commit(ctx))
+}
+
+type classifyingRow struct {
+	pgx.Row
+}
+
+func (row classifyingRow) Scan(dest ...any) error {
+	return classifyError(row.Row.Scan(dest...))
+}
+
+type classifyingRows struct {
+	pgx.Rows
+}
+
+func (rows classifyingRows) Scan(dest ...any) error {
+	return classifyError(rows.Rows.Scan(dest...))
+}
+
+func (rows classifyingRows) Err() error {
+	return classifyError(rows.Rows.Err())
+}
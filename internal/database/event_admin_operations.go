@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// GetStripeEvent retrieves a stored Stripe event by its Stripe event ID
+func (r *Repository) GetStripeEvent(ctx context.Context, eventID string) (*StripeEvent, error) {
+	return ScanStripeEvent(r.db.QueryRow(ctx, `
+		SELECT `+stripeEventColumns+`
+		FROM stripe_events
+		WHERE id = $1
+	`, eventID))
+}
+
+// ListStripeEvents lists stored events of a type created within [from, to), newest first.
+// An empty eventType matches every type.
+func (r *Repository) ListStripeEvents(ctx context.Context, eventType string, from, to time.Time, limit int) ([]*StripeEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+stripeEventColumns+`
+		FROM stripe_events
+		WHERE ($1 = '' OR type = $1) AND created >= $2 AND created < $3
+		ORDER BY created DESC
+		LIMIT $4
+	`, eventType, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*StripeEvent
+	for rows.Next() {
+		event, err := ScanStripeEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// ListStripeEventsByStatus lists stored events in the given statuses created within [from, to), oldest first.
+// An empty eventType matches every type.
+func (r *Repository) ListStripeEventsByStatus(ctx context.Context, statuses []string, eventType string, from, to time.Time, limit int) ([]*StripeEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+stripeEventColumns+`
+		FROM stripe_events
+		WHERE status = ANY($1) AND ($2 = '' OR type = $2) AND created >= $3 AND created < $4
+		ORDER BY created, received_at
+		LIMIT $5
+	`, statuses, eventType, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*StripeEvent{}
+	for rows.Next() {
+		event, err := ScanStripeEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// ClaimStripeEvent locks a single event for an admin replay regardless of its status.
+// It returns ErrStripeEventLocked while a worker holds the event and ErrNotFound if it does not exist.
+func (r *Repository) ClaimStripeEvent(ctx context.Context, eventID string, lease time.Duration) (*StripeEvent, error) {
+	event, err := ScanStripeEvent(r.db.QueryRow(ctx, `
+		UPDATE stripe_events
+		SET status = $1, locked_until = $2, updated_at = NOW()
+		WHERE id = $3 AND NOT (status = $1 AND locked_until >= NOW())
+		RETURNING `+stripeEventColumns,
+		EventStatusProcessing, time.Now().Add(lease), eventID))
+	if !errors.Is(err, ErrNotFound) {
+		return event, err
+	}
+
+	if _, err := r.GetStripeEvent(ctx, eventID); err != nil {
+		return nil, err
+	}
+	return nil, ErrStripeEventLocked
+}
+
+// MarkStripeEventIgnored sets an event aside so the queue never processes it.
+// Processed events and events held by a worker are left alone; it reports whether the event was ignored.
+func (r *Repository) MarkStripeEventIgnored(ctx context.Context, eventID string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE stripe_events
+		SET status = $1, locked_until = NULL, updated_at = NOW()
+		WHERE id = $2 AND status <> $3 AND NOT (status = $4 AND locked_until >= NOW())
+	`, EventStatusIgnored, eventID, EventStatusProcessed, EventStatusProcessing)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// RecordStripeEventAction stores an admin action taken on an event
+func (r *Repository) RecordStripeEventAction(ctx context.Context, action *StripeEventAction) error {
+	return r.db.QueryRow(ctx, `
+		INSERT INTO stripe_event_actions (event_id, action, triggered_by, result, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id, created_at
+	`, action.EventID, action.Action, action.TriggeredBy, action.Result, nullString(action.Error),
+	).Scan(&action.ID, &action.CreatedAt)
+}
+
+// ListStripeEventActions lists the admin actions taken on an event, oldest first
+func (r *Repository) ListStripeEventActions(ctx context.Context, eventID string) ([]*StripeEventAction, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, event_id, action, triggered_by, result, error, created_at
+		FROM stripe_event_actions
+		WHERE event_id = $1
+		ORDER BY created_at
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []*StripeEventAction{}
+	for rows.Next() {
+		var action StripeEventAction
+		var actionError sql.NullString
+		if err := rows.Scan(&action.ID, &action.EventID, &action.Action, &action.TriggeredBy, &action.Result, &actionError, &action.CreatedAt); err != nil {
+			return nil, err
+		}
+		action.Error = actionError.String
+		actions = append(actions, &action)
+	}
+
+	return actions, rows.Err()
+}
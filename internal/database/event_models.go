@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Stripe event processing statuses
+const (
+	EventStatusPending    = "pending"
+	EventStatusProcessing = "processing"
+	EventStatusProcessed  = "processed"
+	EventStatusFailed     = "failed"      // Failed, will be retried at NextAttemptAt
+	EventStatusDeadLetter = "dead_letter" // Failed too many times, no further retries
+	EventStatusIgnored    = "ignored"     // Set aside by an admin, never processed again unless replayed
+)
+
+// Admin actions recorded against stored Stripe events
+const (
+	EventActionReplay = "replay"
+	EventActionIgnore = "ignore"
+)
+
+// StripeEventAction records an admin replaying or ignoring a stored Stripe event
+type StripeEventAction struct {
+	ID          uuid.UUID `json:"id"`
+	EventID     string    `json:"event_id"`
+	Action      string    `json:"action"`
+	TriggeredBy string    `json:"triggered_by"`
+	Result      string    `json:"result"` // Event status after the action
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// StripeEvent represents a verified Stripe webhook event and its processing state
+type StripeEvent struct {
+	ID            string     `json:"id"`                   // Stripe event ID (evt_...)
+	ProjectID     *uuid.UUID `json:"project_id,omitempty"` // Set when received on the project's own webhook endpoint
+	Type          string     `json:"type"`
+	Created       time.Time  `json:"created"`
+	Payload       []byte     `json:"payload"` // Raw JSON body as received
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"` // Processing attempts so far
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	ReceivedAt    time.Time  `json:"received_at"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ScanStripeEvent scans a database row into a StripeEvent struct
+func ScanStripeEvent(row pgx.Row) (*StripeEvent, error) {
+	var event StripeEvent
+	var lastError sql.NullString
+
+	err := row.Scan(
+		&event.ID,
+		&event.Type,
+		&event.Created,
+		&event.Payload,
+		&event.Status,
+		&event.Attempts,
+		&lastError,
+		&event.NextAttemptAt,
+		&event.ReceivedAt,
+		&event.ProcessedAt,
+		&event.UpdatedAt,
+		&event.ProjectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastError.Valid {
+		event.LastError = lastError.String
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrStripeEventLocked is returned when an event is being processed by a queue worker
+var ErrStripeEventLocked = errors.New("stripe event is being processed")
+
+const stripeEventColumns = `id, type, created, payload, status, attempts, last_error, next_attempt_at, received_at, processed_at, updated_at, project_id`
+
+// EnqueueStripeEvent stores a verified Stripe event for asynchronous processing.
+// It reports whether the event was newly queued; redeliveries of a stored event return false.
+func (r *Repository) EnqueueStripeEvent(ctx context.Context, event *StripeEvent) (bool, error) {
+	var id string
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO stripe_events (id, project_id, type, created, payload, status, attempts, next_attempt_at, received_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 0, NOW(), NOW(), NOW())
+		ON CONFLICT (id) DO NOTHING
+		RETURNING id
+	`, event.ID, event.ProjectID, event.Type, event.Created, event.Payload, EventStatusPending).Scan(&id)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ClaimStripeEvents locks up to limit due events for processing, oldest first.
+// Rows locked by other workers are skipped, and a claim expires after lease so
+// events held by a crashed worker are picked up again.
+func (r *Repository) ClaimStripeEvents(ctx context.Context, limit int, lease time.Duration) ([]*StripeEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE stripe_events
+		SET status = $1, attempts = attempts + 1, locked_until = $2, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM stripe_events
+			WHERE (status IN ($3, $4) AND next_attempt_at <= NOW())
+				OR (status = $1 AND locked_until < NOW())
+			ORDER BY created, received_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+stripeEventColumns,
+		EventStatusProcessing, time.Now().Add(lease), EventStatusPending, EventStatusFailed, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*StripeEvent
+	for rows.Next() {
+		event, err := ScanStripeEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// MarkStripeEventProcessed marks an event as successfully processed
+func (r *Repository) MarkStripeEventProcessed(ctx context.Context, eventID string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE stripe_events
+		SET status = $1, last_error = NULL, locked_until = NULL, processed_at = NOW(), updated_at = NOW()
+		WHERE id = $2
+	`, EventStatusProcessed, eventID)
+	return err
+}
+
+// MarkStripeEventFailed records a processing failure and schedules the next attempt
+func (r *Repository) MarkStripeEventFailed(ctx context.Context, eventID, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE stripe_events
+		SET status = $1, last_error = $2, next_attempt_at = $3, locked_until = NULL, updated_at = NOW()
+		WHERE id = $4
+	`, EventStatusFailed, lastError, nextAttemptAt, eventID)
+	return err
+}
+
+// MarkStripeEventDeadLetter records a final processing failure; the event is not retried again
+func (r *Repository) MarkStripeEventDeadLetter(ctx context.Context, eventID, lastError string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE stripe_events
+		SET status = $1, last_error = $2, locked_until = NULL, updated_at = NOW()
+		WHERE id = $3
+	`, EventStatusDeadLetter, lastError, eventID)
+	return err
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Invoice represents a Stripe invoice of a customer, kept current from invoice.* events
+type Invoice struct {
+	ID                    uuid.UUID  `json:"id"`
+	ProjectID             uuid.UUID  `json:"project_id"`
+	CustomerID            uuid.UUID  `json:"customer_id"`
+	UserID                string     `json:"user_id"`
+	StripeInvoiceID       string     `json:"stripe_invoice_id"`
+	StripeSubscriptionID  string     `json:"stripe_subscription_id,omitempty"`
+	StripePaymentIntentID string     `json:"stripe_payment_intent_id,omitempty"`
+	Number                string     `json:"number,omitempty"`
+	Status                string     `json:"status"` // Stripe invoice status: draft, open, paid, uncollectible or void
+	AmountDue             int64      `json:"amount_due"`
+	AmountPaid            int64      `json:"amount_paid"`
+	AmountRemaining       int64      `json:"amount_remaining"`
+	Currency              string     `json:"currency"`
+	HostedInvoiceURL      string     `json:"hosted_invoice_url,omitempty"`
+	InvoicePDF            string     `json:"invoice_pdf,omitempty"`
+	PeriodStart           time.Time  `json:"period_start"`
+	PeriodEnd             time.Time  `json:"period_end"`
+	AttemptCount          int64      `json:"attempt_count"`
+	NextPaymentAttempt    *time.Time `json:"next_payment_attempt,omitempty"`
+	PaidAt                *time.Time `json:"paid_at,omitempty"`
+	PaymentFailedAt       *time.Time `json:"payment_failed_at,omitempty"` // Time of the latest failed payment attempt
+	LastEventAt           time.Time  `json:"-"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+const invoiceColumns = `id, project_id, customer_id, user_id, stripe_invoice_id, stripe_subscription_id, stripe_payment_intent_id,
+	number, status, amount_due, amount_paid, amount_remaining, currency, hosted_invoice_url, invoice_pdf,
+	period_start, period_end, attempt_count, next_payment_attempt, paid_at, payment_failed_at,
+	last_event_at, created_at, updated_at`
+
+// ScanInvoice scans a database row into an Invoice struct
+func ScanInvoice(row pgx.Row) (*Invoice, error) {
+	var invoice Invoice
+	var subscriptionID, paymentIntentID, number, hostedURL, pdf sql.NullString
+
+	err := row.Scan(
+		&invoice.ID,
+		&invoice.ProjectID,
+		&invoice.CustomerID,
+		&invoice.UserID,
+		&invoice.StripeInvoiceID,
+		&subscriptionID,
+		&paymentIntentID,
+		&number,
+		&invoice.Status,
+		&invoice.AmountDue,
+		&invoice.AmountPaid,
+		&invoice.AmountRemaining,
+		&invoice.Currency,
+		&hostedURL,
+		&pdf,
+		&invoice.PeriodStart,
+		&invoice.PeriodEnd,
+		&invoice.AttemptCount,
+		&invoice.NextPaymentAttempt,
+		&invoice.PaidAt,
+		&invoice.PaymentFailedAt,
+		&invoice.LastEventAt,
+		&invoice.CreatedAt,
+		&invoice.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice.StripeSubscriptionID = subscriptionID.String
+	invoice.StripePaymentIntentID = paymentIntentID.String
+	invoice.Number = number.String
+	invoice.HostedInvoiceURL = hostedURL.String
+	invoice.InvoicePDF = pdf.String
+
+	return &invoice, nil
+}
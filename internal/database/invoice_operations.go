@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// UpsertInvoice stores the latest state of an invoice from an event created at invoice.LastEventAt.
+// It reports whether the write was applied; events older than the stored state and writes to
+// another project's invoice are ignored. A recorded payment failure is kept until a newer failure replaces it.
+func (r *Repository) UpsertInvoice(ctx context.Context, invoice *Invoice) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO invoices (
+			project_id, customer_id, user_id, stripe_invoice_id, stripe_subscription_id, stripe_payment_intent_id,
+			number, status, amount_due, amount_paid, amount_remaining, currency, hosted_invoice_url, invoice_pdf,
+			period_start, period_end, attempt_count, next_payment_attempt, paid_at, payment_failed_at,
+			last_event_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, NOW(), NOW()
+		)
+		ON CONFLICT (stripe_invoice_id) DO UPDATE SET
+			stripe_subscription_id = EXCLUDED.stripe_subscription_id,
+			stripe_payment_intent_id = COALESCE(EXCLUDED.stripe_payment_intent_id, invoices.stripe_payment_intent_id),
+			number = EXCLUDED.number,
+			status = EXCLUDED.status,
+			amount_due = EXCLUDED.amount_due,
+			amount_paid = EXCLUDED.amount_paid,
+			amount_remaining = EXCLUDED.amount_remaining,
+			currency = EXCLUDED.currency,
+			hosted_invoice_url = EXCLUDED.hosted_invoice_url,
+			invoice_pdf = EXCLUDED.invoice_pdf,
+			period_start = EXCLUDED.period_start,
+			period_end = EXCLUDED.period_end,
+			attempt_count = EXCLUDED.attempt_count,
+			next_payment_attempt = EXCLUDED.next_payment_attempt,
+			paid_at = EXCLUDED.paid_at,
+			payment_failed_at = COALESCE(EXCLUDED.payment_failed_at, invoices.payment_failed_at),
+			last_event_at = EXCLUDED.last_event_at,
+			updated_at = NOW()
+		WHERE invoices.project_id = EXCLUDED.project_id AND invoices.last_event_at <= EXCLUDED.last_event_at
+	`, invoice.ProjectID, invoice.CustomerID, invoice.UserID, invoice.StripeInvoiceID,
+		nullString(invoice.StripeSubscriptionID), nullString(invoice.StripePaymentIntentID), nullString(invoice.Number), invoice.Status,
+		invoice.AmountDue, invoice.AmountPaid, invoice.AmountRemaining, invoice.Currency,
+		nullString(invoice.HostedInvoiceURL), nullString(invoice.InvoicePDF),
+		invoice.PeriodStart, invoice.PeriodEnd, invoice.AttemptCount, invoice.NextPaymentAttempt,
+		invoice.PaidAt, invoice.PaymentFailedAt, invoice.LastEventAt)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetInvoiceByStripeID retrieves an invoice by its Stripe invoice ID
+func (r *Repository) GetInvoiceByStripeID(ctx context.Context, stripeInvoiceID string) (*Invoice, error) {
+	return ScanInvoice(r.db.QueryRow(ctx, `
+		SELECT `+invoiceColumns+`
+		FROM invoices
+		WHERE stripe_invoice_id = $1
+	`, stripeInvoiceID))
+}
+
+// GetInvoicesByUserID retrieves a page of a user's invoices, newest period first
+func (r *Repository) GetInvoicesByUserID(ctx context.Context, projectID uuid.UUID, userID string, limit, offset int) ([]*Invoice, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+invoiceColumns+`
+		FROM invoices
+		WHERE project_id = $1 AND user_id = $2
+		ORDER BY period_end DESC, created_at DESC, id
+		LIMIT $3 OFFSET $4
+	`, projectID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []*Invoice{}
+	for rows.Next() {
+		invoice, err := ScanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+
+	return invoices, rows.Err()
+}
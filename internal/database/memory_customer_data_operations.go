@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Customer data operations of MemoryRepository
+
+// EraseCustomer anonymizes the personal data of a customer, mirroring the Postgres repository.
+// It returns ErrNotFound if the customer does not exist.
+func (m *MemoryRepository) EraseCustomer(ctx context.Context, customerID uuid.UUID) error {
+	defer m.lock()()
+
+	customer, ok := m.state.customers[customerID]
+	if !ok {
+		return errNoRows
+	}
+	projectID, userID, stripeCustomerID := customer.ProjectID, customer.UserID, customer.StripeCustomerID
+	erasedUserID := ErasedUserID(customerID)
+
+	now := time.Now()
+	customer.UserID, customer.Email, customer.Name, customer.DefaultPaymentMethod = erasedUserID, "", "", ""
+	if customer.ErasedAt == nil {
+		customer.ErasedAt = timePtr(now)
+	}
+	customer.UpdatedAt = now
+
+	subscriptionIDs := make(map[uuid.UUID]bool)
+	for _, sub := range m.state.subscriptions {
+		if sub.CustomerID == customerID {
+			sub.UserID, sub.UpdatedAt = erasedUserID, now
+			subscriptionIDs[sub.ID] = true
+		}
+	}
+	for _, event := range m.state.subscriptionLog {
+		if subscriptionIDs[event.SubscriptionID] {
+			event.UserID = erasedUserID
+		}
+	}
+	for _, order := range m.state.orders {
+		if order.CustomerID == customerID {
+			order.UserID, order.UpdatedAt = erasedUserID, now
+		}
+	}
+	for _, invoice := range m.state.invoices {
+		if invoice.CustomerID == customerID {
+			invoice.UserID, invoice.HostedInvoiceURL, invoice.InvoicePDF, invoice.UpdatedAt = erasedUserID, "", "", now
+		}
+	}
+	for _, refund := range m.state.refunds {
+		if refund.CustomerID == customerID {
+			refund.UserID, refund.UpdatedAt = erasedUserID, now
+		}
+	}
+	for _, dispute := range m.state.disputes {
+		if dispute.CustomerID == customerID {
+			dispute.UserID, dispute.UpdatedAt = erasedUserID, now
+		}
+	}
+
+	kept := m.state.deliveries[:0]
+	for _, delivery := range m.state.deliveries {
+		if delivery.ProjectID != projectID || deliveryUserID(delivery.Payload) != userID {
+			kept = append(kept, delivery)
+		}
+	}
+	m.state.deliveries = kept
+	for id, notification := range m.state.notifications {
+		if notification.ProjectID == projectID && deliveryUserID(notification.Payload) == userID {
+			delete(m.state.notifications, id)
+		}
+	}
+
+	// An event held by a queue worker keeps its status, the worker finishes it with the body it loaded
+	if stripeCustomerID != "" {
+		for _, event := range m.state.events {
+			redacted, ok := redactStripeEventPayload(event.Payload, stripeCustomerID)
+			if !ok {
+				continue
+			}
+			event.Payload, event.UpdatedAt = redacted, now
+			if event.Status != EventStatusProcessed && event.Status != EventStatusIgnored && !event.lockedByWorker(now) {
+				event.Status, event.lockedUntil = EventStatusIgnored, nil
+			}
+		}
+	}
+	return nil
+}
+
+// ExportCustomerData collects everything stored about a project's user.
+// It returns ErrNotFound if the user has no customer in the project.
+func (m *MemoryRepository) ExportCustomerData(ctx context.Context, projectID uuid.UUID, userID string) (*CustomerExport, error) {
+	customer, err := m.GetCustomerByUserID(ctx, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	export := &CustomerExport{Customer: customer}
+	if export.Orders, err = m.GetOrdersByUserID(ctx, projectID, userID); err != nil {
+		return nil, err
+	}
+	if export.Invoices, err = m.GetInvoicesByUserID(ctx, projectID, userID, -1, 0); err != nil {
+		return nil, err
+	}
+
+	defer m.lock()()
+
+	export.Subscriptions = []*Subscription{}
+	subscriptionIDs := make(map[uuid.UUID]bool)
+	for _, sub := range m.sortedSubscriptions() {
+		if sub.CustomerID == customer.ID {
+			copied := *sub
+			export.Subscriptions = append(export.Subscriptions, &copied)
+			subscriptionIDs[sub.ID] = true
+		}
+	}
+
+	export.SubscriptionItems = []*SubscriptionItem{}
+	for _, item := range m.state.subscriptionItems {
+		if subscriptionIDs[item.SubscriptionID] {
+			copied := *item
+			export.SubscriptionItems = append(export.SubscriptionItems, &copied)
+		}
+	}
+	sort.Slice(export.SubscriptionItems, func(i, j int) bool {
+		a, b := export.SubscriptionItems[i], export.SubscriptionItems[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.StripeSubscriptionItemID < b.StripeSubscriptionItemID
+	})
+
+	export.SubscriptionHistory = []*SubscriptionEvent{}
+	for _, event := range m.state.subscriptionLog {
+		if event.ProjectID == projectID && event.UserID == userID {
+			copied := *event
+			export.SubscriptionHistory = append(export.SubscriptionHistory, &copied)
+		}
+	}
+
+	export.Refunds = []*Refund{}
+	for _, refund := range m.state.refunds {
+		if refund.CustomerID == customer.ID {
+			copied := *refund
+			export.Refunds = append(export.Refunds, &copied)
+		}
+	}
+	sort.SliceStable(export.Refunds, func(i, j int) bool { return export.Refunds[i].CreatedAt.Before(export.Refunds[j].CreatedAt) })
+
+	export.Disputes = []*Dispute{}
+	for _, dispute := range m.state.disputes {
+		if dispute.CustomerID == customer.ID {
+			copied := *dispute
+			export.Disputes = append(export.Disputes, &copied)
+		}
+	}
+	sort.SliceStable(export.Disputes, func(i, j int) bool { return export.Disputes[i].CreatedAt.Before(export.Disputes[j].CreatedAt) })
+
+	return export, nil
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Customer operations of MemoryRepository
+
+// EnsureCustomer returns the customer of a project's user, creating it without a Stripe customer
+// if there is none yet
+func (m *MemoryRepository) EnsureCustomer(ctx context.Context, projectID uuid.UUID, userID, email string) (*Customer, error) {
+	defer m.lock()()
+
+	customer := m.findCustomer(projectID, userID)
+	if customer == nil {
+		now := time.Now()
+		customer = &memoryCustomer{Customer: Customer{
+			ID:        uuid.New(),
+			ProjectID: projectID,
+			UserID:    userID,
+			Email:     email,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}}
+		m.state.customers[customer.ID] = customer
+	}
+
+	copied := customer.Customer
+	return &copied, nil
+}
+
+// LockCustomer must be called within WithTx, which already serializes all access to the repository
+func (m *MemoryRepository) LockCustomer(ctx context.Context, projectID uuid.UUID, userID string) error {
+	if !m.inTx {
+		return ErrLockOutsideTx
+	}
+	return nil
+}
+
+// findCustomer returns the stored customer of a project's user, nil if there is none
+func (m *MemoryRepository) findCustomer(projectID uuid.UUID, userID string) *memoryCustomer {
+	for _, customer := range m.state.customers {
+		if customer.ProjectID == projectID && customer.UserID == userID {
+			return customer
+		}
+	}
+	return nil
+}
+
+// checkStripeCustomerIDUnique enforces the unique Stripe customer ID of customers other than customerID
+func (m *MemoryRepository) checkStripeCustomerIDUnique(customerID uuid.UUID, stripeCustomerID string) error {
+	if stripeCustomerID == "" {
+		return nil
+	}
+	for _, customer := range m.state.customers {
+		if customer.ID != customerID && customer.StripeCustomerID == stripeCustomerID {
+			return uniqueViolation("customers_stripe_customer_id_key")
+		}
+	}
+	return nil
+}
+
+// UpdateCustomerStripeID updates the Stripe customer ID for an existing customer
+func (m *MemoryRepository) UpdateCustomerStripeID(ctx context.Context, projectID uuid.UUID, userID, stripeCustomerID string) error {
+	defer m.lock()()
+
+	customer := m.findCustomer(projectID, userID)
+	if customer == nil {
+		return nil
+	}
+	if err := m.checkStripeCustomerIDUnique(customer.ID, stripeCustomerID); err != nil {
+		return err
+	}
+	customer.StripeCustomerID = stripeCustomerID
+	customer.UpdatedAt = time.Now()
+	return nil
+}
+
+// GetCustomerByStripeID retrieves customer by Stripe customer ID
+func (m *MemoryRepository) GetCustomerByStripeID(ctx context.Context, stripeCustomerID string) (*Customer, error) {
+	defer m.lock()()
+	for _, customer := range m.state.customers {
+		if stripeCustomerID != "" && customer.StripeCustomerID == stripeCustomerID {
+			copied := customer.Customer
+			return &copied, nil
+		}
+	}
+	return nil, errNoRows
+}
+
+// GetProjectCustomerByStripeID retrieves a project's customer by Stripe customer ID
+func (m *MemoryRepository) GetProjectCustomerByStripeID(ctx context.Context, projectID uuid.UUID, stripeCustomerID string) (*Customer, error) {
+	defer m.lock()()
+	for _, customer := range m.state.customers {
+		if customer.ProjectID == projectID && stripeCustomerID != "" && customer.StripeCustomerID == stripeCustomerID {
+			copied := customer.Customer
+			return &copied, nil
+		}
+	}
+	return nil, errNoRows
+}
+
+// UpdateCustomerDetails writes the email, name and default payment method carried by a customer.updated event.
+// An empty email keeps the stored one; events older than the last applied one and erased customers are skipped.
+func (m *MemoryRepository) UpdateCustomerDetails(ctx context.Context, customerID uuid.UUID, email, name, defaultPaymentMethod string, eventAt time.Time) (bool, error) {
+	defer m.lock()()
+
+	customer, ok := m.state.customers[customerID]
+	if !ok || customer.ErasedAt != nil || (customer.lastEventAt != nil && customer.lastEventAt.After(eventAt)) {
+		return false, nil
+	}
+	if email != "" {
+		customer.Email = email
+	}
+	customer.Name = name
+	customer.DefaultPaymentMethod = defaultPaymentMethod
+	customer.lastEventAt = timePtr(eventAt)
+	customer.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// DetachStripeCustomer clears the Stripe ID of a customer deleted in Stripe and ends its subscriptions.
+// It returns the Stripe IDs of the subscriptions that were ended.
+func (m *MemoryRepository) DetachStripeCustomer(ctx context.Context, customerID uuid.UUID, eventAt time.Time) ([]string, error) {
+	defer m.lock()()
+
+	ended := []string{}
+	for _, sub := range m.sortedSubscriptions() {
+		if sub.CustomerID != customerID || IsTerminalSubscriptionStatus(sub.Status) {
+			continue
+		}
+		sub.Status = SubscriptionStatusCanceled
+		if sub.EndedAt == nil {
+			sub.EndedAt = timePtr(eventAt)
+		}
+		sub.UpdatedAt = time.Now()
+		ended = append(ended, sub.StripeSubscriptionID)
+	}
+
+	if customer, ok := m.state.customers[customerID]; ok {
+		customer.StripeCustomerID = ""
+		customer.DefaultPaymentMethod = ""
+		customer.lastEventAt = timePtr(eventAt)
+		customer.UpdatedAt = time.Now()
+	}
+
+	return ended, nil
+}
+
+// GetCustomerByUserID retrieves a customer by User ID
+func (m *MemoryRepository) GetCustomerByUserID(ctx context.Context, projectID uuid.UUID, userID string) (*Customer, error) {
+	defer m.lock()()
+	customer := m.findCustomer(projectID, userID)
+	if customer == nil {
+		return nil, errNoRows
+	}
+	copied := customer.Customer
+	return &copied, nil
+}
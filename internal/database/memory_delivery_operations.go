@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outbound webhook delivery operations of MemoryRepository
+
+// RecordWebhookDelivery stores the outcome of one outbound webhook delivery attempt
+func (m *MemoryRepository) RecordWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error {
+	defer m.lock()()
+
+	delivery.ID, delivery.CreatedAt = uuid.New(), time.Now()
+	copied := *delivery
+	m.state.deliveries = append(m.state.deliveries, &copied)
+	return nil
+}
+
+// ListWebhookDeliveries lists the most recent delivery attempts for a project
+func (m *MemoryRepository) ListWebhookDeliveries(ctx context.Context, projectID uuid.UUID, limit int) ([]*WebhookDelivery, error) {
+	defer m.lock()()
+
+	var deliveries []*WebhookDelivery
+	for _, delivery := range m.state.deliveries {
+		if delivery.ProjectID == projectID {
+			copied := *delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		a, b := deliveries[i], deliveries[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.Attempt > b.Attempt
+	})
+	return page(deliveries, limit, 0), nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Dunning operations of MemoryRepository
+
+// StartDunning records a failed payment of a subscription. A subscription outside an open dunning
+// state enters grace; otherwise only its retry count is raised. A failure no later than a payment
+// of one of the subscription's invoices is ignored. It reports whether it entered grace.
+func (m *MemoryRepository) StartDunning(ctx context.Context, stripeSubID string, failedAt time.Time, attempts int64) (bool, error) {
+	defer m.lock()()
+
+	sub := m.findSubscription(stripeSubID)
+	if sub == nil {
+		return false, nil
+	}
+	for _, invoice := range m.state.invoices {
+		if invoice.StripeSubscriptionID == stripeSubID && invoice.PaidAt != nil && !invoice.PaidAt.Before(failedAt) {
+			return false, nil
+		}
+	}
+	sub.UpdatedAt = time.Now()
+	if sub.DunningState == "" || sub.DunningState == DunningStateRecovered {
+		sub.DunningState = DunningStateGrace
+		sub.DunningStartedAt = timePtr(failedAt)
+		sub.DunningRetries = attempts
+		return true, nil
+	}
+	if attempts > sub.DunningRetries {
+		sub.DunningRetries = attempts
+	}
+	return false, nil
+}
+
+// TransitionDunningState moves a subscription from one dunning state to another.
+// It reports false if the subscription was no longer in the from state.
+func (m *MemoryRepository) TransitionDunningState(ctx context.Context, stripeSubID, from, to string) (bool, error) {
+	defer m.lock()()
+
+	sub := m.findSubscription(stripeSubID)
+	if sub == nil || sub.DunningState != from {
+		return false, nil
+	}
+	sub.DunningState = to
+	sub.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// ListDunningSubscriptions lists subscriptions in grace or retrying with their project's dunning settings
+func (m *MemoryRepository) ListDunningSubscriptions(ctx context.Context) ([]*DunningSubscription, error) {
+	defer m.lock()()
+
+	var subscriptions []*DunningSubscription
+	for _, sub := range m.sortedSubscriptions() {
+		if sub.DunningState != DunningStateGrace && sub.DunningState != DunningStateRetrying {
+			continue
+		}
+		project, ok := m.state.projects[sub.ProjectID]
+		if !ok {
+			continue
+		}
+		subscriptions = append(subscriptions, &DunningSubscription{
+			Subscription: *sub,
+			GraceDays:    project.DunningGraceDays,
+			MaxRetries:   project.DunningMaxRetries,
+		})
+	}
+	return subscriptions, nil
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Stripe event admin operations of MemoryRepository
+
+// GetStripeEvent retrieves a stored Stripe event by its Stripe event ID
+func (m *MemoryRepository) GetStripeEvent(ctx context.Context, eventID string) (*StripeEvent, error) {
+	defer m.lock()()
+	event, ok := m.state.events[eventID]
+	if !ok {
+		return nil, errNoRows
+	}
+	copied := event.StripeEvent
+	return &copied, nil
+}
+
+// ListStripeEvents lists stored events of a type created within [from, to), newest first.
+// An empty eventType matches every type.
+func (m *MemoryRepository) ListStripeEvents(ctx context.Context, eventType string, from, to time.Time, limit int) ([]*StripeEvent, error) {
+	defer m.lock()()
+
+	var events []*StripeEvent
+	for _, event := range m.state.events {
+		if (eventType == "" || event.Type == eventType) && !event.Created.Before(from) && event.Created.Before(to) {
+			copied := event.StripeEvent
+			events = append(events, &copied)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Created.After(events[j].Created) })
+	return page(events, limit, 0), nil
+}
+
+// ListStripeEventsByStatus lists stored events in the given statuses created within [from, to), oldest first.
+// An empty eventType matches every type.
+func (m *MemoryRepository) ListStripeEventsByStatus(ctx context.Context, statuses []string, eventType string, from, to time.Time, limit int) ([]*StripeEvent, error) {
+	defer m.lock()()
+
+	matching := m.sortedEvents(func(event *memoryEvent) bool {
+		if (eventType != "" && event.Type != eventType) || event.Created.Before(from) || !event.Created.Before(to) {
+			return false
+		}
+		for _, status := range statuses {
+			if event.Status == status {
+				return true
+			}
+		}
+		return false
+	})
+
+	events := []*StripeEvent{}
+	for _, event := range page(matching, limit, 0) {
+		copied := event.StripeEvent
+		events = append(events, &copied)
+	}
+	return events, nil
+}
+
+// ClaimStripeEvent locks a single event for an admin replay regardless of its status.
+// It returns ErrStripeEventLocked while a worker holds the event and ErrNotFound if it does not exist.
+func (m *MemoryRepository) ClaimStripeEvent(ctx context.Context, eventID string, lease time.Duration) (*StripeEvent, error) {
+	defer m.lock()()
+
+	event, ok := m.state.events[eventID]
+	if !ok {
+		return nil, errNoRows
+	}
+	now := time.Now()
+	if event.lockedByWorker(now) {
+		return nil, ErrStripeEventLocked
+	}
+
+	event.Status = EventStatusProcessing
+	event.lockedUntil = timePtr(now.Add(lease))
+	event.UpdatedAt = now
+	copied := event.StripeEvent
+	return &copied, nil
+}
+
+// MarkStripeEventIgnored sets an event aside so the queue never processes it.
+// Processed events and events held by a worker are left alone; it reports whether the event was ignored.
+func (m *MemoryRepository) MarkStripeEventIgnored(ctx context.Context, eventID string) (bool, error) {
+	defer m.lock()()
+
+	event, ok := m.state.events[eventID]
+	now := time.Now()
+	if !ok || event.Status == EventStatusProcessed || event.lockedByWorker(now) {
+		return false, nil
+	}
+	event.Status, event.lockedUntil, event.UpdatedAt = EventStatusIgnored, nil, now
+	return true, nil
+}
+
+// RecordStripeEventAction stores an admin action taken on an event
+func (m *MemoryRepository) RecordStripeEventAction(ctx context.Context, action *StripeEventAction) error {
+	defer m.lock()()
+
+	action.ID, action.CreatedAt = uuid.New(), time.Now()
+	copied := *action
+	m.state.eventActions = append(m.state.eventActions, &copied)
+	return nil
+}
+
+// ListStripeEventActions lists the admin actions taken on an event, oldest first
+func (m *MemoryRepository) ListStripeEventActions(ctx context.Context, eventID string) ([]*StripeEventAction, error) {
+	defer m.lock()()
+
+	actions := []*StripeEventAction{}
+	for _, action := range m.state.eventActions {
+		if action.EventID == eventID {
+			copied := *action
+			actions = append(actions, &copied)
+		}
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].CreatedAt.Before(actions[j].CreatedAt) })
+	return actions, nil
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Stripe event processing queue operations of MemoryRepository
+
+// EnqueueStripeEvent stores a verified Stripe event for asynchronous processing.
+// It reports whether the event was newly queued; redeliveries of a stored event return false.
+func (m *MemoryRepository) EnqueueStripeEvent(ctx context.Context, event *StripeEvent) (bool, error) {
+	defer m.lock()()
+
+	if _, exists := m.state.events[event.ID]; exists {
+		return false, nil
+	}
+
+	now := time.Now()
+	m.state.events[event.ID] = &memoryEvent{StripeEvent: StripeEvent{
+		ID:            event.ID,
+		ProjectID:     event.ProjectID,
+		Type:          event.Type,
+		Created:       event.Created,
+		Payload:       event.Payload,
+		Status:        EventStatusPending,
+		NextAttemptAt: now,
+		ReceivedAt:    now,
+		UpdatedAt:     now,
+	}}
+	return true, nil
+}
+
+// sortedEvents returns the stored events matching keep, oldest first
+func (m *MemoryRepository) sortedEvents(keep func(*memoryEvent) bool) []*memoryEvent {
+	var events []*memoryEvent
+	for _, event := range m.state.events {
+		if keep(event) {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.Created.Equal(b.Created) {
+			return a.Created.Before(b.Created)
+		}
+		if !a.ReceivedAt.Equal(b.ReceivedAt) {
+			return a.ReceivedAt.Before(b.ReceivedAt)
+		}
+		return a.ID < b.ID
+	})
+	return events
+}
+
+// lockedByWorker reports whether a queue worker holds the event
+func (e *memoryEvent) lockedByWorker(now time.Time) bool {
+	return e.Status == EventStatusProcessing && (e.lockedUntil == nil || !e.lockedUntil.Before(now))
+}
+
+// ClaimStripeEvents locks up to limit due events for processing, oldest first.
+// A claim expires after lease so events held by a crashed worker are picked up again.
+func (m *MemoryRepository) ClaimStripeEvents(ctx context.Context, limit int, lease time.Duration) ([]*StripeEvent, error) {
+	defer m.lock()()
+
+	now := time.Now()
+	due := m.sortedEvents(func(event *memoryEvent) bool {
+		switch event.Status {
+		case EventStatusPending, EventStatusFailed:
+			return !event.NextAttemptAt.After(now)
+		case EventStatusProcessing:
+			return event.lockedUntil != nil && event.lockedUntil.Before(now)
+		}
+		return false
+	})
+
+	var events []*StripeEvent
+	for _, event := range page(due, limit, 0) {
+		event.Status = EventStatusProcessing
+		event.Attempts++
+		event.lockedUntil = timePtr(now.Add(lease))
+		event.UpdatedAt = now
+		copied := event.StripeEvent
+		events = append(events, &copied)
+	}
+	return events, nil
+}
+
+// updateStripeEvent applies update to a stored event; unknown events are left alone like an UPDATE matching no row
+func (m *MemoryRepository) updateStripeEvent(eventID string, update func(*memoryEvent)) {
+	defer m.lock()()
+
+	if event, ok := m.state.events[eventID]; ok {
+		update(event)
+		event.UpdatedAt = time.Now()
+	}
+}
+
+// MarkStripeEventProcessed marks an event as successfully processed
+func (m *MemoryRepository) MarkStripeEventProcessed(ctx context.Context, eventID string) error {
+	m.updateStripeEvent(eventID, func(event *memoryEvent) {
+		event.Status, event.LastError, event.lockedUntil = EventStatusProcessed, "", nil
+		event.ProcessedAt = timePtr(time.Now())
+	})
+	return nil
+}
+
+// MarkStripeEventFailed records a processing failure and schedules the next attempt
+func (m *MemoryRepository) MarkStripeEventFailed(ctx context.Context, eventID, lastError string, nextAttemptAt time.Time) error {
+	m.updateStripeEvent(eventID, func(event *memoryEvent) {
+		event.Status, event.LastError, event.lockedUntil = EventStatusFailed, lastError, nil
+		event.NextAttemptAt = nextAttemptAt
+	})
+	return nil
+}
+
+// MarkStripeEventDeadLetter records a final processing failure; the event is not retried again
+func (m *MemoryRepository) MarkStripeEventDeadLetter(ctx context.Context, eventID, lastError string) error {
+	m.updateStripeEvent(eventID, func(event *memoryEvent) {
+		event.Status, event.LastError, event.lockedUntil = EventStatusDeadLetter, lastError, nil
+	})
+	return nil
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invoice operations of MemoryRepository
+
+// UpsertInvoice stores the latest state of an invoice from an event created at invoice.LastEventAt.
+// It reports whether the write was applied; events older than the stored state and writes to
+// another project's invoice are ignored.
+func (m *MemoryRepository) UpsertInvoice(ctx context.Context, invoice *Invoice) (bool, error) {
+	defer m.lock()()
+
+	now := time.Now()
+	stored, ok := m.state.invoices[invoice.StripeInvoiceID]
+	if !ok {
+		copied := *invoice
+		copied.ID, copied.CreatedAt, copied.UpdatedAt = uuid.New(), now, now
+		m.state.invoices[invoice.StripeInvoiceID] = &copied
+		return true, nil
+	}
+	if stored.ProjectID != invoice.ProjectID || stored.LastEventAt.After(invoice.LastEventAt) {
+		return false, nil
+	}
+
+	paymentIntentID, paymentFailedAt := stored.StripePaymentIntentID, stored.PaymentFailedAt
+	id, projectID, customerID, userID, createdAt := stored.ID, stored.ProjectID, stored.CustomerID, stored.UserID, stored.CreatedAt
+	*stored = *invoice
+	stored.ID, stored.ProjectID, stored.CustomerID, stored.UserID, stored.CreatedAt = id, projectID, customerID, userID, createdAt
+	if stored.StripePaymentIntentID == "" {
+		stored.StripePaymentIntentID = paymentIntentID
+	}
+	if stored.PaymentFailedAt == nil {
+		stored.PaymentFailedAt = paymentFailedAt
+	}
+	stored.UpdatedAt = now
+	return true, nil
+}
+
+// GetInvoiceByStripeID retrieves an invoice by its Stripe invoice ID
+func (m *MemoryRepository) GetInvoiceByStripeID(ctx context.Context, stripeInvoiceID string) (*Invoice, error) {
+	defer m.lock()()
+	invoice, ok := m.state.invoices[stripeInvoiceID]
+	if !ok {
+		return nil, errNoRows
+	}
+	copied := *invoice
+	return &copied, nil
+}
+
+// GetInvoicesByUserID retrieves a page of a user's invoices, newest period first
+func (m *MemoryRepository) GetInvoicesByUserID(ctx context.Context, projectID uuid.UUID, userID string, limit, offset int) ([]*Invoice, error) {
+	defer m.lock()()
+
+	invoices := []*Invoice{}
+	for _, invoice := range m.state.invoices {
+		if invoice.ProjectID == projectID && invoice.UserID == userID {
+			copied := *invoice
+			invoices = append(invoices, &copied)
+		}
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		a, b := invoices[i], invoices[j]
+		if !a.PeriodEnd.Equal(b.PeriodEnd) {
+			return a.PeriodEnd.After(b.PeriodEnd)
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	return page(invoices, limit, offset), nil
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pending notification operations of MemoryRepository
+
+// EnqueueNotification stores a notification for delivery; storing the same notification twice is a no-op
+func (m *MemoryRepository) EnqueueNotification(ctx context.Context, notification *PendingNotification) error {
+	defer m.lock()()
+
+	if _, exists := m.state.notifications[notification.ID]; exists {
+		return nil
+	}
+	now := time.Now()
+	m.state.notifications[notification.ID] = &memoryNotification{PendingNotification: PendingNotification{
+		ID:            notification.ID,
+		ProjectID:     notification.ProjectID,
+		EventType:     notification.EventType,
+		Payload:       append([]byte(nil), notification.Payload...),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}}
+	return nil
+}
+
+// ClaimNotifications locks up to limit due notifications for delivery, oldest first, and counts the
+// attempt. A claim expires after lease so notifications held by a stopped notifier are picked up again.
+func (m *MemoryRepository) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]*PendingNotification, error) {
+	defer m.lock()()
+
+	now := time.Now()
+	var due []*memoryNotification
+	for _, notification := range m.state.notifications {
+		if !notification.NextAttemptAt.After(now) && (notification.lockedUntil == nil || notification.lockedUntil.Before(now)) {
+			due = append(due, notification)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		a, b := due[i], due[j]
+		if !a.NextAttemptAt.Equal(b.NextAttemptAt) {
+			return a.NextAttemptAt.Before(b.NextAttemptAt)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	var claimed []*PendingNotification
+	for _, notification := range page(due, limit, 0) {
+		notification.Attempts++
+		notification.lockedUntil = timePtr(now.Add(lease))
+		copied := notification.PendingNotification
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+// RescheduleNotification releases a claimed notification after a failed attempt, to be retried at nextAttemptAt
+func (m *MemoryRepository) RescheduleNotification(ctx context.Context, notificationID uuid.UUID, lastError string, nextAttemptAt time.Time) error {
+	defer m.lock()()
+
+	if notification, ok := m.state.notifications[notificationID]; ok {
+		notification.LastError, notification.NextAttemptAt, notification.lockedUntil = lastError, nextAttemptAt, nil
+	}
+	return nil
+}
+
+// DeleteNotification removes a notification that was delivered or will not be retried again
+func (m *MemoryRepository) DeleteNotification(ctx context.Context, notificationID uuid.UUID) error {
+	defer m.lock()()
+	delete(m.state.notifications, notificationID)
+	return nil
+}
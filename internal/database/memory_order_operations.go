@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Order operations of MemoryRepository
+
+// copyOrder copies an order together with its line items
+func copyOrder(order *Order) *Order {
+	copied := *order
+	copied.Items = make([]*OrderItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		copiedItem := *item
+		copied.Items = append(copied.Items, &copiedItem)
+	}
+	return &copied
+}
+
+// CreateOrder stores an order and its line items.
+// Orders are keyed by checkout session, so recording the same session twice is a no-op.
+func (m *MemoryRepository) CreateOrder(ctx context.Context, order *Order) error {
+	defer m.lock()()
+
+	for _, existing := range m.state.orders {
+		if existing.StripeCheckoutSessionID == order.StripeCheckoutSessionID {
+			// Order was already recorded by an earlier delivery
+			return nil
+		}
+	}
+
+	now := time.Now()
+	order.ID, order.CreatedAt, order.UpdatedAt = uuid.New(), now, now
+	for _, item := range order.Items {
+		item.ID, item.OrderID, item.CreatedAt = uuid.New(), order.ID, now
+	}
+
+	stored := copyOrder(order)
+	stored.AmountRefunded, stored.RefundedAt = 0, nil
+	m.state.orders[order.ID] = stored
+	return nil
+}
+
+// GetOrdersByUserID retrieves all orders of a user with their line items, newest first
+func (m *MemoryRepository) GetOrdersByUserID(ctx context.Context, projectID uuid.UUID, userID string) ([]*Order, error) {
+	defer m.lock()()
+
+	orders := []*Order{}
+	for _, order := range m.state.orders {
+		if order.ProjectID != projectID || order.UserID != userID {
+			continue
+		}
+		copied := copyOrder(order)
+		copied.Entitled = m.orderEntitled(order)
+		orders = append(orders, copied)
+	}
+	sort.SliceStable(orders, func(i, j int) bool { return orders[i].CreatedAt.After(orders[j].CreatedAt) })
+	return orders, nil
+}
+
+// orderEntitled mirrors the entitled column of orderColumns
+func (m *MemoryRepository) orderEntitled(order *Order) bool {
+	if (order.PaymentStatus != "paid" && order.PaymentStatus != "no_payment_required") || order.RefundedAt != nil {
+		return false
+	}
+	for _, dispute := range m.state.disputes {
+		if dispute.OrderID != nil && *dispute.OrderID == order.ID && dispute.Status == "lost" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Registered product operations of MemoryRepository
+
+// checkProductPlanUnique enforces the unique plan name per project of products other than stripeProductID
+func (m *MemoryRepository) checkProductPlanUnique(stripeProductID string, projectID uuid.UUID, planName string) error {
+	for _, product := range m.state.products {
+		if product.StripeProductID != stripeProductID && product.ProjectID == projectID && product.PlanName == planName {
+			return uniqueViolation("registered_products_project_id_plan_name_key")
+		}
+	}
+	return nil
+}
+
+// CreateRegisteredProduct creates a new registered product.
+// A row already synced from the product's Stripe events is overwritten with the registered details;
+// a row of another project is left alone and reported as ErrConflict.
+func (m *MemoryRepository) CreateRegisteredProduct(ctx context.Context, product *RegisteredProduct) error {
+	defer m.lock()()
+
+	stored, ok := m.state.products[product.StripeProductID]
+	if ok && stored.ProjectID != product.ProjectID {
+		return errProductOfAnotherProject(product.StripeProductID)
+	}
+	if err := m.checkProductPlanUnique(product.StripeProductID, product.ProjectID, product.PlanName); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if !ok {
+		stored = &memoryProduct{RegisteredProduct: RegisteredProduct{
+			ID:              uuid.New(),
+			ProjectID:       product.ProjectID,
+			StripeProductID: product.StripeProductID,
+			Active:          true,
+			CreatedAt:       now,
+		}}
+		m.state.products[product.StripeProductID] = stored
+	}
+	stored.PlanName = product.PlanName
+	stored.StripePriceMonthly, stored.StripePriceYearly = product.StripePriceMonthly, product.StripePriceYearly
+	stored.MonthlyAmount, stored.YearlyAmount = product.MonthlyAmount, product.YearlyAmount
+	stored.Currency = product.Currency
+	stored.Description = product.Description
+	stored.Features = product.Features
+	stored.UpdatedAt = now
+
+	product.ID, product.CreatedAt, product.UpdatedAt = stored.ID, stored.CreatedAt, stored.UpdatedAt
+	return nil
+}
+
+// GetRegisteredProductsByProject retrieves all registered products for a project, newest first
+func (m *MemoryRepository) GetRegisteredProductsByProject(ctx context.Context, projectID uuid.UUID) ([]*RegisteredProduct, error) {
+	defer m.lock()()
+
+	var products []*RegisteredProduct
+	for _, product := range m.state.products {
+		if product.ProjectID == projectID {
+			copied := product.RegisteredProduct
+			products = append(products, &copied)
+		}
+	}
+	sort.SliceStable(products, func(i, j int) bool { return products[i].CreatedAt.After(products[j].CreatedAt) })
+	return products, nil
+}
+
+// ProductExistsForProject checks if a product with the given plan name already exists for a project
+func (m *MemoryRepository) ProductExistsForProject(ctx context.Context, projectID uuid.UUID, planName string) (bool, string, error) {
+	defer m.lock()()
+	for _, product := range m.state.products {
+		if product.ProjectID == projectID && product.PlanName == planName {
+			return true, product.StripeProductID, nil
+		}
+	}
+	return false, "", nil
+}
+
+// GetRegisteredProductByStripeID retrieves a registered product by its Stripe product ID
+func (m *MemoryRepository) GetRegisteredProductByStripeID(ctx context.Context, stripeProductID string) (*RegisteredProduct, error) {
+	defer m.lock()()
+	product, ok := m.state.products[stripeProductID]
+	if !ok {
+		return nil, errNoRows
+	}
+	copied := product.RegisteredProduct
+	return &copied, nil
+}
+
+// staleEvent reports whether an event created at eventAt is older than the last one applied to the product
+func (p *memoryProduct) staleEvent(eventAt time.Time) bool {
+	return p.lastEventAt != nil && p.lastEventAt.After(eventAt)
+}
+
+// SyncRegisteredProduct upserts the catalog entry of a Stripe product from a product.* event.
+// Prices are left alone; events older than the last applied one and entries of another project are skipped.
+func (m *MemoryRepository) SyncRegisteredProduct(ctx context.Context, product *RegisteredProduct, eventAt time.Time) (bool, error) {
+	defer m.lock()()
+
+	stored, ok := m.state.products[product.StripeProductID]
+	if ok && (stored.ProjectID != product.ProjectID || stored.staleEvent(eventAt)) {
+		return false, nil
+	}
+	if err := m.checkProductPlanUnique(product.StripeProductID, product.ProjectID, product.PlanName); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	if !ok {
+		stored = &memoryProduct{RegisteredProduct: RegisteredProduct{
+			ID:              uuid.New(),
+			ProjectID:       product.ProjectID,
+			StripeProductID: product.StripeProductID,
+			Currency:        "usd",
+			ArchivedAt:      product.ArchivedAt,
+			CreatedAt:       now,
+		}}
+		m.state.products[product.StripeProductID] = stored
+	} else if product.Active {
+		stored.ArchivedAt = nil
+	} else if stored.ArchivedAt == nil {
+		stored.ArchivedAt = product.ArchivedAt
+	}
+	stored.PlanName = product.PlanName
+	stored.Description = product.Description
+	stored.Active = product.Active
+	stored.lastEventAt = timePtr(eventAt)
+	stored.UpdatedAt = now
+	return true, nil
+}
+
+// ArchiveRegisteredProduct marks a product deleted in Stripe as inactive, reporting whether it was known
+func (m *MemoryRepository) ArchiveRegisteredProduct(ctx context.Context, stripeProductID string, archivedAt time.Time) (bool, error) {
+	defer m.lock()()
+
+	product, ok := m.state.products[stripeProductID]
+	if !ok {
+		return false, nil
+	}
+	product.Active = false
+	if product.ArchivedAt == nil {
+		product.ArchivedAt = timePtr(archivedAt)
+	}
+	if product.lastEventAt == nil || archivedAt.After(*product.lastEventAt) {
+		product.lastEventAt = timePtr(archivedAt)
+	}
+	product.UpdatedAt = time.Now()
+	return true, nil
+}
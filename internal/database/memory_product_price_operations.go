@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Registered product price operations of MemoryRepository
+
+// SetRegisteredProductPrice stores an active recurring price from an event created at eventAt in the
+// product's monthly or yearly slot. It reports whether the product is known and the event is not stale.
+func (m *MemoryRepository) SetRegisteredProductPrice(ctx context.Context, stripeProductID, interval, stripePriceID string, amount int64, currency string, eventAt time.Time) (bool, error) {
+	if _, _, err := priceColumns(interval); err != nil {
+		return false, err
+	}
+
+	defer m.lock()()
+
+	product, ok := m.state.products[stripeProductID]
+	if !ok || product.staleEvent(eventAt) {
+		return false, nil
+	}
+	if interval == PriceIntervalMonth {
+		product.StripePriceMonthly, product.MonthlyAmount = stripePriceID, amount
+	} else {
+		product.StripePriceYearly, product.YearlyAmount = stripePriceID, amount
+	}
+	product.Currency = currency
+	product.lastEventAt = timePtr(eventAt)
+	product.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// ClearRegisteredProductPrice empties the product's monthly or yearly slot if it still holds a price
+// archived by an event created at eventAt, unless the event is stale
+func (m *MemoryRepository) ClearRegisteredProductPrice(ctx context.Context, stripeProductID, interval, stripePriceID string, eventAt time.Time) (bool, error) {
+	if _, _, err := priceColumns(interval); err != nil {
+		return false, err
+	}
+
+	defer m.lock()()
+
+	product, ok := m.state.products[stripeProductID]
+	if !ok || product.staleEvent(eventAt) {
+		return false, nil
+	}
+	switch {
+	case interval == PriceIntervalMonth && product.StripePriceMonthly == stripePriceID:
+		product.StripePriceMonthly, product.MonthlyAmount = "", 0
+	case interval == PriceIntervalYear && product.StripePriceYearly == stripePriceID:
+		product.StripePriceYearly, product.YearlyAmount = "", 0
+	default:
+		return false, nil
+	}
+	product.lastEventAt = timePtr(eventAt)
+	product.UpdatedAt = time.Now()
+	return true, nil
+}
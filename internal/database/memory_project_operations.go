@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Project operations of MemoryRepository
+
+// CreateProject creates a new project with a generated API key
+func (m *MemoryRepository) CreateProject(ctx context.Context, name, webhookURL string) (*Project, error) {
+	apiKey, err := GenerateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	webhookSecret, err := GenerateWebhookSigningSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook signing secret: %w", err)
+	}
+
+	project := &Project{
+		ID:                   uuid.New(),
+		Name:                 name,
+		APIKey:               apiKey,
+		WebhookURL:           webhookURL,
+		WebhookSigningSecret: webhookSecret,
+		DunningGraceDays:     DefaultDunningGraceDays,
+		DunningMaxRetries:    DefaultDunningMaxRetries,
+		IsActive:             true,
+	}
+
+	defer m.lock()()
+	stored := *project
+	stored.CreatedAt, stored.UpdatedAt = time.Now(), time.Now()
+	if err := m.insertProject(&stored); err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return project, nil
+}
+
+// insertProject stores a new project, enforcing the unique ID and API key
+func (m *MemoryRepository) insertProject(project *Project) error {
+	if _, exists := m.state.projects[project.ID]; exists {
+		return uniqueViolation("projects_pkey")
+	}
+	for _, existing := range m.state.projects {
+		if existing.APIKey == project.APIKey {
+			return uniqueViolation("projects_api_key_key")
+		}
+	}
+	m.state.projects[project.ID] = project
+	return nil
+}
+
+// GetProjectByAPIKey retrieves a project by its API key
+func (m *MemoryRepository) GetProjectByAPIKey(ctx context.Context, apiKey string) (*Project, error) {
+	defer m.lock()()
+	for _, project := range m.state.projects {
+		if project.APIKey == apiKey && project.IsActive {
+			copied := *project
+			return &copied, nil
+		}
+	}
+	return nil, errNoRows
+}
+
+// GetProjectByID retrieves a project by its ID
+func (m *MemoryRepository) GetProjectByID(ctx context.Context, projectID uuid.UUID) (*Project, error) {
+	defer m.lock()()
+	project, ok := m.state.projects[projectID]
+	if !ok {
+		return nil, errNoRows
+	}
+	copied := *project
+	return &copied, nil
+}
+
+// ListProjects retrieves all projects, newest first
+func (m *MemoryRepository) ListProjects(ctx context.Context) ([]*Project, error) {
+	defer m.lock()()
+	var projects []*Project
+	for _, project := range m.state.projects {
+		copied := *project
+		projects = append(projects, &copied)
+	}
+	sort.SliceStable(projects, func(i, j int) bool { return projects[i].CreatedAt.After(projects[j].CreatedAt) })
+	return projects, nil
+}
+
+// UpdateProjectDunningSettings sets the grace period and retry count used for a project's failed payments
+func (m *MemoryRepository) UpdateProjectDunningSettings(ctx context.Context, projectID uuid.UUID, graceDays, maxRetries int) error {
+	defer m.lock()()
+	project, ok := m.state.projects[projectID]
+	if !ok {
+		return errNoRows
+	}
+	project.DunningGraceDays, project.DunningMaxRetries = graceDays, maxRetries
+	project.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateProjectStripeSettings sets the Stripe account a project's webhook events are processed with
+func (m *MemoryRepository) UpdateProjectStripeSettings(ctx context.Context, projectID uuid.UUID, secretKey, webhookSecret string) error {
+	defer m.lock()()
+	project, ok := m.state.projects[projectID]
+	if !ok {
+		return errNoRows
+	}
+	project.StripeSecretKey, project.StripeWebhookSecret = secretKey, webhookSecret
+	project.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,75 @@
+package database
+
+import "encoding/json"
+
+// Payload redaction helpers of MemoryRepository
+
+// deliveryUserID returns the user a stored notification payload is about, empty if it names none
+func deliveryUserID(payload []byte) string {
+	var notification struct {
+		Data struct {
+			UserID string `json:"user_id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &notification); err != nil {
+		return ""
+	}
+	return notification.Data.UserID
+}
+
+// redactStripeEventPayload strips the object of a Stripe event down to its ID and type if the object
+// is the Stripe customer or belongs to it. It reports whether the payload was redacted.
+func redactStripeEventPayload(payload []byte, stripeCustomerID string) ([]byte, bool) {
+	var event map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, false
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(event["data"], &data); err != nil {
+		return nil, false
+	}
+	var object struct {
+		ID       json.RawMessage `json:"id"`
+		Object   json.RawMessage `json:"object"`
+		Customer json.RawMessage `json:"customer"`
+	}
+	if err := json.Unmarshal(data["object"], &object); err != nil {
+		return nil, false
+	}
+
+	var id, objectType, customer string
+	var expanded struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(object.ID, &id)
+	_ = json.Unmarshal(object.Object, &objectType)
+	if json.Unmarshal(object.Customer, &customer) != nil && json.Unmarshal(object.Customer, &expanded) == nil {
+		customer = expanded.ID
+	}
+	if customer != stripeCustomerID && (objectType != "customer" || id != stripeCustomerID) {
+		return nil, false
+	}
+
+	redactedObject, err := json.Marshal(map[string]json.RawMessage{
+		"id":       nullIfEmpty(object.ID),
+		"object":   nullIfEmpty(object.Object),
+		"redacted": json.RawMessage("true"),
+	})
+	if err != nil {
+		return nil, false
+	}
+	data["object"] = redactedObject
+	if event["data"], err = json.Marshal(data); err != nil {
+		return nil, false
+	}
+	redacted, err := json.Marshal(event)
+	return redacted, err == nil
+}
+
+// nullIfEmpty returns JSON null for a missing value, like jsonb_build_object does for a missing path
+func nullIfEmpty(value json.RawMessage) json.RawMessage {
+	if len(value) == 0 {
+		return json.RawMessage("null")
+	}
+	return value
+}
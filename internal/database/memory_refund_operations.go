@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Refund and dispute operations of MemoryRepository
+
+// GetChargeLinks finds the order or invoice a charge paid for, by payment intent or Stripe invoice ID.
+// It returns ErrNotFound when the charge belongs to neither.
+func (m *MemoryRepository) GetChargeLinks(ctx context.Context, paymentIntentID, stripeInvoiceID string) (*ChargeLinks, error) {
+	defer m.lock()()
+
+	if paymentIntentID != "" {
+		for _, order := range m.state.orders {
+			if order.StripePaymentIntentID == paymentIntentID {
+				orderID := order.ID
+				return &ChargeLinks{ProjectID: order.ProjectID, CustomerID: order.CustomerID, UserID: order.UserID, OrderID: &orderID}, nil
+			}
+		}
+	}
+
+	for _, invoice := range m.state.invoices {
+		if (stripeInvoiceID != "" && invoice.StripeInvoiceID == stripeInvoiceID) ||
+			(paymentIntentID != "" && invoice.StripePaymentIntentID == paymentIntentID) {
+			invoiceID := invoice.ID
+			return &ChargeLinks{ProjectID: invoice.ProjectID, CustomerID: invoice.CustomerID, UserID: invoice.UserID, InvoiceID: &invoiceID}, nil
+		}
+	}
+	return nil, errNoRows
+}
+
+// UpsertRefund stores the latest state of a refund; another project's refund is left alone
+func (m *MemoryRepository) UpsertRefund(ctx context.Context, refund *Refund) error {
+	defer m.lock()()
+
+	now := time.Now()
+	stored, ok := m.state.refunds[refund.StripeRefundID]
+	if !ok {
+		copied := *refund
+		copied.ID, copied.CreatedAt, copied.UpdatedAt = uuid.New(), now, now
+		m.state.refunds[refund.StripeRefundID] = &copied
+		return nil
+	}
+	if stored.ProjectID != refund.ProjectID {
+		return nil
+	}
+	stored.Amount, stored.Status, stored.Reason = refund.Amount, refund.Status, refund.Reason
+	stored.UpdatedAt = now
+	return nil
+}
+
+// GetRefundByStripeID retrieves a refund by its Stripe refund ID
+func (m *MemoryRepository) GetRefundByStripeID(ctx context.Context, stripeRefundID string) (*Refund, error) {
+	defer m.lock()()
+	refund, ok := m.state.refunds[stripeRefundID]
+	if !ok {
+		return nil, errNoRows
+	}
+	copied := *refund
+	return &copied, nil
+}
+
+// MarkOrderRefunded records the refunded amount of an order; refundedAt flags it as fully refunded.
+// Refunded amounts only grow and a refund time is never cleared, so an event delivered late cannot
+// lower them or make a fully refunded order entitled again.
+func (m *MemoryRepository) MarkOrderRefunded(ctx context.Context, orderID uuid.UUID, amountRefunded int64, refundedAt *time.Time) error {
+	defer m.lock()()
+
+	order, ok := m.state.orders[orderID]
+	if !ok {
+		return nil
+	}
+	if amountRefunded > order.AmountRefunded {
+		order.AmountRefunded = amountRefunded
+	}
+	if order.RefundedAt == nil && refundedAt != nil {
+		order.RefundedAt = timePtr(*refundedAt)
+	}
+	order.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpsertDispute stores the latest state of a dispute from an event created at dispute.LastEventAt.
+// It reports whether the write was applied; events older than the stored state and writes to
+// another project's dispute are ignored.
+func (m *MemoryRepository) UpsertDispute(ctx context.Context, dispute *Dispute) (bool, error) {
+	defer m.lock()()
+
+	now := time.Now()
+	stored, ok := m.state.disputes[dispute.StripeDisputeID]
+	if !ok {
+		copied := *dispute
+		copied.ID, copied.CreatedAt, copied.UpdatedAt = uuid.New(), now, now
+		m.state.disputes[dispute.StripeDisputeID] = &copied
+		return true, nil
+	}
+	if stored.ProjectID != dispute.ProjectID || stored.LastEventAt.After(dispute.LastEventAt) {
+		return false, nil
+	}
+	stored.Amount, stored.Status, stored.Reason = dispute.Amount, dispute.Status, dispute.Reason
+	stored.LastEventAt = dispute.LastEventAt
+	stored.UpdatedAt = now
+	return true, nil
+}
+
+// GetDisputeByStripeID retrieves a dispute by its Stripe dispute ID
+func (m *MemoryRepository) GetDisputeByStripeID(ctx context.Context, stripeDisputeID string) (*Dispute, error) {
+	defer m.lock()()
+	dispute, ok := m.state.disputes[stripeDisputeID]
+	if !ok {
+		return nil, errNoRows
+	}
+	copied := *dispute
+	return &copied, nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// MemoryRepository is an in-memory RepositoryInterface for tests and local development.
+// It enforces the unique constraints of the schema and reports violations and missing rows
+// with the same errors as Repository (ErrConflict and ErrNotFound); foreign keys
+// are not checked. All operations are serialized, so it is safe for concurrent use.
+type MemoryRepository struct {
+	mu    *sync.Mutex
+	state *memoryState
+	inTx  bool // Set on the repository passed to a WithTx function, which already holds mu
+}
+
+// NewMemoryRepository creates an empty in-memory repository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{mu: &sync.Mutex{}, state: newMemoryState()}
+}
+
+// lock takes the repository lock unless the caller runs inside WithTx, which already holds it
+func (m *MemoryRepository) lock() func() {
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+// WithTx runs fn with exclusive access to the repository. Its writes are undone if fn returns
+// an error, and a nested call only undoes its own writes, like a savepoint. Other callers wait
+// until fn returns, so fn must not use the outer repository.
+func (m *MemoryRepository) WithTx(ctx context.Context, fn func(RepositoryInterface) error) error {
+	unlock := m.lock()
+	defer unlock()
+
+	// Restore the snapshot unless fn succeeds, also when it panics
+	snapshot := m.state.clone()
+	committed := false
+	defer func() {
+		if !committed {
+			*m.state = *snapshot
+		}
+	}()
+
+	if err := fn(&MemoryRepository{mu: m.mu, state: m.state, inTx: true}); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// Ping always succeeds, there is no database to reach
+func (m *MemoryRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+// PoolStats returns empty statistics, the repository has no connection pool
+func (m *MemoryRepository) PoolStats() *PoolStats {
+	return &PoolStats{}
+}
+
+// uniqueViolation builds the error Repository returns for a violated unique constraint
+func uniqueViolation(constraint string) error {
+	return classifyError(&pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           sqlStateUniqueViolation,
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint \"%s\"", constraint),
+		ConstraintName: constraint,
+	})
+}
+
+// timePtr returns a pointer to a copy of t
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// page returns the items of a LIMIT/OFFSET page
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,87 @@
+package database
+
+import "github.com/google/uuid"
+
+// Test data seeding of MemoryRepository
+
+// seedProject stores a test project like CreateTestProject's upsert on the API key
+func (m *MemoryRepository) seedProject(project *Project) error {
+	defer m.lock()()
+
+	for _, existing := range m.state.projects {
+		if existing.APIKey == project.APIKey {
+			existing.Name, existing.WebhookURL = project.Name, project.WebhookURL
+			existing.WebhookSigningSecret = project.WebhookSigningSecret
+			existing.UpdatedAt = project.UpdatedAt
+			return nil
+		}
+	}
+
+	stored := *project
+	stored.StripeSecretKey, stored.StripeWebhookSecret = "", ""
+	stored.DunningGraceDays, stored.DunningMaxRetries = DefaultDunningGraceDays, DefaultDunningMaxRetries
+	return m.insertProject(&stored)
+}
+
+// seedCustomer stores a test customer like CreateTestCustomer's upsert on the project and user
+func (m *MemoryRepository) seedCustomer(customer *Customer) error {
+	defer m.lock()()
+
+	existing := m.findCustomer(customer.ProjectID, customer.UserID)
+	if existing == nil {
+		if _, exists := m.state.customers[customer.ID]; exists {
+			return uniqueViolation("customers_pkey")
+		}
+		if err := m.checkStripeCustomerIDUnique(customer.ID, customer.StripeCustomerID); err != nil {
+			return err
+		}
+		m.state.customers[customer.ID] = &memoryCustomer{Customer: *customer}
+		return nil
+	}
+
+	if err := m.checkStripeCustomerIDUnique(existing.ID, customer.StripeCustomerID); err != nil {
+		return err
+	}
+	existing.Email, existing.StripeCustomerID = customer.Email, customer.StripeCustomerID
+	existing.UpdatedAt = customer.UpdatedAt
+	return nil
+}
+
+// seedSubscription stores a test subscription like CreateTestSubscription's upsert on the project, user and product
+func (m *MemoryRepository) seedSubscription(subscription *Subscription) error {
+	defer m.lock()()
+
+	for _, existing := range m.state.subscriptions {
+		if existing.ProjectID != subscription.ProjectID || existing.UserID != subscription.UserID || existing.ProductID != subscription.ProductID {
+			continue
+		}
+		if err := m.checkStripeSubscriptionIDUnique(existing.ID, subscription.StripeSubscriptionID); err != nil {
+			return err
+		}
+		existing.StripeSubscriptionID, existing.CustomerID = subscription.StripeSubscriptionID, subscription.CustomerID
+		existing.Status = subscription.Status
+		existing.CurrentPeriodStart, existing.CurrentPeriodEnd = subscription.CurrentPeriodStart, subscription.CurrentPeriodEnd
+		existing.UpdatedAt = subscription.UpdatedAt
+		return nil
+	}
+
+	if err := m.checkStripeSubscriptionIDUnique(uuid.Nil, subscription.StripeSubscriptionID); err != nil {
+		return err
+	}
+	stored := Subscription{
+		ID:                   uuid.New(),
+		ProjectID:            subscription.ProjectID,
+		CustomerID:           subscription.CustomerID,
+		UserID:               subscription.UserID,
+		ProductID:            subscription.ProductID,
+		PriceID:              subscription.PriceID,
+		StripeSubscriptionID: subscription.StripeSubscriptionID,
+		Status:               subscription.Status,
+		CurrentPeriodStart:   subscription.CurrentPeriodStart,
+		CurrentPeriodEnd:     subscription.CurrentPeriodEnd,
+		CreatedAt:            subscription.CreatedAt,
+		UpdatedAt:            subscription.UpdatedAt,
+	}
+	m.state.subscriptions[stored.ID] = &stored
+	return nil
+}
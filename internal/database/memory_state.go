@@ -0,0 +1,126 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryState holds the rows of every table, keyed by their primary or natural key
+type memoryState struct {
+	projects          map[uuid.UUID]*Project
+	customers         map[uuid.UUID]*memoryCustomer
+	subscriptions     map[uuid.UUID]*Subscription
+	subscriptionItems map[string]*SubscriptionItem // By Stripe subscription item ID
+	subscriptionLog   []*SubscriptionEvent         // In the order the entries were recorded
+	products          map[string]*memoryProduct    // By Stripe product ID
+	orders            map[uuid.UUID]*Order
+	invoices          map[string]*Invoice // By Stripe invoice ID
+	refunds           map[string]*Refund  // By Stripe refund ID
+	disputes          map[string]*Dispute // By Stripe dispute ID
+	deliveries        []*WebhookDelivery
+	notifications     map[uuid.UUID]*memoryNotification
+	events            map[string]*memoryEvent
+	eventActions      []*StripeEventAction
+}
+
+// memoryCustomer is a customer row with the columns Customer does not expose
+type memoryCustomer struct {
+	Customer
+	lastEventAt *time.Time
+}
+
+// memoryProduct is a registered product row with the columns RegisteredProduct does not expose
+type memoryProduct struct {
+	RegisteredProduct
+	lastEventAt *time.Time
+}
+
+// memoryNotification is a pending notification with the columns PendingNotification does not expose
+type memoryNotification struct {
+	PendingNotification
+	lockedUntil *time.Time
+}
+
+// memoryEvent is a stored Stripe event with the columns StripeEvent does not expose
+type memoryEvent struct {
+	StripeEvent
+	lockedUntil *time.Time
+}
+
+func newMemoryState() *memoryState {
+	return &memoryState{
+		projects:          make(map[uuid.UUID]*Project),
+		customers:         make(map[uuid.UUID]*memoryCustomer),
+		subscriptions:     make(map[uuid.UUID]*Subscription),
+		subscriptionItems: make(map[string]*SubscriptionItem),
+		products:          make(map[string]*memoryProduct),
+		orders:            make(map[uuid.UUID]*Order),
+		invoices:          make(map[string]*Invoice),
+		refunds:           make(map[string]*Refund),
+		disputes:          make(map[string]*Dispute),
+		notifications:     make(map[uuid.UUID]*memoryNotification),
+		events:            make(map[string]*memoryEvent),
+	}
+}
+
+// clone copies every row so a transaction can be rolled back by restoring the copy
+func (s *memoryState) clone() *memoryState {
+	c := newMemoryState()
+	for id, project := range s.projects {
+		copied := *project
+		c.projects[id] = &copied
+	}
+	for id, customer := range s.customers {
+		copied := *customer
+		c.customers[id] = &copied
+	}
+	for id, sub := range s.subscriptions {
+		copied := *sub
+		c.subscriptions[id] = &copied
+	}
+	for id, item := range s.subscriptionItems {
+		copied := *item
+		c.subscriptionItems[id] = &copied
+	}
+	for _, event := range s.subscriptionLog {
+		copied := *event
+		c.subscriptionLog = append(c.subscriptionLog, &copied)
+	}
+	for id, product := range s.products {
+		copied := *product
+		c.products[id] = &copied
+	}
+	for id, order := range s.orders {
+		c.orders[id] = copyOrder(order)
+	}
+	for id, invoice := range s.invoices {
+		copied := *invoice
+		c.invoices[id] = &copied
+	}
+	for id, refund := range s.refunds {
+		copied := *refund
+		c.refunds[id] = &copied
+	}
+	for id, dispute := range s.disputes {
+		copied := *dispute
+		c.disputes[id] = &copied
+	}
+	for _, delivery := range s.deliveries {
+		copied := *delivery
+		c.deliveries = append(c.deliveries, &copied)
+	}
+	for id, notification := range s.notifications {
+		copied := *notification
+		c.notifications[id] = &copied
+	}
+	for id, event := range s.events {
+		copied := *event
+		c.events[id] = &copied
+	}
+	for _, action := range s.eventActions {
+		copied := *action
+		c.eventActions = append(c.eventActions, &copied)
+	}
+	return c
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription history operations of MemoryRepository
+
+// RecordSubscriptionEvent appends the current state of a subscription to its history, attributed to the
+// Stripe event that produced it. Nothing is recorded if the status, billing period end and Stripe
+// subscription are unchanged since the last entry; it reports whether an entry was added.
+func (m *MemoryRepository) RecordSubscriptionEvent(ctx context.Context, stripeSubID, sourceEventID, eventType string, occurredAt time.Time) (bool, error) {
+	defer m.lock()()
+
+	sub := m.findSubscription(stripeSubID)
+	if sub == nil {
+		return false, nil
+	}
+
+	event := &SubscriptionEvent{
+		ID:                   uuid.New(),
+		SubscriptionID:       sub.ID,
+		ProjectID:            sub.ProjectID,
+		UserID:               sub.UserID,
+		ProductID:            sub.ProductID,
+		StripeSubscriptionID: sub.StripeSubscriptionID,
+		Status:               sub.Status,
+		CurrentPeriodEnd:     sub.CurrentPeriodEnd,
+		SourceEventID:        sourceEventID,
+		EventType:            eventType,
+		OccurredAt:           occurredAt,
+		RecordedAt:           time.Now(),
+	}
+	for i := len(m.state.subscriptionLog) - 1; i >= 0; i-- {
+		last := m.state.subscriptionLog[i]
+		if last.SubscriptionID != sub.ID {
+			continue
+		}
+		if last.Status == sub.Status && last.CurrentPeriodEnd.Equal(sub.CurrentPeriodEnd) && last.StripeSubscriptionID == sub.StripeSubscriptionID {
+			return false, nil
+		}
+		event.PreviousStatus, event.PreviousPeriodEnd = last.Status, timePtr(last.CurrentPeriodEnd)
+		break
+	}
+
+	m.state.subscriptionLog = append(m.state.subscriptionLog, event)
+	return true, nil
+}
+
+// ListSubscriptionEvents lists the history of a user's subscription to a product, newest first
+func (m *MemoryRepository) ListSubscriptionEvents(ctx context.Context, projectID uuid.UUID, userID, productID string, limit, offset int) ([]*SubscriptionEvent, error) {
+	defer m.lock()()
+
+	events := []*SubscriptionEvent{}
+	for i := len(m.state.subscriptionLog) - 1; i >= 0; i-- {
+		event := m.state.subscriptionLog[i]
+		if event.ProjectID == projectID && event.UserID == userID && event.ProductID == productID {
+			copied := *event
+			events = append(events, &copied)
+		}
+	}
+	return page(events, limit, offset), nil
+}
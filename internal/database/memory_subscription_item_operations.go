@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription item operations of MemoryRepository
+
+// SyncSubscriptionItems replaces the stored items of a subscription with the given set.
+// Items are keyed by Stripe subscription item ID; items missing from the set are removed.
+func (m *MemoryRepository) SyncSubscriptionItems(ctx context.Context, stripeSubID string, items []*SubscriptionItem) error {
+	defer m.lock()()
+
+	sub := m.findSubscription(stripeSubID)
+	if sub == nil {
+		return errNoRows
+	}
+
+	keep := make(map[string]bool, len(items))
+	for _, item := range items {
+		keep[item.StripeSubscriptionItemID] = true
+	}
+	for id, stored := range m.state.subscriptionItems {
+		if stored.SubscriptionID == sub.ID && !keep[id] {
+			delete(m.state.subscriptionItems, id)
+		}
+	}
+
+	now := time.Now()
+	for _, item := range items {
+		stored, ok := m.state.subscriptionItems[item.StripeSubscriptionItemID]
+		if !ok {
+			stored = &SubscriptionItem{
+				ID:                       uuid.New(),
+				StripeSubscriptionItemID: item.StripeSubscriptionItemID,
+				CreatedAt:                now,
+			}
+			m.state.subscriptionItems[item.StripeSubscriptionItemID] = stored
+		}
+		stored.SubscriptionID = sub.ID
+		stored.PriceID, stored.ProductID, stored.Quantity = item.PriceID, item.ProductID, item.Quantity
+		stored.UpdatedAt = now
+
+		item.ID, item.SubscriptionID, item.CreatedAt, item.UpdatedAt = stored.ID, stored.SubscriptionID, stored.CreatedAt, stored.UpdatedAt
+	}
+	return nil
+}
+
+// GetSubscriptionItems retrieves the items of a subscription by Stripe subscription ID
+func (m *MemoryRepository) GetSubscriptionItems(ctx context.Context, stripeSubID string) ([]*SubscriptionItem, error) {
+	defer m.lock()()
+
+	items := []*SubscriptionItem{}
+	sub := m.findSubscription(stripeSubID)
+	if sub == nil {
+		return items, nil
+	}
+	for _, item := range m.state.subscriptionItems {
+		if item.SubscriptionID == sub.ID {
+			copied := *item
+			items = append(items, &copied)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.Before(items[j].CreatedAt)
+		}
+		return items[i].StripeSubscriptionItemID < items[j].StripeSubscriptionItemID
+	})
+	return items, nil
+}
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription operations of MemoryRepository
+
+// sortedSubscriptions returns the stored subscriptions, oldest first
+func (m *MemoryRepository) sortedSubscriptions() []*Subscription {
+	subs := make([]*Subscription, 0, len(m.state.subscriptions))
+	for _, sub := range m.state.subscriptions {
+		subs = append(subs, sub)
+	}
+	sort.SliceStable(subs, func(i, j int) bool { return subs[i].CreatedAt.Before(subs[j].CreatedAt) })
+	return subs
+}
+
+// findSubscription returns the subscription with a Stripe subscription ID, nil if there is none
+func (m *MemoryRepository) findSubscription(stripeSubID string) *Subscription {
+	for _, sub := range m.state.subscriptions {
+		if sub.StripeSubscriptionID == stripeSubID {
+			return sub
+		}
+	}
+	return nil
+}
+
+// checkStripeSubscriptionIDUnique enforces the unique Stripe subscription ID of subscriptions other than subID
+func (m *MemoryRepository) checkStripeSubscriptionIDUnique(subID uuid.UUID, stripeSubID string) error {
+	for _, sub := range m.state.subscriptions {
+		if sub.ID != subID && sub.StripeSubscriptionID == stripeSubID {
+			return uniqueViolation("subscriptions_stripe_subscription_id_key")
+		}
+	}
+	return nil
+}
+
+// isStaleSubscriptionEvent mirrors staleEventCondition: an event is skipped if it is older than the
+// event the subscription was last written from, or would revive a terminal subscription
+func isStaleSubscriptionEvent(sub *Subscription, stripeSubID, status string, eventAt time.Time) bool {
+	if sub.LastEventAt != nil && sub.LastEventAt.After(eventAt) {
+		return true
+	}
+	return sub.StripeSubscriptionID == stripeSubID &&
+		IsTerminalSubscriptionStatus(sub.Status) && !IsTerminalSubscriptionStatus(status)
+}
+
+// GetSubscriptionStatus retrieves subscription status for a user/product.
+// A subscription matches if its primary product or any of its items is the product.
+func (m *MemoryRepository) GetSubscriptionStatus(ctx context.Context, projectID uuid.UUID, userID, productID string) (string, string, time.Time, bool, error) {
+	defer m.lock()()
+
+	var latest *Subscription
+	for _, sub := range m.state.subscriptions {
+		if sub.ProjectID != projectID || sub.UserID != userID || !m.subscriptionHasProduct(sub, productID) {
+			continue
+		}
+		if latest == nil || sub.CurrentPeriodEnd.After(latest.CurrentPeriodEnd) {
+			latest = sub
+		}
+	}
+	if latest == nil {
+		return "", "", time.Time{}, false, errNoRows
+	}
+	return latest.StripeSubscriptionID, latest.CustomerID.String(), latest.CurrentPeriodEnd, true, nil
+}
+
+// subscriptionHasProduct reports whether the product is the subscription's primary product or one of its items
+func (m *MemoryRepository) subscriptionHasProduct(sub *Subscription, productID string) bool {
+	if sub.ProductID == productID {
+		return true
+	}
+	for _, item := range m.state.subscriptionItems {
+		if item.SubscriptionID == sub.ID && item.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateSubscription creates or updates a subscription from a Stripe event created at eventAt.
+// A known subscription is updated in place, including a plan change to another product; an unknown
+// one is inserted, replacing an older subscription of the user to the same product.
+// It reports whether the write was applied; stale events are ignored.
+func (m *MemoryRepository) CreateSubscription(ctx context.Context, projectID uuid.UUID, customerID, stripeSubID, productID, priceID, userID, status string, periodStart, periodEnd, eventAt time.Time) (bool, error) {
+	customerUUID, err := uuid.Parse(customerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid customer ID '%s': %w", customerID, err)
+	}
+
+	defer m.lock()()
+
+	now := time.Now()
+	if sub := m.findSubscription(stripeSubID); sub != nil {
+		if isStaleSubscriptionEvent(sub, stripeSubID, status, eventAt) {
+			return false, nil
+		}
+		for _, other := range m.state.subscriptions {
+			if other.ID != sub.ID && other.ProjectID == sub.ProjectID && other.UserID == sub.UserID && other.ProductID == productID {
+				return false, uniqueViolation("subscriptions_project_user_product_unique")
+			}
+		}
+		sub.CustomerID, sub.ProductID, sub.PriceID = customerUUID, productID, priceID
+		sub.Status = status
+		sub.CurrentPeriodStart, sub.CurrentPeriodEnd = periodStart, periodEnd
+		sub.LastEventAt = timePtr(eventAt)
+		sub.UpdatedAt = now
+		return true, nil
+	}
+
+	for _, sub := range m.state.subscriptions {
+		if sub.ProjectID != projectID || sub.UserID != userID || sub.ProductID != productID {
+			continue
+		}
+		if isStaleSubscriptionEvent(sub, stripeSubID, status, eventAt) {
+			return false, nil
+		}
+		sub.CustomerID, sub.PriceID = customerUUID, priceID
+		sub.StripeSubscriptionID = stripeSubID
+		sub.Status = status
+		sub.CurrentPeriodStart, sub.CurrentPeriodEnd = periodStart, periodEnd
+		sub.LastEventAt = timePtr(eventAt)
+		sub.UpdatedAt = now
+		return true, nil
+	}
+
+	sub := &Subscription{
+		ID:                   uuid.New(),
+		ProjectID:            projectID,
+		CustomerID:           customerUUID,
+		UserID:               userID,
+		ProductID:            productID,
+		PriceID:              priceID,
+		StripeSubscriptionID: stripeSubID,
+		Status:               status,
+		CurrentPeriodStart:   periodStart,
+		CurrentPeriodEnd:     periodEnd,
+		LastEventAt:          timePtr(eventAt),
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+	m.state.subscriptions[sub.ID] = sub
+	return true, nil
+}
+
+// UpdateSubscriptionStatus updates subscription status and period end from a Stripe event created at eventAt.
+// It reports whether a row was updated; unknown subscriptions and stale events leave it false.
+func (m *MemoryRepository) UpdateSubscriptionStatus(ctx context.Context, stripeSubID, status string, periodEnd, eventAt time.Time) (bool, error) {
+	defer m.lock()()
+
+	sub := m.findSubscription(stripeSubID)
+	if sub == nil || isStaleSubscriptionEvent(sub, stripeSubID, status, eventAt) {
+		return false, nil
+	}
+	sub.Status = status
+	sub.CurrentPeriodEnd = periodEnd
+	sub.LastEventAt = timePtr(eventAt)
+	sub.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// GetSubscriptionByStripeID retrieves subscription by Stripe subscription ID
+func (m *MemoryRepository) GetSubscriptionByStripeID(ctx context.Context, stripeSubID string) (*Subscription, error) {
+	defer m.lock()()
+	sub := m.findSubscription(stripeSubID)
+	if sub == nil {
+		return nil, errNoRows
+	}
+	copied := *sub
+	return &copied, nil
+}
+
+// UpdateSubscriptionLifecycle stores the trial, cancellation and pause state of a subscription
+func (m *MemoryRepository) UpdateSubscriptionLifecycle(ctx context.Context, stripeSubID string, lifecycle SubscriptionLifecycle) error {
+	defer m.lock()()
+	if sub := m.findSubscription(stripeSubID); sub != nil {
+		sub.SubscriptionLifecycle = lifecycle
+		sub.UpdatedAt = time.Now()
+	}
+	return nil
+}
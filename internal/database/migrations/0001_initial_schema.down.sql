@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS registered_products;
+DROP TABLE IF EXISTS subscriptions;
+DROP TABLE IF EXISTS customers;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,66 @@
+-- Projects, customers, subscriptions and the product catalog.
+-- Tables are created only if missing so databases set up before versioned migrations can adopt them.
+
+-- Projects table for multi-tenant API key authentication
+CREATE TABLE IF NOT EXISTS projects (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	name VARCHAR(255) NOT NULL,
+	api_key VARCHAR(64) UNIQUE NOT NULL,
+	webhook_url TEXT,
+	is_active BOOLEAN DEFAULT true,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS customers (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+	user_id VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	stripe_customer_id VARCHAR(255) UNIQUE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	UNIQUE(project_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+	customer_id UUID REFERENCES customers(id) ON DELETE CASCADE,
+	user_id VARCHAR(255) NOT NULL,
+	product_id VARCHAR(255) NOT NULL,
+	price_id VARCHAR(255) NOT NULL,
+	stripe_subscription_id VARCHAR(255) UNIQUE NOT NULL,
+	status VARCHAR(100) NOT NULL,
+	current_period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+	current_period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	UNIQUE(project_id, user_id, product_id)
+);
+
+CREATE TABLE IF NOT EXISTS registered_products (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	project_name VARCHAR(255) NOT NULL,
+	plan_name VARCHAR(255) NOT NULL,
+	stripe_product_id VARCHAR(255) NOT NULL UNIQUE,
+	stripe_price_monthly VARCHAR(255),
+	stripe_price_yearly VARCHAR(255),
+	monthly_amount INT,
+	yearly_amount INT,
+	currency VARCHAR(10) DEFAULT 'usd',
+	description TEXT,
+	features JSONB,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	UNIQUE(project_name, plan_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_api_key ON projects(api_key);
+CREATE INDEX IF NOT EXISTS idx_customers_user_id ON customers(user_id);
+CREATE INDEX IF NOT EXISTS idx_customers_stripe_id ON customers(stripe_customer_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON subscriptions(user_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_stripe_id ON subscriptions(stripe_subscription_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_product_id ON subscriptions(product_id);
+CREATE INDEX IF NOT EXISTS idx_registered_products_project ON registered_products(project_name);
+CREATE INDEX IF NOT EXISTS idx_registered_products_stripe_id ON registered_products(stripe_product_id);
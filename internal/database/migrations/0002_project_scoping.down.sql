@@ -0,0 +1,4 @@
+-- The project_id columns belong to the initial schema and backfilled project assignments are kept.
+-- Global uniqueness of user IDs is not restored.
+DROP INDEX IF EXISTS idx_subscriptions_project_id;
+DROP INDEX IF EXISTS idx_customers_project_id;
@@ -0,0 +1,30 @@
+-- Scopes customers and subscriptions of databases created before projects existed.
+-- Rows without a project are assigned to the oldest project, created as "Default Project" if there is none.
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE CASCADE;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE CASCADE;
+
+INSERT INTO projects (name, api_key)
+SELECT 'Default Project', 'proj_' || substr(replace(gen_random_uuid()::text, '-', '') || replace(gen_random_uuid()::text, '-', ''), 1, 43)
+WHERE NOT EXISTS (SELECT 1 FROM projects)
+	AND (EXISTS (SELECT 1 FROM customers WHERE project_id IS NULL)
+		OR EXISTS (SELECT 1 FROM subscriptions WHERE project_id IS NULL));
+
+UPDATE customers SET project_id = (SELECT id FROM projects ORDER BY created_at LIMIT 1) WHERE project_id IS NULL;
+UPDATE subscriptions SET project_id = (SELECT id FROM projects ORDER BY created_at LIMIT 1) WHERE project_id IS NULL;
+
+-- User IDs are unique per project, not globally
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'customers_user_id_key') THEN
+		ALTER TABLE customers DROP CONSTRAINT customers_user_id_key;
+		CREATE UNIQUE INDEX IF NOT EXISTS customers_project_user_unique ON customers(project_id, user_id);
+	END IF;
+	IF EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'subscriptions_user_id_product_id_key') THEN
+		ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_user_id_product_id_key;
+		CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_project_user_product_unique ON subscriptions(project_id, user_id, product_id);
+	END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_customers_project_id ON customers(project_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_project_id ON subscriptions(project_id);
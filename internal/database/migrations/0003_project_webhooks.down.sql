@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+ALTER TABLE projects DROP COLUMN IF EXISTS webhook_signing_secret;
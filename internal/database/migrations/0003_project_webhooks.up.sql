@@ -0,0 +1,23 @@
+-- Secret for signing outbound webhooks, backfilled for projects created before it existed
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS webhook_signing_secret VARCHAR(255);
+UPDATE projects SET webhook_signing_secret = 'pwhsec_' || replace(gen_random_uuid()::text, '-', '') || replace(gen_random_uuid()::text, '-', '')
+	WHERE webhook_signing_secret IS NULL;
+
+-- Attempts to deliver normalized events to project webhook URLs
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+	event_id UUID NOT NULL,
+	event_type VARCHAR(100) NOT NULL,
+	url TEXT NOT NULL,
+	payload JSONB NOT NULL,
+	attempt INT NOT NULL,
+	status VARCHAR(50) NOT NULL,
+	response_code INT,
+	latency_ms BIGINT NOT NULL DEFAULT 0,
+	error TEXT,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_project ON webhook_deliveries(project_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(event_id);
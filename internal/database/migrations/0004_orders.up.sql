@@ -0,0 +1,30 @@
+-- One-time purchases recorded from completed checkout sessions
+CREATE TABLE IF NOT EXISTS orders (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+	customer_id UUID REFERENCES customers(id) ON DELETE CASCADE,
+	user_id VARCHAR(255) NOT NULL,
+	stripe_checkout_session_id VARCHAR(255) UNIQUE NOT NULL,
+	stripe_payment_intent_id VARCHAR(255),
+	payment_type VARCHAR(50) NOT NULL,
+	payment_status VARCHAR(50) NOT NULL,
+	amount_total BIGINT NOT NULL DEFAULT 0,
+	currency VARCHAR(10) NOT NULL DEFAULT 'usd',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS order_items (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+	stripe_price_id VARCHAR(255) NOT NULL,
+	stripe_product_id VARCHAR(255) NOT NULL,
+	description TEXT,
+	quantity BIGINT NOT NULL DEFAULT 1,
+	amount_total BIGINT NOT NULL DEFAULT 0,
+	currency VARCHAR(10) NOT NULL DEFAULT 'usd',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_project_user ON orders(project_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items(order_id);
@@ -0,0 +1,16 @@
+-- Log of every verified Stripe webhook event, doubling as the processing queue
+CREATE TABLE IF NOT EXISTS stripe_events (
+	id VARCHAR(255) PRIMARY KEY,
+	type VARCHAR(255) NOT NULL,
+	created TIMESTAMP WITH TIME ZONE NOT NULL,
+	payload JSONB NOT NULL,
+	status VARCHAR(50) NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	received_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	processed_at TIMESTAMP WITH TIME ZONE,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stripe_events_type_created ON stripe_events(type, created);
+CREATE INDEX IF NOT EXISTS idx_stripe_events_status ON stripe_events(status);
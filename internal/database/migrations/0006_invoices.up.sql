@@ -0,0 +1,28 @@
+-- Invoices of customers, kept current from invoice.* events
+CREATE TABLE IF NOT EXISTS invoices (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+	user_id VARCHAR(255) NOT NULL,
+	stripe_invoice_id VARCHAR(255) NOT NULL UNIQUE,
+	stripe_subscription_id VARCHAR(255),
+	number VARCHAR(255),
+	status VARCHAR(50) NOT NULL,
+	amount_due BIGINT NOT NULL DEFAULT 0,
+	amount_paid BIGINT NOT NULL DEFAULT 0,
+	amount_remaining BIGINT NOT NULL DEFAULT 0,
+	currency VARCHAR(10) NOT NULL,
+	hosted_invoice_url TEXT,
+	invoice_pdf TEXT,
+	period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+	period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+	attempt_count BIGINT NOT NULL DEFAULT 0,
+	next_payment_attempt TIMESTAMP WITH TIME ZONE,
+	paid_at TIMESTAMP WITH TIME ZONE,
+	payment_failed_at TIMESTAMP WITH TIME ZONE,
+	last_event_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_project_user ON invoices(project_id, user_id, period_end DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_invoices_payment_intent;
+DROP INDEX IF EXISTS idx_orders_payment_intent;
+ALTER TABLE invoices DROP COLUMN IF EXISTS stripe_payment_intent_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS refunded_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS amount_refunded;
+DROP TABLE IF EXISTS disputes;
+DROP TABLE IF EXISTS refunds;
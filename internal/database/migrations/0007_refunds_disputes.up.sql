@@ -0,0 +1,46 @@
+-- Refunds and chargebacks of charges, linked to the order or invoice they paid for
+CREATE TABLE IF NOT EXISTS refunds (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+	user_id VARCHAR(255) NOT NULL,
+	stripe_refund_id VARCHAR(255) NOT NULL UNIQUE,
+	stripe_charge_id VARCHAR(255) NOT NULL,
+	stripe_payment_intent_id VARCHAR(255),
+	order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+	invoice_id UUID REFERENCES invoices(id) ON DELETE SET NULL,
+	amount BIGINT NOT NULL DEFAULT 0,
+	currency VARCHAR(10) NOT NULL,
+	status VARCHAR(50) NOT NULL,
+	reason VARCHAR(100),
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS disputes (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+	user_id VARCHAR(255) NOT NULL,
+	stripe_dispute_id VARCHAR(255) NOT NULL UNIQUE,
+	stripe_charge_id VARCHAR(255) NOT NULL,
+	stripe_payment_intent_id VARCHAR(255),
+	order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+	invoice_id UUID REFERENCES invoices(id) ON DELETE SET NULL,
+	amount BIGINT NOT NULL DEFAULT 0,
+	currency VARCHAR(10) NOT NULL,
+	status VARCHAR(50) NOT NULL,
+	reason VARCHAR(100),
+	last_event_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS amount_refunded BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refunded_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS stripe_payment_intent_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_orders_payment_intent ON orders(stripe_payment_intent_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_payment_intent ON invoices(stripe_payment_intent_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_order ON refunds(order_id);
+CREATE INDEX IF NOT EXISTS idx_disputes_order ON disputes(order_id);
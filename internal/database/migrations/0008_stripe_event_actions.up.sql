@@ -0,0 +1,12 @@
+-- Admin replays and ignores of stored Stripe events
+CREATE TABLE IF NOT EXISTS stripe_event_actions (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	event_id VARCHAR(255) NOT NULL REFERENCES stripe_events(id) ON DELETE CASCADE,
+	action VARCHAR(20) NOT NULL,
+	triggered_by VARCHAR(255) NOT NULL,
+	result VARCHAR(50) NOT NULL,
+	error TEXT,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stripe_event_actions_event ON stripe_event_actions(event_id);
@@ -0,0 +1,7 @@
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS pause_resumes_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS pause_behavior;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS ended_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS canceled_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_at_period_end;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_end;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_start;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_subscriptions_dunning_state;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS dunning_retries;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS dunning_started_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS dunning_state;
+ALTER TABLE projects DROP COLUMN IF EXISTS dunning_max_retries;
+ALTER TABLE projects DROP COLUMN IF EXISTS dunning_grace_days;
@@ -0,0 +1,8 @@
+-- Failed payment recovery settings of projects and state of subscriptions
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS dunning_grace_days INT NOT NULL DEFAULT 7;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS dunning_max_retries INT NOT NULL DEFAULT 4;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS dunning_state VARCHAR(20);
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS dunning_started_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS dunning_retries BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_dunning_state ON subscriptions(dunning_state) WHERE dunning_state IS NOT NULL;
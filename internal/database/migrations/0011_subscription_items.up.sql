@@ -0,0 +1,14 @@
+-- Individual prices of a subscription (base plan and add-ons)
+CREATE TABLE IF NOT EXISTS subscription_items (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+	stripe_subscription_item_id VARCHAR(255) NOT NULL UNIQUE,
+	price_id VARCHAR(255) NOT NULL,
+	product_id VARCHAR(255) NOT NULL,
+	quantity BIGINT NOT NULL DEFAULT 1,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_items_subscription ON subscription_items(subscription_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_items_product ON subscription_items(product_id);
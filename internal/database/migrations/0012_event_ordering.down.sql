@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_stripe_events_queue;
+ALTER TABLE stripe_events DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE stripe_events DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS last_event_at;
@@ -0,0 +1,8 @@
+-- Creation time of the Stripe event each subscription row was last written from
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS last_event_at TIMESTAMP WITH TIME ZONE;
+
+-- Retry scheduling and worker leases of queued Stripe events
+ALTER TABLE stripe_events ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+ALTER TABLE stripe_events ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_stripe_events_queue ON stripe_events(status, next_attempt_at);
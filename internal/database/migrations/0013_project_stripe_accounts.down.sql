@@ -0,0 +1,3 @@
+ALTER TABLE stripe_events DROP COLUMN IF EXISTS project_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS stripe_webhook_secret;
+ALTER TABLE projects DROP COLUMN IF EXISTS stripe_secret_key;
@@ -0,0 +1,4 @@
+-- Stripe accounts of projects with their own webhook endpoint, and the project an event was received for
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS stripe_secret_key VARCHAR(255);
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS stripe_webhook_secret TEXT;
+ALTER TABLE stripe_events ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+ALTER TABLE customers DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE customers DROP COLUMN IF EXISTS default_payment_method;
+ALTER TABLE customers DROP COLUMN IF EXISTS name;
@@ -0,0 +1,4 @@
+-- Customer details synced from customer.updated events
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS name VARCHAR(255);
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS default_payment_method VARCHAR(255);
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS last_event_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+ALTER TABLE registered_products DROP COLUMN IF EXISTS last_event_at;
+ALTER TABLE registered_products DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE registered_products DROP COLUMN IF EXISTS active;
@@ -0,0 +1,4 @@
+-- Catalog state synced from product.* and price.* events
+ALTER TABLE registered_products ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE registered_products ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE registered_products ADD COLUMN IF NOT EXISTS last_event_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,12 @@
+ALTER TABLE registered_products ADD COLUMN IF NOT EXISTS project_name VARCHAR(255);
+-- Plans of no project get a name of their own so they keep their unique (project_name, plan_name)
+UPDATE registered_products rp
+SET project_name = COALESCE((SELECT p.name FROM projects p WHERE p.id = rp.project_id), rp.id::text);
+ALTER TABLE registered_products ALTER COLUMN project_name SET NOT NULL;
+
+ALTER TABLE registered_products DROP CONSTRAINT IF EXISTS registered_products_project_id_plan_name_key;
+DROP INDEX IF EXISTS idx_registered_products_project_id;
+ALTER TABLE registered_products DROP COLUMN IF EXISTS project_id;
+
+ALTER TABLE registered_products ADD CONSTRAINT registered_products_project_name_plan_name_key UNIQUE (project_name, plan_name);
+CREATE INDEX IF NOT EXISTS idx_registered_products_project ON registered_products(project_name);
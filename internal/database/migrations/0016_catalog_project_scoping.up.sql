@@ -0,0 +1,19 @@
+-- Scopes the product catalog to the project that registered it instead of a free-text project name.
+-- Existing plans move to the project of the same name; plans whose name matches no project, or
+-- several, cannot be attributed safely and keep a NULL project_id, which no project can see.
+
+ALTER TABLE registered_products ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE CASCADE;
+
+UPDATE registered_products rp
+SET project_id = p.id
+FROM projects p
+WHERE rp.project_id IS NULL
+	AND p.name = rp.project_name
+	AND (SELECT COUNT(*) FROM projects same WHERE same.name = rp.project_name) = 1;
+
+ALTER TABLE registered_products DROP CONSTRAINT IF EXISTS registered_products_project_name_plan_name_key;
+DROP INDEX IF EXISTS idx_registered_products_project;
+ALTER TABLE registered_products DROP COLUMN IF EXISTS project_name;
+
+ALTER TABLE registered_products ADD CONSTRAINT registered_products_project_id_plan_name_key UNIQUE (project_id, plan_name);
+CREATE INDEX IF NOT EXISTS idx_registered_products_project_id ON registered_products(project_id);
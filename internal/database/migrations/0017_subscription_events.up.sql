@@ -0,0 +1,21 @@
+-- Append-only history of subscription status and billing period changes, so past access can be explained.
+-- Each row records the state a subscription moved to, the state it left and the Stripe event that caused it.
+CREATE TABLE IF NOT EXISTS subscription_events (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	user_id VARCHAR(255) NOT NULL,
+	product_id VARCHAR(255) NOT NULL,
+	stripe_subscription_id VARCHAR(255) NOT NULL,
+	previous_status VARCHAR(50),
+	status VARCHAR(50) NOT NULL,
+	previous_period_end TIMESTAMP WITH TIME ZONE,
+	current_period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+	source_event_id VARCHAR(255) NOT NULL,
+	event_type VARCHAR(100) NOT NULL,
+	occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_events_user_product ON subscription_events(project_id, user_id, product_id, recorded_at);
+CREATE INDEX IF NOT EXISTS idx_subscription_events_subscription ON subscription_events(subscription_id, recorded_at);
@@ -0,0 +1,3 @@
+-- Erasure of a user's personal data on request. An erased customer keeps its billing records under a
+-- pseudonymous user ID, and Stripe events no longer write personal details back into it.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS erased_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,15 @@
+-- Notifications waiting to be delivered to project webhook URLs, so retries survive a restart.
+-- A row is removed once its notification is delivered or its attempts are exhausted.
+CREATE TABLE IF NOT EXISTS pending_notifications (
+	id UUID PRIMARY KEY,
+	project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	event_type VARCHAR(100) NOT NULL,
+	payload JSONB NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	locked_until TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_notifications_due ON pending_notifications(next_attempt_at);
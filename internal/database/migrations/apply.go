@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lastApplied returns up to n applied migrations, newest first
+func (m *Migrator) lastApplied(appliedByVersion map[int64]applied, n int) []*Migration {
+	var last []*Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(last) < n; i-- {
+		if _, ok := appliedByVersion[m.migrations[i].Version]; ok {
+			last = append(last, m.migrations[i])
+		}
+	}
+	return last
+}
+
+// apply runs the up SQL of a migration and records it in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, migration *Migration) error {
+	if m.dryRun != nil {
+		_, err := fmt.Fprintf(m.dryRun, "-- up %s\n%s\n", migration, migration.Up)
+		return err
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting migration %s: %w", migration, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, migration.Up); err != nil {
+		return fmt.Errorf("error applying migration %s: %w", migration, err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)
+	`, migration.Version, migration.Name, migration.Checksum); err != nil {
+		return fmt.Errorf("error recording migration %s: %w", migration, err)
+	}
+	return tx.Commit(ctx)
+}
+
+// rollback runs the down SQL of a migration and removes its record in one transaction
+func (m *Migrator) rollback(ctx context.Context, conn *pgxpool.Conn, migration *Migration) error {
+	if m.dryRun != nil {
+		_, err := fmt.Fprintf(m.dryRun, "-- down %s\n%s\n", migration, migration.Down)
+		return err
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting rollback of %s: %w", migration, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, migration.Down); err != nil {
+		return fmt.Errorf("error rolling back migration %s: %w", migration, err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+		return fmt.Errorf("error removing record of migration %s: %w", migration, err)
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,147 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockID is the Postgres advisory lock held while migrating, so concurrent runs apply each migration once
+const lockID int64 = 7239531004
+
+// Migrator applies and rolls back migrations
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []*Migration
+	dryRun     io.Writer
+}
+
+// New creates a migrator for the embedded migrations
+func New(pool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return NewMigrator(pool, migrations), nil
+}
+
+// NewMigrator creates a migrator for the given migrations, ordered by version
+func NewMigrator(pool *pgxpool.Pool, migrations []*Migration) *Migrator {
+	return &Migrator{pool: pool, migrations: migrations}
+}
+
+// SetDryRun makes Up, Down and Redo write the SQL they would run to out instead of running it.
+// A nil writer turns dry run off.
+func (m *Migrator) SetDryRun(out io.Writer) {
+	m.dryRun = out
+}
+
+// Up applies all pending migrations in version order and returns them
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var done []*Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		appliedByVersion, err := m.loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.validate(appliedByVersion); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := appliedByVersion[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last n applied migrations, newest first, and returns them
+func (m *Migrator) Down(ctx context.Context, n int) ([]*Migration, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("number of migrations to roll back must be positive, got %d", n)
+	}
+
+	var done []*Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		appliedByVersion, err := m.loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.validate(appliedByVersion); err != nil {
+			return err
+		}
+
+		for _, migration := range m.lastApplied(appliedByVersion, n) {
+			if err := m.rollback(ctx, conn, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Redo rolls back the last applied migration and applies it again
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		appliedByVersion, err := m.loadApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.validate(appliedByVersion); err != nil {
+			return err
+		}
+
+		last := m.lastApplied(appliedByVersion, 1)
+		if len(last) == 0 {
+			return errors.New("no applied migration to redo")
+		}
+		redone = last[0]
+		if err := m.rollback(ctx, conn, redone); err != nil {
+			return err
+		}
+		return m.apply(ctx, conn, redone)
+	})
+	return redone, err
+}
+
+// withLock runs fn on one connection holding the migration advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("error acquiring connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("error acquiring migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	if m.dryRun == nil {
+		if _, err := conn.Exec(ctx, `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version BIGINT PRIMARY KEY,
+				name VARCHAR(255) NOT NULL,
+				checksum VARCHAR(64) NOT NULL,
+				applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+			)
+		`); err != nil {
+			return fmt.Errorf("error creating schema_migrations: %w", err)
+		}
+	}
+
+	return fn(conn)
+}
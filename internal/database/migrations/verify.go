@@ -0,0 +1,151 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrSchemaBehind is returned when migrations are pending
+	ErrSchemaBehind = errors.New("database schema is behind")
+	// ErrChecksumMismatch is returned when an applied migration was edited afterwards
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	// ErrUnknownMigration is returned when the database has a migration this build does not contain
+	ErrUnknownMigration = errors.New("unknown applied migration")
+)
+
+// Status describes one migration, embedded or applied
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	// Modified is set when the applied checksum differs from the embedded up SQL
+	Modified bool
+	// Missing is set for an applied migration this build does not contain
+	Missing bool
+}
+
+// Applied reports whether the migration is recorded in schema_migrations
+func (s Status) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+// applied is a row of schema_migrations
+type applied struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Status lists every embedded migration and any applied migration missing from this build
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring connection: %w", err)
+	}
+	defer conn.Release()
+
+	appliedByVersion, err := m.loadApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	known := make(map[int64]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := appliedByVersion[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			status.Modified = record.Checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range appliedByVersion {
+		if known[version] {
+			continue
+		}
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, Status{Version: version, Name: record.Name, AppliedAt: &appliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// CheckCurrent returns an error unless every migration is applied unmodified.
+// The server calls it at startup instead of creating tables itself.
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []Status
+	for _, status := range statuses {
+		switch {
+		case status.Missing:
+			return fmt.Errorf("%w: %04d_%s is applied but not part of this build", ErrUnknownMigration, status.Version, status.Name)
+		case status.Modified:
+			return fmt.Errorf("%w: %04d_%s was changed after it was applied", ErrChecksumMismatch, status.Version, status.Name)
+		case !status.Applied():
+			pending = append(pending, status)
+		}
+	}
+	if len(pending) > 0 {
+		latest := pending[len(pending)-1]
+		return fmt.Errorf("%w: %d pending migrations up to %04d_%s", ErrSchemaBehind, len(pending), latest.Version, latest.Name)
+	}
+	return nil
+}
+
+// loadApplied returns the recorded migrations by version; none if schema_migrations does not exist yet
+func (m *Migrator) loadApplied(ctx context.Context, conn *pgxpool.Conn) (map[int64]applied, error) {
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error checking schema_migrations: %w", err)
+	}
+	appliedByVersion := make(map[int64]applied)
+	if !exists {
+		return appliedByVersion, nil
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("error loading applied migrations: %w", err)
+	}
+	records, err := pgx.CollectRows(rows, pgx.RowToStructByPos[applied])
+	if err != nil {
+		return nil, fmt.Errorf("error loading applied migrations: %w", err)
+	}
+	for _, record := range records {
+		appliedByVersion[record.Version] = record
+	}
+	return appliedByVersion, nil
+}
+
+// validate refuses to migrate a database whose history does not match the embedded migrations
+func (m *Migrator) validate(appliedByVersion map[int64]applied) error {
+	known := make(map[int64]*Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	for version, record := range appliedByVersion {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("%w: %04d_%s is applied but not part of this build", ErrUnknownMigration, version, record.Name)
+		}
+		if record.Checksum != migration.Checksum {
+			return fmt.Errorf("%w: %s was changed after it was applied", ErrChecksumMismatch, migration)
+		}
+	}
+	return nil
+}
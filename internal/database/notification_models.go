@@ -0,0 +1,49 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// PendingNotification is a notification waiting to be delivered to a project's webhook URL
+type PendingNotification struct {
+	ID            uuid.UUID `json:"id"` // ID of the notification event, sent to the project
+	ProjectID     uuid.UUID `json:"project_id"`
+	EventType     string    `json:"event_type"`
+	Payload       []byte    `json:"payload"`  // Body POSTed to the project
+	Attempts      int       `json:"attempts"` // Delivery attempts so far
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+const pendingNotificationColumns = `id, project_id, event_type, payload, attempts, last_error, next_attempt_at, created_at`
+
+// ScanPendingNotification scans a database row into a PendingNotification struct
+func ScanPendingNotification(row pgx.Row) (*PendingNotification, error) {
+	var notification PendingNotification
+	var lastError sql.NullString
+
+	err := row.Scan(
+		&notification.ID,
+		&notification.ProjectID,
+		&notification.EventType,
+		&notification.Payload,
+		&notification.Attempts,
+		&lastError,
+		&notification.NextAttemptAt,
+		&notification.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastError.Valid {
+		notification.LastError = lastError.String
+	}
+
+	return &notification, nil
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EnqueueNotification stores a notification for delivery; storing the same notification twice is a no-op
+func (r *Repository) EnqueueNotification(ctx context.Context, notification *PendingNotification) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO pending_notifications (id, project_id, event_type, payload, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (id) DO NOTHING
+	`, notification.ID, notification.ProjectID, notification.EventType, notification.Payload)
+	return err
+}
+
+// ClaimNotifications locks up to limit due notifications for delivery, oldest first, and counts the
+// attempt. A claim expires after lease so notifications held by a stopped notifier are picked up again.
+func (r *Repository) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]*PendingNotification, error) {
+	return collectRows(ctx, r.db, ScanPendingNotification, `
+		UPDATE pending_notifications
+		SET attempts = attempts + 1, locked_until = $1
+		WHERE id IN (
+			SELECT id FROM pending_notifications
+			WHERE next_attempt_at <= NOW() AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY next_attempt_at, created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+pendingNotificationColumns,
+		time.Now().Add(lease), limit)
+}
+
+// RescheduleNotification releases a claimed notification after a failed attempt, to be retried at nextAttemptAt
+func (r *Repository) RescheduleNotification(ctx context.Context, notificationID uuid.UUID, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE pending_notifications
+		SET last_error = $1, next_attempt_at = $2, locked_until = NULL
+		WHERE id = $3
+	`, lastError, nextAttemptAt, notificationID)
+	return err
+}
+
+// DeleteNotification removes a notification that was delivered or will not be retried again
+func (r *Repository) DeleteNotification(ctx context.Context, notificationID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM pending_notifications WHERE id = $1`, notificationID)
+	return err
+}
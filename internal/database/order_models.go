@@ -0,0 +1,110 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Order represents a completed one-time checkout (item or cart purchase)
+type Order struct {
+	ID                      uuid.UUID    `json:"id"`
+	ProjectID               uuid.UUID    `json:"project_id"`
+	CustomerID              uuid.UUID    `json:"customer_id"`
+	UserID                  string       `json:"user_id"`
+	StripeCheckoutSessionID string       `json:"stripe_checkout_session_id"`
+	StripePaymentIntentID   string       `json:"stripe_payment_intent_id,omitempty"`
+	PaymentType             string       `json:"payment_type"`   // "item" or "cart"
+	PaymentStatus           string       `json:"payment_status"` // Stripe checkout payment_status
+	AmountTotal             int64        `json:"amount_total"`
+	Currency                string       `json:"currency"`
+	AmountRefunded          int64        `json:"amount_refunded"`
+	RefundedAt              *time.Time   `json:"refunded_at,omitempty"` // Set once the payment is fully refunded
+	Entitled                bool         `json:"entitled"`              // False once the order is unpaid, fully refunded or lost to a dispute
+	Items                   []*OrderItem `json:"items"`
+	CreatedAt               time.Time    `json:"created_at"`
+	UpdatedAt               time.Time    `json:"updated_at"`
+}
+
+// OrderItem represents a single line item of an order
+type OrderItem struct {
+	ID              uuid.UUID `json:"id"`
+	OrderID         uuid.UUID `json:"order_id"`
+	StripePriceID   string    `json:"stripe_price_id"`
+	StripeProductID string    `json:"stripe_product_id"`
+	Description     string    `json:"description,omitempty"`
+	Quantity        int64     `json:"quantity"`
+	AmountTotal     int64     `json:"amount_total"`
+	Currency        string    `json:"currency"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// orderColumns selects an order; entitled is derived from its payment, refund and dispute state
+const orderColumns = `id, project_id, customer_id, user_id, stripe_checkout_session_id, stripe_payment_intent_id,
+	payment_type, payment_status, amount_total, currency, amount_refunded, refunded_at,
+	(payment_status IN ('paid', 'no_payment_required') AND refunded_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM disputes WHERE disputes.order_id = orders.id AND disputes.status = 'lost')) AS entitled,
+	created_at, updated_at`
+
+// ScanOrder scans a database row into an Order struct
+func ScanOrder(row pgx.Row) (*Order, error) {
+	var order Order
+	var paymentIntentID sql.NullString
+
+	err := row.Scan(
+		&order.ID,
+		&order.ProjectID,
+		&order.CustomerID,
+		&order.UserID,
+		&order.StripeCheckoutSessionID,
+		&paymentIntentID,
+		&order.PaymentType,
+		&order.PaymentStatus,
+		&order.AmountTotal,
+		&order.Currency,
+		&order.AmountRefunded,
+		&order.RefundedAt,
+		&order.Entitled,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if paymentIntentID.Valid {
+		order.StripePaymentIntentID = paymentIntentID.String
+	}
+	order.Items = []*OrderItem{}
+
+	return &order, nil
+}
+
+// ScanOrderItem scans a database row into an OrderItem struct
+func ScanOrderItem(row pgx.Row) (*OrderItem, error) {
+	var item OrderItem
+	var description sql.NullString
+
+	err := row.Scan(
+		&item.ID,
+		&item.OrderID,
+		&item.StripePriceID,
+		&item.StripeProductID,
+		&description,
+		&item.Quantity,
+		&item.AmountTotal,
+		&item.Currency,
+		&item.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if description.Valid {
+		item.Description = description.String
+	}
+
+	return &item, nil
+}
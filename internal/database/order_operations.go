@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// CreateOrder stores an order and its line items in one transaction.
+// Orders are keyed by checkout session, so recording the same session twice is a no-op.
+func (r *Repository) CreateOrder(ctx context.Context, order *Order) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO orders (
+			project_id, customer_id, user_id, stripe_checkout_session_id, stripe_payment_intent_id,
+			payment_type, payment_status, amount_total, currency, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+		ON CONFLICT (stripe_checkout_session_id) DO NOTHING
+		RETURNING id, created_at, updated_at
+	`, order.ProjectID, order.CustomerID, order.UserID, order.StripeCheckoutSessionID,
+		nullString(order.StripePaymentIntentID), order.PaymentType, order.PaymentStatus,
+		order.AmountTotal, order.Currency,
+	).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+	if errors.Is(err, ErrNotFound) {
+		// Order was already recorded by an earlier delivery
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, item := range order.Items {
+		item.OrderID = order.ID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO order_items (
+				order_id, stripe_price_id, stripe_product_id, description,
+				quantity, amount_total, currency, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+			RETURNING id, created_at
+		`, item.OrderID, item.StripePriceID, item.StripeProductID, nullString(item.Description),
+			item.Quantity, item.AmountTotal, item.Currency,
+		).Scan(&item.ID, &item.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetOrdersByUserID retrieves all orders of a user with their line items, newest first
+func (r *Repository) GetOrdersByUserID(ctx context.Context, projectID uuid.UUID, userID string) ([]*Order, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+orderColumns+`
+		FROM orders
+		WHERE project_id = $1 AND user_id = $2
+		ORDER BY created_at DESC
+	`, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*Order{}
+	ordersByID := make(map[uuid.UUID]*Order)
+	var orderIDs []uuid.UUID
+	for rows.Next() {
+		order, err := ScanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+		ordersByID[order.ID] = order
+		orderIDs = append(orderIDs, order.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(orderIDs) == 0 {
+		return orders, nil
+	}
+
+	itemRows, err := r.db.Query(ctx, `
+		SELECT id, order_id, stripe_price_id, stripe_product_id, description,
+			quantity, amount_total, currency, created_at
+		FROM order_items
+		WHERE order_id = ANY($1)
+		ORDER BY created_at
+	`, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		item, err := ScanOrderItem(itemRows)
+		if err != nil {
+			return nil, err
+		}
+		ordersByID[item.OrderID].Items = append(ordersByID[item.OrderID].Items, item)
+	}
+
+	return orders, itemRows.Err()
+}
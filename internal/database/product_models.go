@@ -0,0 +1,75 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// RegisteredProduct represents a product registered for a project
+type RegisteredProduct struct {
+	ID                 uuid.UUID  `json:"id"`
+	ProjectID          uuid.UUID  `json:"project_id"` // uuid.Nil for plans migrated from a project name no project matched
+	PlanName           string     `json:"plan_name"`
+	StripeProductID    string     `json:"stripe_product_id"`
+	StripePriceMonthly string     `json:"stripe_price_monthly,omitempty"`
+	StripePriceYearly  string     `json:"stripe_price_yearly,omitempty"`
+	MonthlyAmount      int64      `json:"monthly_amount,omitempty"`
+	YearlyAmount       int64      `json:"yearly_amount,omitempty"`
+	Currency           string     `json:"currency"`
+	Description        string     `json:"description,omitempty"`
+	Features           []byte     `json:"features,omitempty"` // JSON bytes
+	Active             bool       `json:"active"`             // False once archived or deleted in Stripe
+	ArchivedAt         *time.Time `json:"archived_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// ScanRegisteredProduct scans a database row into a RegisteredProduct struct
+func ScanRegisteredProduct(row pgx.Row) (*RegisteredProduct, error) {
+	var product RegisteredProduct
+	var monthlyAmount, yearlyAmount sql.NullInt64
+	var stripePriceMonthly, stripePriceYearly sql.NullString
+	var projectID *uuid.UUID
+
+	err := row.Scan(
+		&product.ID,
+		&projectID,
+		&product.PlanName,
+		&product.StripeProductID,
+		&stripePriceMonthly,
+		&stripePriceYearly,
+		&monthlyAmount,
+		&yearlyAmount,
+		&product.Currency,
+		&product.Description,
+		&product.Features,
+		&product.Active,
+		&product.ArchivedAt,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if projectID != nil {
+		product.ProjectID = *projectID
+	}
+	if stripePriceMonthly.Valid {
+		product.StripePriceMonthly = stripePriceMonthly.String
+	}
+	if stripePriceYearly.Valid {
+		product.StripePriceYearly = stripePriceYearly.String
+	}
+	if monthlyAmount.Valid {
+		product.MonthlyAmount = monthlyAmount.Int64
+	}
+	if yearlyAmount.Valid {
+		product.YearlyAmount = yearlyAmount.Int64
+	}
+
+	return &product, nil
+}
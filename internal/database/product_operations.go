@@ -2,22 +2,51 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const registeredProductColumns = `id, project_id, plan_name, stripe_product_id,
+	stripe_price_monthly, stripe_price_yearly,
+	monthly_amount, yearly_amount, currency,
+	description, features, active, archived_at, created_at, updated_at`
+
+// Recurring intervals of the prices kept on a registered product
+const (
+	PriceIntervalMonth = "month"
+	PriceIntervalYear  = "year"
 )
 
-// CreateRegisteredProduct creates a new registered product
+// CreateRegisteredProduct creates a new registered product.
+// A row already synced from the product's Stripe events is overwritten with the registered details;
+// a row of another project is left alone and reported as ErrConflict.
 func (r *Repository) CreateRegisteredProduct(ctx context.Context, product *RegisteredProduct) error {
 	query := `
 		INSERT INTO registered_products (
-			project_name, plan_name, stripe_product_id,
+			project_id, plan_name, stripe_product_id,
 			stripe_price_monthly, stripe_price_yearly,
 			monthly_amount, yearly_amount, currency,
 			description, features, created_at, updated_at
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		ON CONFLICT (stripe_product_id) DO UPDATE SET
+			plan_name = EXCLUDED.plan_name,
+			stripe_price_monthly = EXCLUDED.stripe_price_monthly,
+			stripe_price_yearly = EXCLUDED.stripe_price_yearly,
+			monthly_amount = EXCLUDED.monthly_amount,
+			yearly_amount = EXCLUDED.yearly_amount,
+			currency = EXCLUDED.currency,
+			description = EXCLUDED.description,
+			features = EXCLUDED.features,
+			updated_at = NOW()
+		WHERE registered_products.project_id = EXCLUDED.project_id
 		RETURNING id, created_at, updated_at
 	`
 
-	return r.db.QueryRow(ctx, query,
-		product.ProjectName,
+	err := r.db.QueryRow(ctx, query,
+		product.ProjectID,
 		product.PlanName,
 		product.StripeProductID,
 		nullString(product.StripePriceMonthly),
@@ -28,21 +57,27 @@ func (r *Repository) CreateRegisteredProduct(ctx context.Context, product *Regis
 		product.Description,
 		product.Features,
 	).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
+	if errors.Is(err, ErrNotFound) {
+		return errProductOfAnotherProject(product.StripeProductID)
+	}
+	return err
+}
+
+// errProductOfAnotherProject is the error of writing a Stripe product stored for another project
+func errProductOfAnotherProject(stripeProductID string) error {
+	return &repositoryError{kind: ErrConflict, err: fmt.Errorf("product %s belongs to another project", stripeProductID)}
 }
 
 // GetRegisteredProductsByProject retrieves all registered products for a project
-func (r *Repository) GetRegisteredProductsByProject(ctx context.Context, projectName string) ([]*RegisteredProduct, error) {
+func (r *Repository) GetRegisteredProductsByProject(ctx context.Context, projectID uuid.UUID) ([]*RegisteredProduct, error) {
 	query := `
-		SELECT id, project_name, plan_name, stripe_product_id,
-			stripe_price_monthly, stripe_price_yearly,
-			monthly_amount, yearly_amount, currency,
-			description, features, created_at, updated_at
+		SELECT ` + registeredProductColumns + `
 		FROM registered_products
-		WHERE project_name = $1
+		WHERE project_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(ctx, query, projectName)
+	rows, err := r.db.Query(ctx, query, projectID)
 	if err != nil {
 		return nil, err
 	}
@@ -62,19 +97,19 @@ func (r *Repository) GetRegisteredProductsByProject(ctx context.Context, project
 
 // ProductExistsForProject checks if a product with the given plan name already exists for a project
 // Returns (exists bool, stripeProductID string, error)
-func (r *Repository) ProductExistsForProject(ctx context.Context, projectName, planName string) (bool, string, error) {
+func (r *Repository) ProductExistsForProject(ctx context.Context, projectID uuid.UUID, planName string) (bool, string, error) {
 	var stripeProductID string
 	query := `
 		SELECT stripe_product_id FROM registered_products
-		WHERE project_name = $1 AND plan_name = $2
+		WHERE project_id = $1 AND plan_name = $2
 		LIMIT 1
 	`
 
-	err := r.db.QueryRow(ctx, query, projectName, planName).Scan(&stripeProductID)
+	err := r.db.QueryRow(ctx, query, projectID, planName).Scan(&stripeProductID)
+	if errors.Is(err, ErrNotFound) {
+		return false, "", nil
+	}
 	if err != nil {
-		if err.Error() == "no rows in result set" {
-			return false, "", nil
-		}
 		return false, "", err
 	}
 
@@ -84,10 +119,7 @@ func (r *Repository) ProductExistsForProject(ctx context.Context, projectName, p
 // GetRegisteredProductByStripeID retrieves a registered product by its Stripe product ID
 func (r *Repository) GetRegisteredProductByStripeID(ctx context.Context, stripeProductID string) (*RegisteredProduct, error) {
 	query := `
-		SELECT id, project_name, plan_name, stripe_product_id,
-			stripe_price_monthly, stripe_price_yearly,
-			monthly_amount, yearly_amount, currency,
-			description, features, created_at, updated_at
+		SELECT ` + registeredProductColumns + `
 		FROM registered_products
 		WHERE stripe_product_id = $1
 	`
@@ -95,6 +127,47 @@ func (r *Repository) GetRegisteredProductByStripeID(ctx context.Context, stripeP
 	return ScanRegisteredProduct(r.db.QueryRow(ctx, query, stripeProductID))
 }
 
+// SyncRegisteredProduct upserts the catalog entry of a Stripe product from a product.* event.
+// Prices are left alone; events older than the last applied one and entries of another project are skipped.
+// It reports whether the product was written.
+func (r *Repository) SyncRegisteredProduct(ctx context.Context, product *RegisteredProduct, eventAt time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO registered_products (
+			project_id, plan_name, stripe_product_id, currency, description,
+			active, archived_at, last_event_at, created_at, updated_at
+		) VALUES ($1, $2, $3, 'usd', $4, $5, $6, $7, NOW(), NOW())
+		ON CONFLICT (stripe_product_id) DO UPDATE SET
+			plan_name = EXCLUDED.plan_name,
+			description = EXCLUDED.description,
+			active = EXCLUDED.active,
+			archived_at = CASE WHEN EXCLUDED.active THEN NULL ELSE COALESCE(registered_products.archived_at, EXCLUDED.archived_at) END,
+			last_event_at = EXCLUDED.last_event_at,
+			updated_at = NOW()
+		WHERE registered_products.project_id = EXCLUDED.project_id
+			AND (registered_products.last_event_at IS NULL OR registered_products.last_event_at <= EXCLUDED.last_event_at)
+	`, product.ProjectID, product.PlanName, product.StripeProductID, product.Description,
+		product.Active, product.ArchivedAt, eventAt)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// ArchiveRegisteredProduct marks a product deleted in Stripe as inactive, reporting whether it was known
+func (r *Repository) ArchiveRegisteredProduct(ctx context.Context, stripeProductID string, archivedAt time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE registered_products
+		SET active = FALSE, archived_at = COALESCE(archived_at, $1), last_event_at = GREATEST(last_event_at, $1), updated_at = NOW()
+		WHERE stripe_product_id = $2
+	`, archivedAt, stripeProductID)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
 // Helper functions for nullable fields
 func nullString(s string) interface{} {
 	if s == "" {
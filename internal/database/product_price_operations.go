@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SetRegisteredProductPrice stores an active recurring price from an event created at eventAt in the
+// product's monthly or yearly slot. It reports whether the product is known and the event is not stale.
+func (r *Repository) SetRegisteredProductPrice(ctx context.Context, stripeProductID, interval, stripePriceID string, amount int64, currency string, eventAt time.Time) (bool, error) {
+	priceColumn, amountColumn, err := priceColumns(interval)
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := r.db.Exec(ctx, `
+		UPDATE registered_products
+		SET `+priceColumn+` = $1, `+amountColumn+` = $2, currency = $3, last_event_at = $4, updated_at = NOW()
+		WHERE stripe_product_id = $5 AND (last_event_at IS NULL OR last_event_at <= $4)
+	`, stripePriceID, amount, currency, eventAt, stripeProductID)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// ClearRegisteredProductPrice empties the product's monthly or yearly slot if it still holds a price
+// archived by an event created at eventAt, unless the event is stale
+func (r *Repository) ClearRegisteredProductPrice(ctx context.Context, stripeProductID, interval, stripePriceID string, eventAt time.Time) (bool, error) {
+	priceColumn, amountColumn, err := priceColumns(interval)
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := r.db.Exec(ctx, `
+		UPDATE registered_products
+		SET `+priceColumn+` = NULL, `+amountColumn+` = NULL, last_event_at = $3, updated_at = NOW()
+		WHERE stripe_product_id = $1 AND `+priceColumn+` = $2 AND (last_event_at IS NULL OR last_event_at <= $3)
+	`, stripeProductID, stripePriceID, eventAt)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// priceColumns returns the price and amount columns of a recurring interval
+func priceColumns(interval string) (string, string, error) {
+	switch interval {
+	case PriceIntervalMonth:
+		return "stripe_price_monthly", "monthly_amount", nil
+	case PriceIntervalYear:
+		return "stripe_price_yearly", "yearly_amount", nil
+	}
+	return "", "", fmt.Errorf("unsupported price interval '%s'", interval)
+}
@@ -4,11 +4,21 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/google/uuid"
 )
 
+const projectColumns = `id, name, api_key, webhook_url, webhook_signing_secret,
+	stripe_secret_key, stripe_webhook_secret, dunning_grace_days, dunning_max_retries, is_active, created_at, updated_at`
+
+// Dunning settings of new projects
+const (
+	DefaultDunningGraceDays  = 7
+	DefaultDunningMaxRetries = 4
+)
+
 // CreateProject creates a new project with a generated API key
 func (r *Repository) CreateProject(ctx context.Context, name, webhookURL string) (*Project, error) {
 	apiKey, err := GenerateAPIKey()
@@ -16,19 +26,28 @@ func (r *Repository) CreateProject(ctx context.Context, name, webhookURL string)
 		return nil, fmt.Errorf("failed to generate API key: %w", err)
 	}
 
+	webhookSecret, err := GenerateWebhookSigningSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook signing secret: %w", err)
+	}
+
 	id := uuid.New()
 	project := &Project{
-		ID:         id,
-		Name:       name,
-		APIKey:     apiKey,
-		WebhookURL: webhookURL,
-		IsActive:   true,
+		ID:                   id,
+		Name:                 name,
+		APIKey:               apiKey,
+		WebhookURL:           webhookURL,
+		WebhookSigningSecret: webhookSecret,
+		DunningGraceDays:     DefaultDunningGraceDays,
+		DunningMaxRetries:    DefaultDunningMaxRetries,
+		IsActive:             true,
 	}
 
 	_, err = r.db.Exec(ctx, `
-		INSERT INTO projects (id, name, api_key, webhook_url, is_active)
-		VALUES ($1, $2, $3, $4, $5)
-	`, project.ID, project.Name, project.APIKey, project.WebhookURL, project.IsActive)
+		INSERT INTO projects (id, name, api_key, webhook_url, webhook_signing_secret, dunning_grace_days, dunning_max_retries, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, project.ID, project.Name, project.APIKey, project.WebhookURL, project.WebhookSigningSecret,
+		project.DunningGraceDays, project.DunningMaxRetries, project.IsActive)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create project: %w", err)
@@ -40,7 +59,7 @@ func (r *Repository) CreateProject(ctx context.Context, name, webhookURL string)
 // GetProjectByAPIKey retrieves a project by its API key
 func (r *Repository) GetProjectByAPIKey(ctx context.Context, apiKey string) (*Project, error) {
 	return ScanProject(r.db.QueryRow(ctx, `
-		SELECT id, name, api_key, webhook_url, is_active, created_at, updated_at
+		SELECT `+projectColumns+`
 		FROM projects
 		WHERE api_key = $1 AND is_active = true
 	`, apiKey))
@@ -49,7 +68,7 @@ func (r *Repository) GetProjectByAPIKey(ctx context.Context, apiKey string) (*Pr
 // GetProjectByID retrieves a project by its ID
 func (r *Repository) GetProjectByID(ctx context.Context, projectID uuid.UUID) (*Project, error) {
 	return ScanProject(r.db.QueryRow(ctx, `
-		SELECT id, name, api_key, webhook_url, is_active, created_at, updated_at
+		SELECT `+projectColumns+`
 		FROM projects
 		WHERE id = $1
 	`, projectID))
@@ -58,7 +77,7 @@ func (r *Repository) GetProjectByID(ctx context.Context, projectID uuid.UUID) (*
 // ListProjects retrieves all projects
 func (r *Repository) ListProjects(ctx context.Context) ([]*Project, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, name, api_key, webhook_url, is_active, created_at, updated_at
+		SELECT `+projectColumns+`
 		FROM projects
 		ORDER BY created_at DESC
 	`)
@@ -79,6 +98,39 @@ func (r *Repository) ListProjects(ctx context.Context) ([]*Project, error) {
 	return projects, rows.Err()
 }
 
+// UpdateProjectDunningSettings sets the grace period and retry count used for a project's failed payments
+func (r *Repository) UpdateProjectDunningSettings(ctx context.Context, projectID uuid.UUID, graceDays, maxRetries int) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE projects
+		SET dunning_grace_days = $1, dunning_max_retries = $2, updated_at = NOW()
+		WHERE id = $3
+	`, graceDays, maxRetries, projectID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errNoRows
+	}
+	return nil
+}
+
+// UpdateProjectStripeSettings sets the Stripe account a project's webhook events are processed with.
+// Empty values fall back to the service-wide Stripe configuration.
+func (r *Repository) UpdateProjectStripeSettings(ctx context.Context, projectID uuid.UUID, secretKey, webhookSecret string) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE projects
+		SET stripe_secret_key = NULLIF($1, ''), stripe_webhook_secret = NULLIF($2, ''), updated_at = NOW()
+		WHERE id = $3
+	`, secretKey, webhookSecret, projectID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errNoRows
+	}
+	return nil
+}
+
 // GenerateAPIKey generates a secure random API key
 func GenerateAPIKey() (string, error) {
 	b := make([]byte, 32)
@@ -91,3 +143,14 @@ func GenerateAPIKey() (string, error) {
 	encoded := base64.URLEncoding.EncodeToString(b)
 	return "proj_" + encoded[:43], nil
 }
+
+// GenerateWebhookSigningSecret generates a secret for signing outbound project webhooks
+func GenerateWebhookSigningSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return "pwhsec_" + hex.EncodeToString(b), nil
+}
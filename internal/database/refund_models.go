@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Refund represents a refund of a charge, recorded from charge.refunded and charge.refund.updated events
+type Refund struct {
+	ID                    uuid.UUID  `json:"id"`
+	ProjectID             uuid.UUID  `json:"project_id"`
+	CustomerID            uuid.UUID  `json:"customer_id"`
+	UserID                string     `json:"user_id"`
+	StripeRefundID        string     `json:"stripe_refund_id"`
+	StripeChargeID        string     `json:"stripe_charge_id"`
+	StripePaymentIntentID string     `json:"stripe_payment_intent_id,omitempty"`
+	OrderID               *uuid.UUID `json:"order_id,omitempty"`
+	InvoiceID             *uuid.UUID `json:"invoice_id,omitempty"`
+	Amount                int64      `json:"amount"`
+	Currency              string     `json:"currency"`
+	Status                string     `json:"status"` // Stripe refund status: pending, succeeded, failed or canceled
+	Reason                string     `json:"reason,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// Dispute represents a chargeback of a charge, kept current from charge.dispute.* events
+type Dispute struct {
+	ID                    uuid.UUID  `json:"id"`
+	ProjectID             uuid.UUID  `json:"project_id"`
+	CustomerID            uuid.UUID  `json:"customer_id"`
+	UserID                string     `json:"user_id"`
+	StripeDisputeID       string     `json:"stripe_dispute_id"`
+	StripeChargeID        string     `json:"stripe_charge_id"`
+	StripePaymentIntentID string     `json:"stripe_payment_intent_id,omitempty"`
+	OrderID               *uuid.UUID `json:"order_id,omitempty"`
+	InvoiceID             *uuid.UUID `json:"invoice_id,omitempty"`
+	Amount                int64      `json:"amount"`
+	Currency              string     `json:"currency"`
+	Status                string     `json:"status"` // Stripe dispute status, e.g. needs_response, under_review, won or lost
+	Reason                string     `json:"reason,omitempty"`
+	LastEventAt           time.Time  `json:"-"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// ChargeLinks identifies the customer, order and invoice a Stripe charge was paid for
+type ChargeLinks struct {
+	ProjectID  uuid.UUID
+	CustomerID uuid.UUID
+	UserID     string
+	OrderID    *uuid.UUID
+	InvoiceID  *uuid.UUID
+}
+
+const refundColumns = `id, project_id, customer_id, user_id, stripe_refund_id, stripe_charge_id, stripe_payment_intent_id,
+	order_id, invoice_id, amount, currency, status, reason, created_at, updated_at`
+
+const disputeColumns = `id, project_id, customer_id, user_id, stripe_dispute_id, stripe_charge_id, stripe_payment_intent_id,
+	order_id, invoice_id, amount, currency, status, reason, last_event_at, created_at, updated_at`
+
+// ScanRefund scans a database row into a Refund struct
+func ScanRefund(row pgx.Row) (*Refund, error) {
+	var refund Refund
+	var paymentIntentID, reason sql.NullString
+
+	err := row.Scan(
+		&refund.ID,
+		&refund.ProjectID,
+		&refund.CustomerID,
+		&refund.UserID,
+		&refund.StripeRefundID,
+		&refund.StripeChargeID,
+		&paymentIntentID,
+		&refund.OrderID,
+		&refund.InvoiceID,
+		&refund.Amount,
+		&refund.Currency,
+		&refund.Status,
+		&reason,
+		&refund.CreatedAt,
+		&refund.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	refund.StripePaymentIntentID = paymentIntentID.String
+	refund.Reason = reason.String
+
+	return &refund, nil
+}
+
+// ScanDispute scans a database row into a Dispute struct
+func ScanDispute(row pgx.Row) (*Dispute, error) {
+	var dispute Dispute
+	var paymentIntentID, reason sql.NullString
+
+	err := row.Scan(
+		&dispute.ID,
+		&dispute.ProjectID,
+		&dispute.CustomerID,
+		&dispute.UserID,
+		&dispute.StripeDisputeID,
+		&dispute.StripeChargeID,
+		&paymentIntentID,
+		&dispute.OrderID,
+		&dispute.InvoiceID,
+		&dispute.Amount,
+		&dispute.Currency,
+		&dispute.Status,
+		&reason,
+		&dispute.LastEventAt,
+		&dispute.CreatedAt,
+		&dispute.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute.StripePaymentIntentID = paymentIntentID.String
+	dispute.Reason = reason.String
+
+	return &dispute, nil
+}
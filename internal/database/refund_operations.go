@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetChargeLinks finds the order or invoice a charge paid for, by payment intent or Stripe invoice ID.
+// It returns ErrNotFound when the charge belongs to neither.
+func (r *Repository) GetChargeLinks(ctx context.Context, paymentIntentID, stripeInvoiceID string) (*ChargeLinks, error) {
+	var links ChargeLinks
+
+	if paymentIntentID != "" {
+		var orderID uuid.UUID
+		err := r.db.QueryRow(ctx, `
+			SELECT id, project_id, customer_id, user_id
+			FROM orders
+			WHERE stripe_payment_intent_id = $1
+		`, paymentIntentID).Scan(&orderID, &links.ProjectID, &links.CustomerID, &links.UserID)
+		if err == nil {
+			links.OrderID = &orderID
+			return &links, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	var invoiceID uuid.UUID
+	err := r.db.QueryRow(ctx, `
+		SELECT id, project_id, customer_id, user_id
+		FROM invoices
+		WHERE ($1 <> '' AND stripe_invoice_id = $1) OR ($2 <> '' AND stripe_payment_intent_id = $2)
+		LIMIT 1
+	`, stripeInvoiceID, paymentIntentID).Scan(&invoiceID, &links.ProjectID, &links.CustomerID, &links.UserID)
+	if err != nil {
+		return nil, err
+	}
+	links.InvoiceID = &invoiceID
+
+	return &links, nil
+}
+
+// UpsertRefund stores the latest state of a refund; another project's refund is left alone
+func (r *Repository) UpsertRefund(ctx context.Context, refund *Refund) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO refunds (
+			project_id, customer_id, user_id, stripe_refund_id, stripe_charge_id, stripe_payment_intent_id,
+			order_id, invoice_id, amount, currency, status, reason, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW())
+		ON CONFLICT (stripe_refund_id) DO UPDATE SET
+			amount = EXCLUDED.amount,
+			status = EXCLUDED.status,
+			reason = EXCLUDED.reason,
+			updated_at = NOW()
+		WHERE refunds.project_id = EXCLUDED.project_id
+	`, refund.ProjectID, refund.CustomerID, refund.UserID, refund.StripeRefundID, refund.StripeChargeID,
+		nullString(refund.StripePaymentIntentID), refund.OrderID, refund.InvoiceID,
+		refund.Amount, refund.Currency, refund.Status, nullString(refund.Reason))
+	return err
+}
+
+// GetRefundByStripeID retrieves a refund by its Stripe refund ID
+func (r *Repository) GetRefundByStripeID(ctx context.Context, stripeRefundID string) (*Refund, error) {
+	return ScanRefund(r.db.QueryRow(ctx, `
+		SELECT `+refundColumns+`
+		FROM refunds
+		WHERE stripe_refund_id = $1
+	`, stripeRefundID))
+}
+
+// MarkOrderRefunded records the refunded amount of an order; refundedAt flags it as fully refunded.
+// Refunded amounts only grow and a refund time is never cleared, so an event delivered late cannot
+// lower them or make a fully refunded order entitled again.
+func (r *Repository) MarkOrderRefunded(ctx context.Context, orderID uuid.UUID, amountRefunded int64, refundedAt *time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE orders
+		SET amount_refunded = GREATEST(amount_refunded, $2),
+			refunded_at = COALESCE(refunded_at, $3),
+			updated_at = NOW()
+		WHERE id = $1
+	`, orderID, amountRefunded, refundedAt)
+	return err
+}
+
+// UpsertDispute stores the latest state of a dispute from an event created at dispute.LastEventAt.
+// It reports whether the write was applied; events older than the stored state and writes to
+// another project's dispute are ignored.
+func (r *Repository) UpsertDispute(ctx context.Context, dispute *Dispute) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO disputes (
+			project_id, customer_id, user_id, stripe_dispute_id, stripe_charge_id, stripe_payment_intent_id,
+			order_id, invoice_id, amount, currency, status, reason, last_event_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW(), NOW())
+		ON CONFLICT (stripe_dispute_id) DO UPDATE SET
+			amount = EXCLUDED.amount,
+			status = EXCLUDED.status,
+			reason = EXCLUDED.reason,
+			last_event_at = EXCLUDED.last_event_at,
+			updated_at = NOW()
+		WHERE disputes.project_id = EXCLUDED.project_id AND disputes.last_event_at <= EXCLUDED.last_event_at
+	`, dispute.ProjectID, dispute.CustomerID, dispute.UserID, dispute.StripeDisputeID, dispute.StripeChargeID,
+		nullString(dispute.StripePaymentIntentID), dispute.OrderID, dispute.InvoiceID,
+		dispute.Amount, dispute.Currency, dispute.Status, nullString(dispute.Reason), dispute.LastEventAt)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetDisputeByStripeID retrieves a dispute by its Stripe dispute ID
+func (r *Repository) GetDisputeByStripeID(ctx context.Context, stripeDisputeID string) (*Dispute, error) {
+	return ScanDispute(r.db.QueryRow(ctx, `
+		SELECT `+disputeColumns+`
+		FROM disputes
+		WHERE stripe_dispute_id = $1
+	`, stripeDisputeID))
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SubscriptionEvent is one entry of a subscription's append-only history: a change of its status
+// or billing period, the state it replaced and the Stripe event that caused it
+type SubscriptionEvent struct {
+	ID                   uuid.UUID  `json:"id"`
+	SubscriptionID       uuid.UUID  `json:"subscription_id"`
+	ProjectID            uuid.UUID  `json:"project_id"`
+	UserID               string     `json:"user_id"`
+	ProductID            string     `json:"product_id"`
+	StripeSubscriptionID string     `json:"stripe_subscription_id"`
+	PreviousStatus       string     `json:"previous_status,omitempty"` // Empty for the first entry of a subscription
+	Status               string     `json:"status"`
+	PreviousPeriodEnd    *time.Time `json:"previous_period_end,omitempty"`
+	CurrentPeriodEnd     time.Time  `json:"current_period_end"`
+	SourceEventID        string     `json:"source_event_id"`
+	EventType            string     `json:"event_type"`
+	OccurredAt           time.Time  `json:"occurred_at"` // Created time of the Stripe event
+	RecordedAt           time.Time  `json:"recorded_at"`
+}
+
+const subscriptionEventColumns = `id, subscription_id, project_id, user_id, product_id, stripe_subscription_id,
+	previous_status, status, previous_period_end, current_period_end, source_event_id, event_type, occurred_at, recorded_at`
+
+// ScanSubscriptionEvent scans a database row into a SubscriptionEvent struct
+func ScanSubscriptionEvent(row pgx.Row) (*SubscriptionEvent, error) {
+	var event SubscriptionEvent
+	var previousStatus sql.NullString
+	err := row.Scan(
+		&event.ID,
+		&event.SubscriptionID,
+		&event.ProjectID,
+		&event.UserID,
+		&event.ProductID,
+		&event.StripeSubscriptionID,
+		&previousStatus,
+		&event.Status,
+		&event.PreviousPeriodEnd,
+		&event.CurrentPeriodEnd,
+		&event.SourceEventID,
+		&event.EventType,
+		&event.OccurredAt,
+		&event.RecordedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	event.PreviousStatus = previousStatus.String
+	return &event, nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecordSubscriptionEvent appends the current state of a subscription to its history, attributed to the
+// Stripe event that produced it. Nothing is recorded if the status, billing period end and Stripe
+// subscription are unchanged since the last entry; it reports whether an entry was added.
+// Call it in the transaction that wrote the subscription, whose row lock keeps concurrent entries in order.
+func (r *Repository) RecordSubscriptionEvent(ctx context.Context, stripeSubID, sourceEventID, eventType string, occurredAt time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO subscription_events (
+			subscription_id, project_id, user_id, product_id, stripe_subscription_id,
+			previous_status, status, previous_period_end, current_period_end,
+			source_event_id, event_type, occurred_at
+		)
+		SELECT s.id, s.project_id, s.user_id, s.product_id, s.stripe_subscription_id,
+			last.status, s.status, last.current_period_end, s.current_period_end,
+			$2, $3, $4
+		FROM subscriptions s
+		LEFT JOIN LATERAL (
+			SELECT e.status, e.current_period_end, e.stripe_subscription_id
+			FROM subscription_events e
+			WHERE e.subscription_id = s.id
+			ORDER BY e.recorded_at DESC, e.id DESC
+			LIMIT 1
+		) last ON TRUE
+		WHERE s.stripe_subscription_id = $1 AND (
+			last.status IS DISTINCT FROM s.status
+			OR last.current_period_end IS DISTINCT FROM s.current_period_end
+			OR last.stripe_subscription_id IS DISTINCT FROM s.stripe_subscription_id
+		)
+	`, stripeSubID, sourceEventID, eventType, occurredAt)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListSubscriptionEvents lists the history of a user's subscription to a product, newest first
+func (r *Repository) ListSubscriptionEvents(ctx context.Context, projectID uuid.UUID, userID, productID string, limit, offset int) ([]*SubscriptionEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+subscriptionEventColumns+`
+		FROM subscription_events
+		WHERE project_id = $1 AND user_id = $2 AND product_id = $3
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT $4 OFFSET $5
+	`, projectID, userID, productID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*SubscriptionEvent{}
+	for rows.Next() {
+		event, err := ScanSubscriptionEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SubscriptionItem represents one price of a subscription (base plan or add-on)
+type SubscriptionItem struct {
+	ID                       uuid.UUID `json:"id"`
+	SubscriptionID           uuid.UUID `json:"subscription_id"`
+	StripeSubscriptionItemID string    `json:"stripe_subscription_item_id"`
+	PriceID                  string    `json:"price_id"`
+	ProductID                string    `json:"product_id"`
+	Quantity                 int64     `json:"quantity"`
+	CreatedAt                time.Time `json:"created_at"`
+	UpdatedAt                time.Time `json:"updated_at"`
+}
+
+// ScanSubscriptionItem scans a database row into a SubscriptionItem struct
+func ScanSubscriptionItem(row pgx.Row) (*SubscriptionItem, error) {
+	var item SubscriptionItem
+	err := row.Scan(
+		&item.ID,
+		&item.SubscriptionID,
+		&item.StripeSubscriptionItemID,
+		&item.PriceID,
+		&item.ProductID,
+		&item.Quantity,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
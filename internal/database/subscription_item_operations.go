@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+)
+
+// SyncSubscriptionItems replaces the stored items of a subscription with the given set in one transaction.
+// Items are keyed by Stripe subscription item ID; items missing from the set are removed.
+func (r *Repository) SyncSubscriptionItems(ctx context.Context, stripeSubID string, items []*SubscriptionItem) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var subscriptionID string
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM subscriptions WHERE stripe_subscription_id = $1 FOR UPDATE
+	`, stripeSubID).Scan(&subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	itemIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.StripeSubscriptionItemID)
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM subscription_items
+		WHERE subscription_id = $1 AND NOT (stripe_subscription_item_id = ANY($2))
+	`, subscriptionID, itemIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		err := tx.QueryRow(ctx, `
+			INSERT INTO subscription_items (
+				subscription_id, stripe_subscription_item_id, price_id, product_id, quantity, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+			ON CONFLICT (stripe_subscription_item_id) DO UPDATE SET
+				subscription_id = EXCLUDED.subscription_id,
+				price_id = EXCLUDED.price_id,
+				product_id = EXCLUDED.product_id,
+				quantity = EXCLUDED.quantity,
+				updated_at = NOW()
+			RETURNING id, subscription_id, created_at, updated_at
+		`, subscriptionID, item.StripeSubscriptionItemID, item.PriceID, item.ProductID, item.Quantity,
+		).Scan(&item.ID, &item.SubscriptionID, &item.CreatedAt, &item.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetSubscriptionItems retrieves the items of a subscription by Stripe subscription ID
+func (r *Repository) GetSubscriptionItems(ctx context.Context, stripeSubID string) ([]*SubscriptionItem, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT si.id, si.subscription_id, si.stripe_subscription_item_id, si.price_id, si.product_id,
+			si.quantity, si.created_at, si.updated_at
+		FROM subscription_items si
+		JOIN subscriptions s ON s.id = si.subscription_id
+		WHERE s.stripe_subscription_id = $1
+		ORDER BY si.created_at, si.stripe_subscription_item_id
+	`, stripeSubID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*SubscriptionItem{}
+	for rows.Next() {
+		item, err := ScanSubscriptionItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
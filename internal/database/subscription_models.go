@@ -0,0 +1,123 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Subscription represents a subscription record
+type Subscription struct {
+	ID                   uuid.UUID  `json:"id"`
+	ProjectID            uuid.UUID  `json:"project_id"`
+	CustomerID           uuid.UUID  `json:"customer_id"`
+	UserID               string     `json:"user_id"`
+	ProductID            string     `json:"product_id"`
+	PriceID              string     `json:"price_id"`
+	StripeSubscriptionID string     `json:"stripe_subscription_id"`
+	Status               string     `json:"status"`
+	CurrentPeriodStart   time.Time  `json:"current_period_start"`
+	CurrentPeriodEnd     time.Time  `json:"current_period_end"`
+	LastEventAt          *time.Time `json:"last_event_at,omitempty"` // Created time of the last applied Stripe event
+	SubscriptionLifecycle
+	SubscriptionDunning
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SubscriptionLifecycle holds the trial, cancellation and pause state of a subscription
+type SubscriptionLifecycle struct {
+	TrialStart        *time.Time `json:"trial_start,omitempty"`
+	TrialEnd          *time.Time `json:"trial_end,omitempty"`
+	CancelAtPeriodEnd bool       `json:"cancel_at_period_end"`
+	CanceledAt        *time.Time `json:"canceled_at,omitempty"`
+	EndedAt           *time.Time `json:"ended_at,omitempty"`
+	PauseBehavior     string     `json:"pause_behavior,omitempty"` // pause_collection.behavior, empty when not paused
+	PauseResumesAt    *time.Time `json:"pause_resumes_at,omitempty"`
+}
+
+// SubscriptionDunning holds the failed payment recovery state of a subscription
+type SubscriptionDunning struct {
+	DunningState     string     `json:"dunning_state,omitempty"` // Empty when no payment has failed
+	DunningStartedAt *time.Time `json:"dunning_started_at,omitempty"`
+	DunningRetries   int64      `json:"dunning_retries"` // Failed payment attempts of the current invoice
+}
+
+// Dunning states of a subscription after a failed payment
+const (
+	DunningStateGrace     = "grace"     // Payment failed, access kept during the project's grace period
+	DunningStateRetrying  = "retrying"  // Grace period over, Stripe still retrying the payment
+	DunningStateSuspended = "suspended" // Retries exhausted, access revoked
+	DunningStateRecovered = "recovered" // A later payment succeeded
+)
+
+// Subscription statuses a Stripe subscription can never leave
+const (
+	SubscriptionStatusCanceled          = "canceled"
+	SubscriptionStatusIncompleteExpired = "incomplete_expired"
+)
+
+// IsTerminalSubscriptionStatus reports whether a subscription in this status can never change again
+func IsTerminalSubscriptionStatus(status string) bool {
+	return status == SubscriptionStatusCanceled || status == SubscriptionStatusIncompleteExpired
+}
+
+// terminalStatusList is the SQL list of terminal subscription statuses
+const terminalStatusList = `('` + SubscriptionStatusCanceled + `', '` + SubscriptionStatusIncompleteExpired + `')`
+
+// ScanSubscription scans a database row into a Subscription struct
+func ScanSubscription(row pgx.Row) (*Subscription, error) {
+	var sub Subscription
+	var pauseBehavior, dunningState sql.NullString
+	err := row.Scan(
+		&sub.ID,
+		&sub.ProjectID,
+		&sub.CustomerID,
+		&sub.UserID,
+		&sub.ProductID,
+		&sub.PriceID,
+		&sub.StripeSubscriptionID,
+		&sub.Status,
+		&sub.CurrentPeriodStart,
+		&sub.CurrentPeriodEnd,
+		&sub.LastEventAt,
+		&sub.TrialStart,
+		&sub.TrialEnd,
+		&sub.CancelAtPeriodEnd,
+		&sub.CanceledAt,
+		&sub.EndedAt,
+		&pauseBehavior,
+		&sub.PauseResumesAt,
+		&dunningState,
+		&sub.DunningStartedAt,
+		&sub.DunningRetries,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if pauseBehavior.Valid {
+		sub.PauseBehavior = pauseBehavior.String
+	}
+	if dunningState.Valid {
+		sub.DunningState = dunningState.String
+	}
+	return &sub, nil
+}
+
+// ScanSubscriptionStatus scans a database row into subscription status fields
+func ScanSubscriptionStatus(row pgx.Row) (string, string, time.Time, bool, error) {
+	var stripeSubID, customerID sqlString
+	var currentPeriodEnd sqlTime
+	var exists sqlBool
+
+	err := row.Scan(&stripeSubID, &customerID, &currentPeriodEnd, &exists)
+	if err != nil {
+		return "", "", time.Time{}, false, err
+	}
+
+	return string(stripeSubID), string(customerID), time.Time(currentPeriodEnd), bool(exists), nil
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetSubscriptionStatus retrieves subscription status for a user/product.
+// A subscription matches if its primary product or any of its items is the product.
+func (r *Repository) GetSubscriptionStatus(ctx context.Context, projectID uuid.UUID, userID, productID string) (string, string, time.Time, bool, error) {
+	row := r.db.QueryRow(ctx, `
+		SELECT 
+			stripe_subscription_id,
+			customer_id,
+			current_period_end,
+			TRUE as exists
+		FROM subscriptions 
+		WHERE project_id = $1 AND user_id = $2 AND (
+			product_id = $3 OR EXISTS (
+				SELECT 1 FROM subscription_items
+				WHERE subscription_items.subscription_id = subscriptions.id AND subscription_items.product_id = $3
+			)
+		)
+		ORDER BY current_period_end DESC
+		LIMIT 1
+	`, projectID, userID, productID)
+
+	return ScanSubscriptionStatus(row)
+}
+
+// CreateSubscription creates or updates a subscription from a Stripe event created at eventAt.
+// A known subscription is updated in place, including a plan change to another product; an unknown
+// one is inserted, replacing an older subscription of the user to the same product.
+// It reports whether the write was applied; stale events (see staleEventCondition) are ignored.
+func (r *Repository) CreateSubscription(ctx context.Context, projectID uuid.UUID, customerID, stripeSubID, productID, priceID, userID, status string, periodStart, periodEnd, eventAt time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE subscriptions
+		SET customer_id = $1, product_id = $2, price_id = $3, status = $4,
+			current_period_start = $5, current_period_end = $6, last_event_at = $7, updated_at = $8
+		WHERE stripe_subscription_id = $9 AND `+staleEventCondition("$9", "$4", "$7"),
+		customerID, productID, priceID, status, periodStart, periodEnd, eventAt, time.Now(), stripeSubID)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() > 0 {
+		return true, nil
+	}
+
+	var known bool
+	if err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM subscriptions WHERE stripe_subscription_id = $1)
+	`, stripeSubID).Scan(&known); err != nil {
+		return false, err
+	}
+	if known {
+		// The update above skipped the subscription as stale
+		return false, nil
+	}
+
+	tag, err = r.db.Exec(ctx, `
+		INSERT INTO subscriptions (
+			project_id, customer_id, user_id, product_id, price_id,
+			stripe_subscription_id, status, current_period_start, current_period_end,
+			last_event_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+		)
+		ON CONFLICT (project_id, user_id, product_id) DO UPDATE SET
+			customer_id = EXCLUDED.customer_id,
+			price_id = EXCLUDED.price_id,
+			stripe_subscription_id = EXCLUDED.stripe_subscription_id,
+			status = EXCLUDED.status,
+			current_period_start = EXCLUDED.current_period_start,
+			current_period_end = EXCLUDED.current_period_end,
+			last_event_at = EXCLUDED.last_event_at,
+			updated_at = EXCLUDED.updated_at
+		WHERE `+staleEventCondition("EXCLUDED.stripe_subscription_id", "EXCLUDED.status", "EXCLUDED.last_event_at"),
+		projectID, customerID, userID, productID, priceID, stripeSubID, status, periodStart, periodEnd, eventAt, time.Now(), time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// UpdateSubscriptionStatus updates subscription status and period end from a Stripe event created at eventAt.
+// It reports whether a row was updated; unknown subscriptions and stale events leave it false.
+func (r *Repository) UpdateSubscriptionStatus(ctx context.Context, stripeSubID, status string, periodEnd, eventAt time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE subscriptions 
+		SET status = $1, current_period_end = $2, last_event_at = $3, updated_at = $4
+		WHERE stripe_subscription_id = $5 AND `+staleEventCondition("$5", "$1", "$3"),
+		status, periodEnd, eventAt, time.Now(), stripeSubID)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// staleEventCondition builds the SQL guard that only lets an event through if it is not older than the
+// event the row was last written from, and does not revive a subscription that already reached a
+// terminal status. Events for a different Stripe subscription only need to be newer.
+func staleEventCondition(stripeSubID, status, eventAt string) string {
+	return `(subscriptions.last_event_at IS NULL OR subscriptions.last_event_at <= ` + eventAt + `)
+			AND NOT (
+				subscriptions.stripe_subscription_id = ` + stripeSubID + `
+				AND subscriptions.status IN ` + terminalStatusList + `
+				AND ` + status + ` NOT IN ` + terminalStatusList + `
+			)`
+}
+
+// UpdateSubscriptionLifecycle stores the trial, cancellation and pause state of a subscription
+func (r *Repository) UpdateSubscriptionLifecycle(ctx context.Context, stripeSubID string, lifecycle SubscriptionLifecycle) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE subscriptions
+		SET trial_start = $1, trial_end = $2, cancel_at_period_end = $3, canceled_at = $4, ended_at = $5,
+			pause_behavior = $6, pause_resumes_at = $7, updated_at = NOW()
+		WHERE stripe_subscription_id = $8
+	`, lifecycle.TrialStart, lifecycle.TrialEnd, lifecycle.CancelAtPeriodEnd, lifecycle.CanceledAt, lifecycle.EndedAt,
+		nullString(lifecycle.PauseBehavior), lifecycle.PauseResumesAt, stripeSubID)
+
+	return err
+}
+
+const subscriptionColumns = `id, project_id, customer_id, user_id, product_id, price_id,
+	stripe_subscription_id, status, current_period_start, current_period_end,
+	last_event_at, trial_start, trial_end, cancel_at_period_end, canceled_at, ended_at,
+	pause_behavior, pause_resumes_at, dunning_state, dunning_started_at, dunning_retries,
+	created_at, updated_at`
+
+// GetSubscriptionByStripeID retrieves subscription by Stripe subscription ID
+func (r *Repository) GetSubscriptionByStripeID(ctx context.Context, stripeSubID string) (*Subscription, error) {
+	return ScanSubscription(r.db.QueryRow(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM subscriptions 
+		WHERE stripe_subscription_id = $1
+	`, stripeSubID))
+}
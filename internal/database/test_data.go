@@ -0,0 +1,129 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/config"
+	"github.com/google/uuid"
+)
+
+// CreateTestProject creates a test project in the database
+func (td *TestDatabase) CreateTestProject(project *Project) error {
+	if td.memory != nil {
+		return td.memory.seedProject(project)
+	}
+
+	_, err := td.Pool.Exec(td.ctx, `
+		INSERT INTO projects (id, name, api_key, webhook_url, webhook_signing_secret, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (api_key) DO UPDATE SET
+			name = EXCLUDED.name,
+			webhook_url = EXCLUDED.webhook_url,
+			webhook_signing_secret = EXCLUDED.webhook_signing_secret,
+			updated_at = EXCLUDED.updated_at
+	`, project.ID, project.Name, project.APIKey, project.WebhookURL, nullString(project.WebhookSigningSecret), project.IsActive, project.CreatedAt, project.UpdatedAt)
+	return err
+}
+
+// CreateTestCustomer creates a test customer in the database
+func (td *TestDatabase) CreateTestCustomer(customer *Customer) error {
+	if td.memory != nil {
+		return td.memory.seedCustomer(customer)
+	}
+
+	_, err := td.Pool.Exec(td.ctx, `
+		INSERT INTO customers (id, project_id, user_id, email, stripe_customer_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (project_id, user_id) DO UPDATE SET
+			email = EXCLUDED.email,
+			stripe_customer_id = EXCLUDED.stripe_customer_id,
+			updated_at = EXCLUDED.updated_at
+	`, customer.ID, customer.ProjectID, customer.UserID, customer.Email, customer.StripeCustomerID, customer.CreatedAt, customer.UpdatedAt)
+	return err
+}
+
+// CreateTestSubscription creates a test subscription in the database
+func (td *TestDatabase) CreateTestSubscription(subscription *Subscription) error {
+	if td.memory != nil {
+		return td.memory.seedSubscription(subscription)
+	}
+
+	_, err := td.Pool.Exec(td.ctx, `
+		INSERT INTO subscriptions (
+			project_id, customer_id, user_id, product_id, price_id,
+			stripe_subscription_id, status, current_period_start, current_period_end,
+			created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		)
+		ON CONFLICT (project_id, user_id, product_id) DO UPDATE SET
+			stripe_subscription_id = EXCLUDED.stripe_subscription_id,
+			customer_id = EXCLUDED.customer_id,
+			status = EXCLUDED.status,
+			current_period_start = EXCLUDED.current_period_start,
+			current_period_end = EXCLUDED.current_period_end,
+			updated_at = EXCLUDED.updated_at
+	`, subscription.ProjectID, subscription.CustomerID, subscription.UserID, subscription.ProductID, subscription.PriceID,
+		subscription.StripeSubscriptionID, subscription.Status, subscription.CurrentPeriodStart, subscription.CurrentPeriodEnd,
+		subscription.CreatedAt, subscription.UpdatedAt)
+	return err
+}
+
+// CreateTestData creates test customers and subscriptions
+func (td *TestDatabase) CreateTestData() (*Project, *Customer, error) {
+	// Use timestamp and UUID to ensure uniqueness across parallel test runs
+	timestamp := time.Now().UnixNano()
+	uniqueSuffix := uuid.New().String()[:8]
+
+	// Create test project
+	projectID := uuid.New()
+	project := &Project{
+		ID:        projectID,
+		Name:      fmt.Sprintf("Test Project %d", timestamp),
+		APIKey:    fmt.Sprintf("sk_test_%d_%s", timestamp, uniqueSuffix),
+		IsActive:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := td.CreateTestProject(project); err != nil {
+		return nil, nil, fmt.Errorf("failed to create test project: %w", err)
+	}
+
+	// Create test customer
+	customerID := uuid.New()
+	customer := &Customer{
+		ID:               customerID,
+		ProjectID:        projectID,
+		UserID:           fmt.Sprintf("test_user_%d_%s", timestamp, uniqueSuffix),
+		Email:            fmt.Sprintf("test_%d_%s@example.com", timestamp, uniqueSuffix),
+		StripeCustomerID: config.TestCustomerID, // Use REAL test customer from Stripe
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+
+	if err := td.CreateTestCustomer(customer); err != nil {
+		return nil, nil, fmt.Errorf("failed to create test customer: %w", err)
+	}
+
+	// Create test subscription
+	subscription := &Subscription{
+		ProjectID:            projectID,
+		CustomerID:           customerID,
+		UserID:               customer.UserID,
+		StripeSubscriptionID: fmt.Sprintf("sub_test_%d_%s", timestamp, uniqueSuffix),
+		ProductID:            "premium_plan",
+		PriceID:              "price_123",
+		Status:               "active",
+		CurrentPeriodStart:   time.Now(),
+		CurrentPeriodEnd:     time.Now().Add(30 * 24 * time.Hour),
+		CreatedAt:            time.Now(),
+		UpdatedAt:            time.Now(),
+	}
+
+	if err := td.CreateTestSubscription(subscription); err != nil {
+		return nil, nil, fmt.Errorf("failed to create test subscription: %w", err)
+	}
+
+	return project, customer, nil
+}
@@ -2,22 +2,41 @@ package database
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"os"
 	"testing"
-	"time"
 
 	"github.com/DraconDev/go-stripe-ms/internal/config"
-	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
+	"github.com/DraconDev/go-stripe-ms/internal/database/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// TestDatabase manages database testing with real PostgreSQL
+// TestDatabase manages database testing with real PostgreSQL, or with a MemoryRepository
+// when created by NewMemoryTestDatabase
 type TestDatabase struct {
-	Conn *pgx.Conn
-	Repo *Repository
-	ctx  context.Context
+	Pool   *pgxpool.Pool // Nil for an in-memory test database
+	Repo   RepositoryInterface
+	memory *MemoryRepository
+	ctx    context.Context
+}
+
+// cleanupQueries truncates test data in reverse dependency order
+var cleanupQueries = []string{
+	"TRUNCATE TABLE pending_notifications CASCADE",
+	"TRUNCATE TABLE webhook_deliveries CASCADE",
+	"TRUNCATE TABLE stripe_event_actions CASCADE",
+	"TRUNCATE TABLE stripe_events CASCADE",
+	"TRUNCATE TABLE disputes CASCADE",
+	"TRUNCATE TABLE refunds CASCADE",
+	"TRUNCATE TABLE invoices CASCADE",
+	"TRUNCATE TABLE order_items CASCADE",
+	"TRUNCATE TABLE orders CASCADE",
+	"TRUNCATE TABLE subscription_events CASCADE",
+	"TRUNCATE TABLE subscription_items CASCADE",
+	"TRUNCATE TABLE subscriptions CASCADE",
+	"TRUNCATE TABLE customers CASCADE",
+	"TRUNCATE TABLE registered_products CASCADE",
+	"TRUNCATE TABLE projects CASCADE",
 }
 
 // NewTestDatabase creates a new test database connection
@@ -36,43 +55,61 @@ func NewTestDatabase(t *testing.T) *TestDatabase {
 	}
 
 	// Connect to test database
-	conn, err := pgx.Connect(ctx, connStr)
+	poolConfig, err := config.LoadDatabasePool().PoolConfig(connStr)
+	if err != nil {
+		t.Fatalf("Invalid test database configuration: %v", err)
+	}
+	pool, err := NewPool(ctx, poolConfig)
 	if err != nil {
 		t.Fatalf("Failed to connect to test database: %v", err)
 	}
 
 	// Create repository
-	repo := NewRepository(conn)
+	repo := NewRepository(pool)
 
 	return &TestDatabase{
-		Conn: conn,
+		Pool: pool,
 		Repo: repo,
 		ctx:  ctx,
 	}
 }
 
-// Setup creates tables and initializes the test database
+// NewMemoryTestDatabase creates a test database backed by an empty MemoryRepository
+func NewMemoryTestDatabase(t *testing.T) *TestDatabase {
+	t.Helper()
+
+	repo := NewMemoryRepository()
+	return &TestDatabase{
+		Repo:   repo,
+		memory: repo,
+		ctx:    context.Background(),
+	}
+}
+
+// Setup migrates the schema and clears the test database
 func (td *TestDatabase) Setup(t *testing.T) {
 	t.Helper()
 
-	// Clean up test data in reverse dependency order
-	cleanupQueries := []string{
-		"TRUNCATE TABLE subscriptions CASCADE",
-		"TRUNCATE TABLE customers CASCADE",
-		"TRUNCATE TABLE registered_products CASCADE",
-		"TRUNCATE TABLE projects CASCADE",
+	// An in-memory test database starts empty and has no schema
+	if td.memory != nil {
+		return
 	}
 
+	// Clean up test data in reverse dependency order
 	for _, query := range cleanupQueries {
-		_, err := td.Conn.Exec(td.ctx, query)
+		_, err := td.Pool.Exec(td.ctx, query)
 		if err != nil {
 			t.Logf("Warning: failed to clean up existing data with query '%s': %v", query, err)
 		}
 	}
 
-	// Initialize database tables
-	if err := td.Repo.InitializeTables(td.ctx); err != nil {
-		t.Fatalf("Failed to initialize test database: %v", err)
+	// Bring the schema up to date
+	migrator, err := migrations.New(td.Pool)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(td.ctx); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
 	}
 
 	log.Println("Test database setup completed")
@@ -82,131 +119,17 @@ func (td *TestDatabase) Setup(t *testing.T) {
 func (td *TestDatabase) Cleanup(t *testing.T) {
 	t.Helper()
 
-	if td.Conn != nil {
+	if td.Pool != nil {
 		// Clean up test data in reverse dependency order
-		cleanupQueries := []string{
-			"TRUNCATE TABLE subscriptions CASCADE",
-			"TRUNCATE TABLE customers CASCADE",
-			"TRUNCATE TABLE registered_products CASCADE",
-			"TRUNCATE TABLE projects CASCADE",
-		}
-
 		for _, query := range cleanupQueries {
-			_, err := td.Conn.Exec(td.ctx, query)
+			_, err := td.Pool.Exec(td.ctx, query)
 			if err != nil {
 				t.Logf("Warning: failed to clean up data with query '%s': %v", query, err)
 			}
 		}
 
-		td.Conn.Close(td.ctx)
+		td.Pool.Close()
 	}
 
 	log.Println("Test database cleanup completed")
 }
-
-// CreateTestProject creates a test project in the database
-func (td *TestDatabase) CreateTestProject(project *Project) error {
-	_, err := td.Conn.Exec(td.ctx, `
-		INSERT INTO projects (id, name, api_key, webhook_url, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (api_key) DO UPDATE SET
-			name = EXCLUDED.name,
-			webhook_url = EXCLUDED.webhook_url,
-			updated_at = EXCLUDED.updated_at
-	`, project.ID, project.Name, project.APIKey, project.WebhookURL, project.IsActive, project.CreatedAt, project.UpdatedAt)
-	return err
-}
-
-// CreateTestCustomer creates a test customer in the database
-func (td *TestDatabase) CreateTestCustomer(customer *Customer) error {
-	_, err := td.Conn.Exec(td.ctx, `
-		INSERT INTO customers (id, project_id, user_id, email, stripe_customer_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (project_id, user_id) DO UPDATE SET
-			email = EXCLUDED.email,
-			stripe_customer_id = EXCLUDED.stripe_customer_id,
-			updated_at = EXCLUDED.updated_at
-	`, customer.ID, customer.ProjectID, customer.UserID, customer.Email, customer.StripeCustomerID, customer.CreatedAt, customer.UpdatedAt)
-	return err
-}
-
-// CreateTestSubscription creates a test subscription in the database
-func (td *TestDatabase) CreateTestSubscription(subscription *Subscription) error {
-	_, err := td.Conn.Exec(td.ctx, `
-		INSERT INTO subscriptions (
-			project_id, customer_id, user_id, product_id, price_id,
-			stripe_subscription_id, status, current_period_start, current_period_end,
-			created_at, updated_at
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
-		)
-		ON CONFLICT (project_id, user_id, product_id) DO UPDATE SET
-			stripe_subscription_id = EXCLUDED.stripe_subscription_id,
-			customer_id = EXCLUDED.customer_id,
-			status = EXCLUDED.status,
-			current_period_start = EXCLUDED.current_period_start,
-			current_period_end = EXCLUDED.current_period_end,
-			updated_at = EXCLUDED.updated_at
-	`, subscription.ProjectID, subscription.CustomerID, subscription.UserID, subscription.ProductID, subscription.PriceID,
-		subscription.StripeSubscriptionID, subscription.Status, subscription.CurrentPeriodStart, subscription.CurrentPeriodEnd,
-		subscription.CreatedAt, subscription.UpdatedAt)
-	return err
-}
-
-// CreateTestData creates test customers and subscriptions
-func (td *TestDatabase) CreateTestData() (*Project, *Customer, error) {
-	// Use timestamp and UUID to ensure uniqueness across parallel test runs
-	timestamp := time.Now().UnixNano()
-	uniqueSuffix := uuid.New().String()[:8]
-
-	// Create test project
-	projectID := uuid.New()
-	project := &Project{
-		ID:        projectID,
-		Name:      fmt.Sprintf("Test Project %d", timestamp),
-		APIKey:    fmt.Sprintf("sk_test_%d_%s", timestamp, uniqueSuffix),
-		IsActive:  true,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-	if err := td.CreateTestProject(project); err != nil {
-		return nil, nil, fmt.Errorf("failed to create test project: %w", err)
-	}
-
-	// Create test customer
-	customerID := uuid.New()
-	customer := &Customer{
-		ID:               customerID,
-		ProjectID:        projectID,
-		UserID:           fmt.Sprintf("test_user_%d_%s", timestamp, uniqueSuffix),
-		Email:            fmt.Sprintf("test_%d_%s@example.com", timestamp, uniqueSuffix),
-		StripeCustomerID: config.TestCustomerID, // Use REAL test customer from Stripe
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
-	}
-
-	if err := td.CreateTestCustomer(customer); err != nil {
-		return nil, nil, fmt.Errorf("failed to create test customer: %w", err)
-	}
-
-	// Create test subscription
-	subscription := &Subscription{
-		ProjectID:            projectID,
-		CustomerID:           customerID,
-		UserID:               customer.UserID,
-		StripeSubscriptionID: fmt.Sprintf("sub_test_%d_%s", timestamp, uniqueSuffix),
-		ProductID:            "premium_plan",
-		PriceID:              "price_123",
-		Status:               "active",
-		CurrentPeriodStart:   time.Now(),
-		CurrentPeriodEnd:     time.Now().Add(30 * 24 * time.Hour),
-		CreatedAt:            time.Now(),
-		UpdatedAt:            time.Now(),
-	}
-
-	if err := td.CreateTestSubscription(subscription); err != nil {
-		return nil, nil, fmt.Errorf("failed to create test subscription: %w", err)
-	}
-
-	return project, customer, nil
-}
@@ -5,7 +5,7 @@ import (
 	"os"
 	"testing"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/DraconDev/go-stripe-ms/internal/config"
 )
 
 // WithTestDatabase runs a test with a real database
@@ -43,15 +43,38 @@ func WithRealDatabase(t *testing.T, testFunc func(*testing.T, *Repository)) {
 	ctx := context.Background()
 
 	// Connect to real database
-	conn, err := pgx.Connect(ctx, dbURL)
+	poolConfig, err := config.LoadDatabasePool().PoolConfig(dbURL)
+	if err != nil {
+		t.Fatalf("Invalid real database configuration: %v", err)
+	}
+	pool, err := NewPool(ctx, poolConfig)
 	if err != nil {
 		t.Fatalf("Failed to connect to real database: %v", err)
 	}
-	defer conn.Close(ctx)
+	defer pool.Close()
 
 	// Create repository
-	repo := NewRepository(conn)
+	repo := NewRepository(pool)
 
 	// Run test
 	testFunc(t, repo)
 }
+
+// WithTestRepository runs a test with a real database when DATABASE_URL is set,
+// and with an in-memory repository otherwise
+func WithTestRepository(t *testing.T, testFunc func(*testing.T, *TestDatabase)) {
+	t.Helper()
+
+	// Ensure environment is loaded
+	autoLoadEnv()
+
+	if os.Getenv("DATABASE_URL") != "" {
+		WithTestDatabase(t, testFunc)
+		return
+	}
+
+	testDB := NewMemoryTestDatabase(t)
+	defer testDB.Cleanup(t)
+
+	testFunc(t, testDB)
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+)
+
+// catalogIsolatedPerProject checks registered products are scoped to their project
+func (s *conformanceSuite) catalogIsolatedPerProject(t *testing.T) {
+	ctx, repo, project := s.ctx, s.repo, s.project
+	other, err := repo.CreateProject(ctx, "Conformance Other Project", "")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	plan := func(projectID uuid.UUID, planName, stripeProductID string) *database.RegisteredProduct {
+		return &database.RegisteredProduct{
+			ProjectID:       projectID,
+			PlanName:        planName,
+			StripeProductID: stripeProductID,
+			MonthlyAmount:   2900,
+			Currency:        "usd",
+			Features:        []byte("{}"),
+		}
+	}
+
+	// Plan names are unique per project, not across projects
+	if err := repo.CreateRegisteredProduct(ctx, plan(project.ID, "Pro", "prod_conformance_a")); err != nil {
+		t.Fatalf("Failed to register plan: %v", err)
+	}
+	if err := repo.CreateRegisteredProduct(ctx, plan(other.ID, "Pro", "prod_conformance_b")); err != nil {
+		t.Fatalf("Expected another project to register the same plan name, got %v", err)
+	}
+	assertUniqueViolation(t, repo.CreateRegisteredProduct(ctx, plan(project.ID, "Pro", "prod_conformance_a2")))
+
+	plans, err := repo.GetRegisteredProductsByProject(ctx, other.ID)
+	if err != nil {
+		t.Fatalf("Failed to list plans: %v", err)
+	}
+	if len(plans) != 1 || plans[0].StripeProductID != "prod_conformance_b" || plans[0].ProjectID != other.ID {
+		t.Errorf("Expected only the other project's plan, got %+v", plans)
+	}
+	exists, _, err := repo.ProductExistsForProject(ctx, other.ID, "Enterprise")
+	if err != nil || exists {
+		t.Errorf("Expected no Enterprise plan in the other project, got %v (%v)", exists, err)
+	}
+	if err := repo.CreateRegisteredProduct(ctx, plan(project.ID, "Enterprise", "prod_conformance_enterprise")); err != nil {
+		t.Fatalf("Failed to register plan: %v", err)
+	}
+	exists, _, err = repo.ProductExistsForProject(ctx, other.ID, "Enterprise")
+	if err != nil || exists {
+		t.Errorf("Expected another project's Enterprise plan to stay invisible, got %v (%v)", exists, err)
+	}
+
+	// Another project cannot overwrite a plan through its Stripe product
+	hijack := plan(other.ID, "Hijacked", "prod_conformance_a")
+	if err := repo.CreateRegisteredProduct(ctx, hijack); !errors.Is(err, database.ErrConflict) {
+		t.Errorf("Expected ErrConflict registering another project's product, got %v", err)
+	}
+	applied, err := repo.SyncRegisteredProduct(ctx, hijack, time.Now())
+	if err != nil || applied {
+		t.Errorf("Expected syncing another project's product to be skipped, got %v (%v)", applied, err)
+	}
+	stored, err := repo.GetRegisteredProductByStripeID(ctx, "prod_conformance_a")
+	if err != nil {
+		t.Fatalf("Failed to get plan: %v", err)
+	}
+	if stored.ProjectID != project.ID || stored.PlanName != "Pro" {
+		t.Errorf("Expected the plan to stay Pro of project %s, got '%s' of %s", project.ID, stored.PlanName, stored.ProjectID)
+	}
+}
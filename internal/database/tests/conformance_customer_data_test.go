@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+)
+
+// customerErasureAndExport checks erasing a customer redacts its data everywhere and exporting collects it
+func (s *conformanceSuite) customerErasureAndExport(t *testing.T) {
+	ctx, repo, now, project, otherProject := s.ctx, s.repo, s.now, s.project, s.otherProject
+	const userID = "conformance_user_erased"
+	customerID := s.customerOf(t, project.ID, userID)
+	customerUUID := uuid.MustParse(customerID)
+	if err := repo.UpdateCustomerStripeID(ctx, project.ID, userID, "cus_conformance_erased"); err != nil {
+		t.Fatalf("Failed to set Stripe customer ID: %v", err)
+	}
+	if _, err := repo.UpdateCustomerDetails(ctx, customerUUID, "", "Erased User", "pm_conformance", now); err != nil {
+		t.Fatalf("Failed to update customer details: %v", err)
+	}
+	if _, err := repo.CreateSubscription(ctx, project.ID, customerID, "sub_conformance_erased", "prod_conformance",
+		"price_conformance", userID, "active", now, now.Add(30*24*time.Hour), now); err != nil {
+		t.Fatalf("Failed to create subscription: %v", err)
+	}
+	if _, err := repo.RecordSubscriptionEvent(ctx, "sub_conformance_erased", "evt_conformance_erased_sub", "customer.subscription.created", now); err != nil {
+		t.Fatalf("Failed to record subscription history: %v", err)
+	}
+	if _, err := repo.UpsertInvoice(ctx, &database.Invoice{
+		ProjectID: project.ID, CustomerID: customerUUID, UserID: userID, StripeInvoiceID: "in_conformance_erased",
+		Status: "paid", Currency: "usd", HostedInvoiceURL: "https://invoice.stripe.com/i/erased",
+		PeriodStart: now, PeriodEnd: now.Add(30 * 24 * time.Hour), LastEventAt: now,
+	}); err != nil {
+		t.Fatalf("Failed to create invoice: %v", err)
+	}
+	if err := repo.CreateOrder(ctx, &database.Order{
+		ProjectID: project.ID, CustomerID: customerUUID, UserID: userID, StripeCheckoutSessionID: "cs_conformance_erased",
+		PaymentType: "item", PaymentStatus: "paid", AmountTotal: 500, Currency: "usd",
+	}); err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+	if err := repo.RecordWebhookDelivery(ctx, &database.WebhookDelivery{
+		ProjectID: project.ID, EventID: uuid.New(), EventType: "customer.updated", URL: "https://example.com/hook",
+		Payload: []byte(`{"type": "customer.updated", "data": {"user_id": "` + userID + `", "email": "erased@example.com"}}`),
+		Attempt: 1, Status: "succeeded",
+	}); err != nil {
+		t.Fatalf("Failed to record delivery: %v", err)
+	}
+	// An event about the Stripe customer in each status, and the status erasure leaves it in
+	if err := repo.EnqueueNotification(ctx, &database.PendingNotification{
+		ID: uuid.New(), ProjectID: project.ID, EventType: "customer.updated",
+		Payload: []byte(`{"type": "customer.updated", "data": {"user_id": "` + userID + `", "email": "erased@example.com"}}`),
+	}); err != nil {
+		t.Fatalf("Failed to enqueue notification: %v", err)
+	}
+	customerEventPayload := `{"id": "%s", "type": "customer.updated", "data": {"object": {"id": "cus_conformance_erased", "object": "customer", "email": "erased@example.com"}}}`
+	erasedEvents := map[string]string{
+		database.EventStatusPending:    database.EventStatusIgnored,
+		database.EventStatusProcessing: database.EventStatusProcessing,
+		database.EventStatusProcessed:  database.EventStatusProcessed,
+		database.EventStatusFailed:     database.EventStatusIgnored,
+		database.EventStatusDeadLetter: database.EventStatusIgnored,
+		database.EventStatusIgnored:    database.EventStatusIgnored,
+	}
+	for status := range erasedEvents {
+		eventID := "evt_conformance_erased_" + status
+		if _, err := repo.EnqueueStripeEvent(ctx, &database.StripeEvent{
+			ID: eventID, Type: "customer.updated", Created: now, Payload: []byte(fmt.Sprintf(customerEventPayload, eventID)),
+		}); err != nil {
+			t.Fatalf("Failed to enqueue event: %v", err)
+		}
+
+		var err error
+		switch status {
+		case database.EventStatusProcessing:
+			_, err = repo.ClaimStripeEvent(ctx, eventID, time.Minute)
+		case database.EventStatusProcessed:
+			err = repo.MarkStripeEventProcessed(ctx, eventID)
+		case database.EventStatusFailed:
+			err = repo.MarkStripeEventFailed(ctx, eventID, "failed", now.Add(time.Minute))
+		case database.EventStatusDeadLetter:
+			err = repo.MarkStripeEventDeadLetter(ctx, eventID, "failed")
+		case database.EventStatusIgnored:
+			_, err = repo.MarkStripeEventIgnored(ctx, eventID)
+		}
+		if err != nil {
+			t.Fatalf("Failed to move event to %s: %v", status, err)
+		}
+	}
+
+	export, err := repo.ExportCustomerData(ctx, project.ID, userID)
+	if err != nil {
+		t.Fatalf("Failed to export customer data: %v", err)
+	}
+	if export.Customer.Name != "Erased User" || len(export.Subscriptions) != 1 || len(export.SubscriptionHistory) != 1 ||
+		len(export.Invoices) != 1 || len(export.Orders) != 1 || len(export.Refunds) != 0 {
+		t.Errorf("Expected the export to hold every record of the user, got %+v", export)
+	}
+	if _, err := repo.ExportCustomerData(ctx, otherProject.ID, userID); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound exporting the user from another project, got %v", err)
+	}
+
+	if err := repo.EraseCustomer(ctx, customerUUID); err != nil {
+		t.Fatalf("Failed to erase customer: %v", err)
+	}
+	if err := repo.EraseCustomer(ctx, uuid.New()); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound erasing an unknown customer, got %v", err)
+	}
+	if _, err := repo.ExportCustomerData(ctx, project.ID, userID); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected the user ID to be gone after erasure, got %v", err)
+	}
+
+	erasedUserID := database.ErasedUserID(customerUUID)
+	erased, err := repo.ExportCustomerData(ctx, project.ID, erasedUserID)
+	if err != nil {
+		t.Fatalf("Failed to export erased customer: %v", err)
+	}
+	if c := erased.Customer; c.Email != "" || c.Name != "" || c.DefaultPaymentMethod != "" || c.ErasedAt == nil {
+		t.Errorf("Expected personal details to be cleared, got %+v", c)
+	}
+	if len(erased.Subscriptions) != 1 || erased.Subscriptions[0].UserID != erasedUserID ||
+		len(erased.SubscriptionHistory) != 1 || erased.SubscriptionHistory[0].UserID != erasedUserID ||
+		len(erased.Orders) != 1 || erased.Orders[0].UserID != erasedUserID {
+		t.Errorf("Expected the billing records to be kept under %s, got %+v", erasedUserID, erased)
+	}
+	if len(erased.Invoices) != 1 || erased.Invoices[0].UserID != erasedUserID || erased.Invoices[0].HostedInvoiceURL != "" {
+		t.Errorf("Expected the invoice to be kept without its hosted link, got %+v", erased.Invoices)
+	}
+
+	// Stripe events no longer write personal details into an erased customer
+	if applied, err := repo.UpdateCustomerDetails(ctx, customerUUID, "back@example.com", "Back", "", now.Add(time.Hour)); err != nil || applied {
+		t.Errorf("Expected details of an erased customer to be left alone: applied=%v err=%v", applied, err)
+	}
+
+	deliveries, err := repo.ListWebhookDeliveries(ctx, project.ID, 100)
+	if err != nil {
+		t.Fatalf("Failed to list deliveries: %v", err)
+	}
+	for _, delivery := range deliveries {
+		if strings.Contains(string(delivery.Payload), userID) {
+			t.Errorf("Expected notifications about the user to be removed, got %s", delivery.Payload)
+		}
+	}
+	pendingNotifications, err := repo.ClaimNotifications(ctx, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to claim notifications: %v", err)
+	}
+	for _, notification := range pendingNotifications {
+		if strings.Contains(string(notification.Payload), userID) {
+			t.Errorf("Expected pending notifications about the user to be removed, got %s", notification.Payload)
+		}
+	}
+
+	// Events not applied yet are ignored, as their redacted bodies can no longer be applied
+	for status, expectedStatus := range erasedEvents {
+		event, err := repo.GetStripeEvent(ctx, "evt_conformance_erased_"+status)
+		if err != nil {
+			t.Fatalf("Failed to get event: %v", err)
+		}
+		if strings.Contains(string(event.Payload), "erased@example.com") || !strings.Contains(string(event.Payload), "cus_conformance_erased") {
+			t.Errorf("Expected the %s event body to be redacted down to the object ID, got %s", status, event.Payload)
+		}
+		if event.Status != expectedStatus {
+			t.Errorf("Expected the %s event to be %s after erasure, got %s", status, expectedStatus, event.Status)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// projects checks projects are found by API key and unknown ones report ErrNotFound
+func (s *conformanceSuite) projects(t *testing.T) {
+	ctx, repo, project := s.ctx, s.repo, s.project
+	found, err := repo.GetProjectByAPIKey(ctx, project.APIKey)
+	if err != nil {
+		t.Fatalf("Failed to get project by API key: %v", err)
+	}
+	if found.ID != project.ID || found.Name != project.Name {
+		t.Errorf("Expected project %s, got %+v", project.ID, found)
+	}
+
+	_, err = repo.GetProjectByID(ctx, uuid.New())
+	if !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown project, got %v", err)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("Expected ErrNotFound to wrap pgx.ErrNoRows, got %v", err)
+	}
+	if err := repo.UpdateProjectDunningSettings(ctx, uuid.New(), 3, 2); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound updating an unknown project, got %v", err)
+	}
+}
+
+// customerUniquePerProjectAndUser checks a user has one customer per project
+func (s *conformanceSuite) customerUniquePerProjectAndUser(t *testing.T) {
+	ctx, repo, project, otherProject := s.ctx, s.repo, s.project, s.otherProject
+	first := s.customerOf(t, project.ID, "conformance_user_1")
+	second := s.customerOf(t, project.ID, "conformance_user_1")
+	if first != second {
+		t.Errorf("Expected one customer per project and user, got %s and %s", first, second)
+	}
+
+	// The same user ID in another project is another customer
+	if other := s.customerOf(t, otherProject.ID, "conformance_user_1"); other == first {
+		t.Errorf("Expected a separate customer in another project, got %s", other)
+	}
+
+	if err := repo.UpdateCustomerStripeID(ctx, project.ID, "conformance_user_1", "cus_conformance_1"); err != nil {
+		t.Fatalf("Failed to set Stripe customer ID: %v", err)
+	}
+	customer, err := repo.EnsureCustomer(ctx, project.ID, "conformance_user_1", "changed@example.com")
+	if err != nil {
+		t.Fatalf("Failed to ensure customer: %v", err)
+	}
+	if customer.StripeCustomerID != "cus_conformance_1" || customer.Email != "conformance_user_1@example.com" {
+		t.Errorf("Expected the stored customer to be left alone, got %+v", customer)
+	}
+
+	// Stripe customer IDs are unique across customers
+	err = repo.UpdateCustomerStripeID(ctx, otherProject.ID, "conformance_user_1", "cus_conformance_1")
+	assertUniqueViolation(t, err)
+
+	if _, err := repo.GetCustomerByStripeID(ctx, "cus_conformance_missing"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown Stripe customer, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+)
+
+// ledgerIsolatedPerProject checks invoices, refunds and disputes are scoped to their project
+func (s *conformanceSuite) ledgerIsolatedPerProject(t *testing.T) {
+	ctx, repo, now, project, otherProject := s.ctx, s.repo, s.now, s.project, s.otherProject
+	ownerID := uuid.MustParse(s.customerOf(t, project.ID, "conformance_user_ledger"))
+	intruderID := uuid.MustParse(s.customerOf(t, otherProject.ID, "conformance_user_intruder"))
+	invoice := func(projectID, customerID uuid.UUID, status string, eventAt time.Time) *database.Invoice {
+		return &database.Invoice{
+			ProjectID: projectID, CustomerID: customerID, UserID: "conformance_user_ledger", StripeInvoiceID: "in_conformance_ledger",
+			Status: status, Currency: "usd", PeriodStart: now, PeriodEnd: now.Add(30 * 24 * time.Hour), LastEventAt: eventAt,
+		}
+	}
+	refund := func(projectID, customerID uuid.UUID, status string) *database.Refund {
+		return &database.Refund{
+			ProjectID: projectID, CustomerID: customerID, UserID: "conformance_user_ledger", StripeRefundID: "re_conformance_ledger",
+			StripeChargeID: "ch_conformance_ledger", Amount: 500, Currency: "usd", Status: status,
+		}
+	}
+	dispute := func(projectID, customerID uuid.UUID, status string, eventAt time.Time) *database.Dispute {
+		return &database.Dispute{
+			ProjectID: projectID, CustomerID: customerID, UserID: "conformance_user_ledger", StripeDisputeID: "dp_conformance_ledger",
+			StripeChargeID: "ch_conformance_ledger", Amount: 500, Currency: "usd", Status: status, LastEventAt: eventAt,
+		}
+	}
+
+	if _, err := repo.UpsertInvoice(ctx, invoice(project.ID, ownerID, "paid", now)); err != nil {
+		t.Fatalf("Failed to create invoice: %v", err)
+	}
+	if err := repo.UpsertRefund(ctx, refund(project.ID, ownerID, "succeeded")); err != nil {
+		t.Fatalf("Failed to create refund: %v", err)
+	}
+	if _, err := repo.UpsertDispute(ctx, dispute(project.ID, ownerID, "won", now)); err != nil {
+		t.Fatalf("Failed to create dispute: %v", err)
+	}
+
+	// Another project cannot overwrite them through their Stripe IDs, even with newer events
+	later := now.Add(time.Minute)
+	if applied, err := repo.UpsertInvoice(ctx, invoice(otherProject.ID, intruderID, "void", later)); err != nil || applied {
+		t.Errorf("Expected writing another project's invoice to be skipped, got %v (%v)", applied, err)
+	}
+	if err := repo.UpsertRefund(ctx, refund(otherProject.ID, intruderID, "canceled")); err != nil {
+		t.Errorf("Failed to upsert refund: %v", err)
+	}
+	if applied, err := repo.UpsertDispute(ctx, dispute(otherProject.ID, intruderID, "lost", later)); err != nil || applied {
+		t.Errorf("Expected writing another project's dispute to be skipped, got %v (%v)", applied, err)
+	}
+
+	storedInvoice, err := repo.GetInvoiceByStripeID(ctx, "in_conformance_ledger")
+	if err != nil || storedInvoice.Status != "paid" || storedInvoice.ProjectID != project.ID {
+		t.Errorf("Expected the invoice to stay paid in project %s, got %+v (%v)", project.ID, storedInvoice, err)
+	}
+	storedRefund, err := repo.GetRefundByStripeID(ctx, "re_conformance_ledger")
+	if err != nil || storedRefund.Status != "succeeded" || storedRefund.ProjectID != project.ID {
+		t.Errorf("Expected the refund to stay succeeded in project %s, got %+v (%v)", project.ID, storedRefund, err)
+	}
+	storedDispute, err := repo.GetDisputeByStripeID(ctx, "dp_conformance_ledger")
+	if err != nil || storedDispute.Status != "won" || storedDispute.ProjectID != project.ID {
+		t.Errorf("Expected the dispute to stay won in project %s, got %+v (%v)", project.ID, storedDispute, err)
+	}
+}
+
+// orderRefundsOnlyGrow checks a late refund event never lowers an order's refund
+func (s *conformanceSuite) orderRefundsOnlyGrow(t *testing.T) {
+	ctx, repo, now, project := s.ctx, s.repo, s.now, s.project
+	const userID = "conformance_user_refunded"
+	customerID := s.customerOf(t, project.ID, userID)
+	order := &database.Order{
+		ProjectID: project.ID, CustomerID: uuid.MustParse(customerID), UserID: userID, StripeCheckoutSessionID: "cs_conformance_refunded",
+		PaymentType: "item", PaymentStatus: "paid", AmountTotal: 3000, Currency: "usd",
+	}
+	if err := repo.CreateOrder(ctx, order); err != nil {
+		t.Fatalf("Failed to create order: %v", err)
+	}
+
+	refundedAt := now
+	if err := repo.MarkOrderRefunded(ctx, order.ID, 3000, &refundedAt); err != nil {
+		t.Fatalf("Failed to mark order refunded: %v", err)
+	}
+	// A late event from before the full refund lowers neither the amount nor the refund time
+	if err := repo.MarkOrderRefunded(ctx, order.ID, 1000, nil); err != nil {
+		t.Fatalf("Failed to apply late refund: %v", err)
+	}
+
+	orders, err := repo.GetOrdersByUserID(ctx, project.ID, userID)
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("Failed to get order: %d orders err=%v", len(orders), err)
+	}
+	if got := orders[0]; got.AmountRefunded != 3000 || got.RefundedAt == nil || got.Entitled {
+		t.Errorf("Expected the order to stay fully refunded, got refunded=%d at=%v entitled=%v", got.AmountRefunded, got.RefundedAt, got.Entitled)
+	}
+}
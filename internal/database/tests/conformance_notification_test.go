@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+)
+
+// pendingNotifications checks the claim, reschedule and delete cycle of pending notifications
+func (s *conformanceSuite) pendingNotifications(t *testing.T) {
+	ctx, repo, now, project := s.ctx, s.repo, s.now, s.project
+	notificationID := uuid.New()
+	notification := &database.PendingNotification{
+		ID: notificationID, ProjectID: project.ID, EventType: "order.completed", Payload: []byte(`{"type": "order.completed"}`),
+	}
+	for i := 0; i < 2; i++ {
+		if err := repo.EnqueueNotification(ctx, notification); err != nil {
+			t.Fatalf("Failed to enqueue notification: %v", err)
+		}
+	}
+
+	claimed, err := repo.ClaimNotifications(ctx, 10, time.Minute)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != notificationID || claimed[0].Attempts != 1 {
+		t.Fatalf("Expected the notification to be claimed once for its first attempt, got %+v err=%v", claimed, err)
+	}
+	if again, err := repo.ClaimNotifications(ctx, 10, time.Minute); err != nil || len(again) != 0 {
+		t.Errorf("Expected a claimed notification to stay locked, got %d err=%v", len(again), err)
+	}
+
+	if err := repo.RescheduleNotification(ctx, notificationID, "unexpected status code 503", now.Add(-time.Second)); err != nil {
+		t.Fatalf("Failed to reschedule notification: %v", err)
+	}
+	claimed, err = repo.ClaimNotifications(ctx, 10, time.Minute)
+	if err != nil || len(claimed) != 1 || claimed[0].Attempts != 2 || claimed[0].LastError != "unexpected status code 503" {
+		t.Fatalf("Expected the rescheduled notification to be claimed for its second attempt, got %+v err=%v", claimed, err)
+	}
+
+	if err := repo.DeleteNotification(ctx, notificationID); err != nil {
+		t.Fatalf("Failed to delete notification: %v", err)
+	}
+	if err := repo.RescheduleNotification(ctx, notificationID, "", now.Add(-time.Second)); err != nil {
+		t.Fatalf("Failed to reschedule deleted notification: %v", err)
+	}
+	if left, err := repo.ClaimNotifications(ctx, 10, time.Minute); err != nil || len(left) != 0 {
+		t.Errorf("Expected a deleted notification to be gone, got %d err=%v", len(left), err)
+	}
+}
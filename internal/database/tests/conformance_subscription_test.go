@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+)
+
+// subscriptionUniquePerProjectUserAndProduct checks plan changes and one subscription per user and product
+func (s *conformanceSuite) subscriptionUniquePerProjectUserAndProduct(t *testing.T) {
+	ctx, repo, now, project := s.ctx, s.repo, s.now, s.project
+	customerID := s.customerOf(t, project.ID, "conformance_user_2")
+	periodEnd := now.Add(30 * 24 * time.Hour)
+
+	if applied, err := repo.CreateSubscription(ctx, project.ID, customerID, "sub_conformance_1", "prod_conformance",
+		"price_conformance", "conformance_user_2", "active", now, periodEnd, now); err != nil || !applied {
+		t.Fatalf("Failed to create subscription: applied=%v err=%v", applied, err)
+	}
+
+	// A second subscription to the same product replaces the first
+	if applied, err := repo.CreateSubscription(ctx, project.ID, customerID, "sub_conformance_2", "prod_conformance",
+		"price_conformance", "conformance_user_2", "active", now, periodEnd, now.Add(time.Second)); err != nil || !applied {
+		t.Fatalf("Failed to replace subscription: applied=%v err=%v", applied, err)
+	}
+	if _, err := repo.GetSubscriptionByStripeID(ctx, "sub_conformance_1"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected the replaced subscription to be gone, got %v", err)
+	}
+	stripeSubID, _, _, exists, err := repo.GetSubscriptionStatus(ctx, project.ID, "conformance_user_2", "prod_conformance")
+	if err != nil || !exists || stripeSubID != "sub_conformance_2" {
+		t.Errorf("Expected sub_conformance_2, got %q exists=%v err=%v", stripeSubID, exists, err)
+	}
+
+	// Another product is another subscription
+	if _, err := repo.CreateSubscription(ctx, project.ID, customerID, "sub_conformance_3", "prod_conformance_other",
+		"price_conformance", "conformance_user_2", "active", now, periodEnd, now); err != nil {
+		t.Fatalf("Failed to create subscription to another product: %v", err)
+	}
+	if _, err := repo.GetSubscriptionByStripeID(ctx, "sub_conformance_2"); err != nil {
+		t.Errorf("Expected sub_conformance_2 to be kept, got %v", err)
+	}
+
+	// A plan change moves the subscription to the new product and price
+	if applied, err := repo.CreateSubscription(ctx, project.ID, customerID, "sub_conformance_2", "prod_conformance_third",
+		"price_conformance_third", "conformance_user_2", "active", now, periodEnd, now.Add(2*time.Second)); err != nil || !applied {
+		t.Fatalf("Failed to change plan: applied=%v err=%v", applied, err)
+	}
+	sub, err := repo.GetSubscriptionByStripeID(ctx, "sub_conformance_2")
+	if err != nil {
+		t.Fatalf("Failed to get subscription: %v", err)
+	}
+	if sub.ProductID != "prod_conformance_third" || sub.PriceID != "price_conformance_third" {
+		t.Errorf("Expected the new product and price, got %s and %s", sub.ProductID, sub.PriceID)
+	}
+
+	// A stale plan change is skipped, even to a product without a subscription
+	if applied, err := repo.CreateSubscription(ctx, project.ID, customerID, "sub_conformance_2", "prod_conformance_fourth",
+		"price_conformance", "conformance_user_2", "active", now, periodEnd, now.Add(time.Second)); err != nil || applied {
+		t.Errorf("Expected an older plan change to be skipped: applied=%v err=%v", applied, err)
+	}
+
+	// Subscriptions stay unique per project, user and product
+	_, err = repo.CreateSubscription(ctx, project.ID, customerID, "sub_conformance_2", "prod_conformance_other",
+		"price_conformance", "conformance_user_2", "active", now, periodEnd, now.Add(3*time.Second))
+	assertUniqueViolation(t, err)
+
+	if _, _, _, _, err := repo.GetSubscriptionStatus(ctx, project.ID, "conformance_user_2", "prod_unknown"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown product, got %v", err)
+	}
+}
+
+// staleSubscriptionEvents checks events older than the stored state are ignored
+func (s *conformanceSuite) staleSubscriptionEvents(t *testing.T) {
+	ctx, repo, now, project := s.ctx, s.repo, s.now, s.project
+	customerID := s.customerOf(t, project.ID, "conformance_user_3")
+	periodEnd := now.Add(30 * 24 * time.Hour)
+
+	if _, err := repo.CreateSubscription(ctx, project.ID, customerID, "sub_conformance_stale", "prod_conformance",
+		"price_conformance", "conformance_user_3", "active", now, periodEnd, now); err != nil {
+		t.Fatalf("Failed to create subscription: %v", err)
+	}
+
+	if applied, err := repo.UpdateSubscriptionStatus(ctx, "sub_conformance_stale", "past_due", periodEnd, now.Add(-time.Minute)); err != nil || applied {
+		t.Errorf("Expected an older event to be skipped: applied=%v err=%v", applied, err)
+	}
+	if applied, err := repo.UpdateSubscriptionStatus(ctx, "sub_conformance_stale", "canceled", periodEnd, now.Add(time.Minute)); err != nil || !applied {
+		t.Errorf("Expected a newer event to be applied: applied=%v err=%v", applied, err)
+	}
+	// A terminal subscription is not revived by a later non-terminal event
+	if applied, err := repo.UpdateSubscriptionStatus(ctx, "sub_conformance_stale", "active", periodEnd, now.Add(2*time.Minute)); err != nil || applied {
+		t.Errorf("Expected a canceled subscription to stay canceled: applied=%v err=%v", applied, err)
+	}
+	if applied, err := repo.UpdateSubscriptionStatus(ctx, "sub_conformance_unknown", "active", periodEnd, now); err != nil || applied {
+		t.Errorf("Expected an unknown subscription to be left alone: applied=%v err=%v", applied, err)
+	}
+
+	sub, err := repo.GetSubscriptionByStripeID(ctx, "sub_conformance_stale")
+	if err != nil {
+		t.Fatalf("Failed to get subscription: %v", err)
+	}
+	if sub.Status != "canceled" {
+		t.Errorf("Expected status canceled, got %s", sub.Status)
+	}
+}
+
+// subscriptionHistory checks the status history recorded for a subscription
+func (s *conformanceSuite) subscriptionHistory(t *testing.T) {
+	ctx, repo, now, project, otherProject := s.ctx, s.repo, s.now, s.project, s.otherProject
+	customerID := s.customerOf(t, project.ID, "conformance_user_history")
+	periodEnd := now.Add(30 * 24 * time.Hour)
+	record := func(t *testing.T, eventID string, want bool) {
+		t.Helper()
+		recorded, err := repo.RecordSubscriptionEvent(ctx, "sub_conformance_history", eventID, "customer.subscription.updated", now)
+		if err != nil || recorded != want {
+			t.Errorf("Expected %s to be recorded=%v: recorded=%v err=%v", eventID, want, recorded, err)
+		}
+	}
+
+	if _, err := repo.CreateSubscription(ctx, project.ID, customerID, "sub_conformance_history", "prod_conformance_history",
+		"price_conformance", "conformance_user_history", "active", now, periodEnd, now); err != nil {
+		t.Fatalf("Failed to create subscription: %v", err)
+	}
+	record(t, "evt_history_1", true)
+	// An event that changed neither the status nor the period adds no entry
+	record(t, "evt_history_2", false)
+
+	if _, err := repo.UpdateSubscriptionStatus(ctx, "sub_conformance_history", "past_due", periodEnd, now.Add(time.Minute)); err != nil {
+		t.Fatalf("Failed to update subscription: %v", err)
+	}
+	record(t, "evt_history_3", true)
+	renewedEnd := periodEnd.Add(30 * 24 * time.Hour)
+	if _, err := repo.UpdateSubscriptionStatus(ctx, "sub_conformance_history", "past_due", renewedEnd, now.Add(2*time.Minute)); err != nil {
+		t.Fatalf("Failed to update subscription: %v", err)
+	}
+	record(t, "evt_history_4", true)
+
+	if recorded, err := repo.RecordSubscriptionEvent(ctx, "sub_conformance_unknown", "evt_history_5", "customer.subscription.updated", now); err != nil || recorded {
+		t.Errorf("Expected an unknown subscription to add no entry: recorded=%v err=%v", recorded, err)
+	}
+
+	events, err := repo.ListSubscriptionEvents(ctx, project.ID, "conformance_user_history", "prod_conformance_history", 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to list subscription history: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 history entries, got %d", len(events))
+	}
+	latest, first := events[0], events[2]
+	if latest.SourceEventID != "evt_history_4" || latest.Status != "past_due" || latest.PreviousStatus != "past_due" ||
+		!latest.CurrentPeriodEnd.Equal(renewedEnd) || latest.PreviousPeriodEnd == nil || !latest.PreviousPeriodEnd.Equal(periodEnd) {
+		t.Errorf("Expected the renewal to be the newest entry, got %+v", latest)
+	}
+	if events[1].SourceEventID != "evt_history_3" || events[1].PreviousStatus != "active" || events[1].Status != "past_due" {
+		t.Errorf("Expected the change to past_due in the middle, got %+v", events[1])
+	}
+	if first.SourceEventID != "evt_history_1" || first.PreviousStatus != "" || first.PreviousPeriodEnd != nil ||
+		first.StripeSubscriptionID != "sub_conformance_history" || first.EventType != "customer.subscription.updated" {
+		t.Errorf("Expected the first entry to have no previous state, got %+v", first)
+	}
+
+	paged, err := repo.ListSubscriptionEvents(ctx, project.ID, "conformance_user_history", "prod_conformance_history", 1, 1)
+	if err != nil || len(paged) != 1 || paged[0].SourceEventID != "evt_history_3" {
+		t.Errorf("Expected the second entry on a page of one at offset one, got %v err=%v", paged, err)
+	}
+	if others, err := repo.ListSubscriptionEvents(ctx, otherProject.ID, "conformance_user_history", "prod_conformance_history", 10, 0); err != nil || len(others) != 0 {
+		t.Errorf("Expected no history in another project, got %d entries err=%v", len(others), err)
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TestPostgresRepositoryConformance runs the shared repository suite against Postgres
+func TestPostgresRepositoryConformance(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		testRepositoryConformance(t, testDB.Repo)
+	})
+}
+
+// TestMemoryRepositoryConformance runs the shared repository suite against the in-memory repository
+func TestMemoryRepositoryConformance(t *testing.T) {
+	testRepositoryConformance(t, database.NewMemoryRepository())
+}
+
+// conformanceSuite holds the repository under test and the projects its checks share.
+// The checks live in conformance_*_test.go, one file per domain.
+type conformanceSuite struct {
+	ctx          context.Context
+	repo         database.RepositoryInterface
+	now          time.Time
+	project      *database.Project
+	otherProject *database.Project
+}
+
+// testRepositoryConformance checks the behavior every RepositoryInterface implementation must share
+func testRepositoryConformance(t *testing.T, repo database.RepositoryInterface) {
+	s := &conformanceSuite{ctx: context.Background(), repo: repo, now: time.Now().Truncate(time.Second)}
+
+	var err error
+	s.project, err = repo.CreateProject(s.ctx, "Conformance Project", "")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	s.otherProject, err = repo.CreateProject(s.ctx, "Conformance Other Project", "")
+	if err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+
+	t.Run("Projects", s.projects)
+	t.Run("CustomerUniquePerProjectAndUser", s.customerUniquePerProjectAndUser)
+	t.Run("SubscriptionUniquePerProjectUserAndProduct", s.subscriptionUniquePerProjectUserAndProduct)
+	t.Run("StaleSubscriptionEvents", s.staleSubscriptionEvents)
+	t.Run("SubscriptionHistory", s.subscriptionHistory)
+	t.Run("CustomerErasureAndExport", s.customerErasureAndExport)
+	t.Run("CatalogIsolatedPerProject", s.catalogIsolatedPerProject)
+	t.Run("LedgerIsolatedPerProject", s.ledgerIsolatedPerProject)
+	t.Run("PendingNotifications", s.pendingNotifications)
+	t.Run("OrderRefundsOnlyGrow", s.orderRefundsOnlyGrow)
+	t.Run("WithTxRollback", s.withTxRollback)
+	t.Run("ConcurrentUpserts", s.concurrentUpserts)
+}
+
+// customerOf creates the customer of a project's user and returns its stored ID
+func (s *conformanceSuite) customerOf(t *testing.T, projectID uuid.UUID, userID string) string {
+	t.Helper()
+	customer, err := s.repo.EnsureCustomer(s.ctx, projectID, userID, userID+"@example.com")
+	if err != nil {
+		t.Fatalf("Failed to create customer %s: %v", userID, err)
+	}
+	return customer.ID.String()
+}
+
+// assertUniqueViolation fails the test unless err is an ErrConflict wrapping a Postgres unique violation
+func assertUniqueViolation(t *testing.T, err error) {
+	t.Helper()
+	if !errors.Is(err, database.ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		t.Errorf("Expected a unique violation, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+)
+
+// withTxRollback checks a failing unit of work is rolled back and a successful one committed
+func (s *conformanceSuite) withTxRollback(t *testing.T) {
+	ctx, repo, project := s.ctx, s.repo, s.project
+	errAbort := errors.New("abort")
+	err := repo.WithTx(ctx, func(tx database.RepositoryInterface) error {
+		if _, err := tx.EnsureCustomer(ctx, project.ID, "conformance_user_tx", "tx@example.com"); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Expected the unit of work's error, got %v", err)
+	}
+	if _, err := repo.GetCustomerByUserID(ctx, project.ID, "conformance_user_tx"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("Expected the rolled back customer to be gone, got %v", err)
+	}
+
+	err = repo.WithTx(ctx, func(tx database.RepositoryInterface) error {
+		if err := tx.LockCustomer(ctx, project.ID, "conformance_user_tx"); err != nil {
+			return err
+		}
+		_, err := tx.EnsureCustomer(ctx, project.ID, "conformance_user_tx", "tx@example.com")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to commit unit of work: %v", err)
+	}
+	if _, err := repo.GetCustomerByUserID(ctx, project.ID, "conformance_user_tx"); err != nil {
+		t.Errorf("Expected the committed customer, got %v", err)
+	}
+
+	// Transaction-scoped locks are refused outside a transaction
+	if err := repo.LockCustomer(ctx, project.ID, "conformance_user_tx"); !errors.Is(err, database.ErrLockOutsideTx) {
+		t.Errorf("Expected ErrLockOutsideTx, got %v", err)
+	}
+}
+
+// concurrentUpserts checks concurrent writes keep the unique constraints
+func (s *conformanceSuite) concurrentUpserts(t *testing.T) {
+	ctx, repo, now, project := s.ctx, s.repo, s.now, s.project
+	const workers = 8
+	customerID := s.customerOf(t, project.ID, "conformance_user_concurrent")
+	periodEnd := now.Add(30 * 24 * time.Hour)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers)
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			if _, err := repo.EnsureCustomer(ctx, project.ID, "conformance_user_racing", "racing@example.com"); err != nil {
+				errs <- err
+			}
+			if _, err := repo.CreateSubscription(ctx, project.ID, customerID, fmt.Sprintf("sub_conformance_racing_%d", worker),
+				"prod_conformance", "price_conformance", "conformance_user_concurrent", "active", now, periodEnd, now); err != nil {
+				errs <- err
+			}
+		}(worker)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Concurrent upsert failed: %v", err)
+	}
+
+	if _, err := repo.GetCustomerByUserID(ctx, project.ID, "conformance_user_racing"); err != nil {
+		t.Errorf("Expected the racing customer, got %v", err)
+	}
+
+	// Exactly one of the racing subscriptions is left
+	remaining := 0
+	for worker := 0; worker < workers; worker++ {
+		_, err := repo.GetSubscriptionByStripeID(ctx, fmt.Sprintf("sub_conformance_racing_%d", worker))
+		switch {
+		case err == nil:
+			remaining++
+		case !errors.Is(err, database.ErrNotFound):
+			t.Errorf("Failed to get subscription: %v", err)
+		}
+	}
+	if remaining != 1 {
+		t.Errorf("Expected one subscription per project, user and product, found %d", remaining)
+	}
+}
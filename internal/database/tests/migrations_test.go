@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/database/migrations"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := migrations.Load()
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(loaded) == 0 {
+		t.Fatal("Expected embedded migrations")
+	}
+	for i, migration := range loaded {
+		if migration.Version != int64(i+1) {
+			t.Errorf("Expected version %d at position %d, got %s", i+1, i, migration)
+		}
+		if migration.Checksum == "" {
+			t.Errorf("Expected a checksum for %s", migration)
+		}
+	}
+}
+
+func TestParseMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr string
+	}{
+		{
+			name: "missing down file",
+			files: fstest.MapFS{
+				"0001_init.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+			},
+			wantErr: "has no down file",
+		},
+		{
+			name: "version used twice",
+			files: fstest.MapFS{
+				"0001_init.up.sql":    {Data: []byte("CREATE TABLE a (id INT);")},
+				"0001_init.down.sql":  {Data: []byte("DROP TABLE a;")},
+				"0001_other.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+				"0001_other.down.sql": {Data: []byte("DROP TABLE b;")},
+			},
+			wantErr: "is used by both",
+		},
+		{
+			name: "invalid file name",
+			files: fstest.MapFS{
+				"init.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+			},
+			wantErr: "invalid migration file name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := migrations.Parse(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing '%s', got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	parsed, err := migrations.Parse(fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+		"0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id INT);")},
+		"0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse migrations: %v", err)
+	}
+	if len(parsed) != 2 || parsed[0].String() != "0001_first" || parsed[1].String() != "0002_second" {
+		t.Errorf("Expected migrations ordered by version, got %v", parsed)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+
+		// Setup already applied every migration
+		migrator, err := migrations.New(testDB.Pool)
+		if err != nil {
+			t.Fatalf("Failed to create migrator: %v", err)
+		}
+		if err := migrator.CheckCurrent(ctx); err != nil {
+			t.Fatalf("Expected schema to be current, got %v", err)
+		}
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			t.Fatalf("Failed to migrate: %v", err)
+		}
+		if len(applied) != 0 {
+			t.Errorf("Expected no pending migrations, applied %d", len(applied))
+		}
+
+		loaded, _ := migrations.Load()
+		last := loaded[len(loaded)-1]
+
+		redone, err := migrator.Redo(ctx)
+		if err != nil {
+			t.Fatalf("Failed to redo: %v", err)
+		}
+		if redone.Version != last.Version {
+			t.Errorf("Expected %s to be redone, got %s", last, redone)
+		}
+
+		// A dry run prints the rollback without running it
+		var out bytes.Buffer
+		migrator.SetDryRun(&out)
+		if _, err := migrator.Down(ctx, 1); err != nil {
+			t.Fatalf("Failed dry run rollback: %v", err)
+		}
+		migrator.SetDryRun(nil)
+		if !strings.Contains(out.String(), "-- down "+last.String()) {
+			t.Errorf("Expected dry run to print the rollback of %s, got %s", last, out.String())
+		}
+		if err := migrator.CheckCurrent(ctx); err != nil {
+			t.Errorf("Expected dry run to leave the schema current, got %v", err)
+		}
+
+		// An applied migration edited afterwards is refused
+		edited := *last
+		edited.Checksum = "edited"
+		modified := migrations.NewMigrator(testDB.Pool, append(append([]*migrations.Migration{}, loaded[:len(loaded)-1]...), &edited))
+		if err := modified.CheckCurrent(ctx); !errors.Is(err, migrations.ErrChecksumMismatch) {
+			t.Errorf("Expected checksum mismatch, got %v", err)
+		}
+		if _, err := modified.Up(ctx); !errors.Is(err, migrations.ErrChecksumMismatch) {
+			t.Errorf("Expected up to refuse an edited migration, got %v", err)
+		}
+
+		// A build without an applied migration is refused
+		older := migrations.NewMigrator(testDB.Pool, loaded[:len(loaded)-1])
+		if err := older.CheckCurrent(ctx); !errors.Is(err, migrations.ErrUnknownMigration) {
+			t.Errorf("Expected unknown migration, got %v", err)
+		}
+
+		// A build with a new migration reports the schema as behind
+		newer := migrations.NewMigrator(testDB.Pool, append(append([]*migrations.Migration{}, loaded...), &migrations.Migration{
+			Version: last.Version + 1, Name: "pending", Up: "SELECT 1", Down: "SELECT 1", Checksum: "pending",
+		}))
+		if err := newer.CheckCurrent(ctx); !errors.Is(err, migrations.ErrSchemaBehind) {
+			t.Errorf("Expected schema behind, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestWithTx(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		errAbort := errors.New("abort")
+
+		projectCount := func() int {
+			projects, err := testDB.Repo.ListProjects(ctx)
+			if err != nil {
+				t.Fatalf("Failed to list projects: %v", err)
+			}
+			return len(projects)
+		}
+
+		// A failing unit of work leaves nothing behind
+		err := testDB.Repo.WithTx(ctx, func(tx database.RepositoryInterface) error {
+			if _, err := tx.CreateProject(ctx, "tx-rolled-back", ""); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("Expected the unit of work's error, got %v", err)
+		}
+		if count := projectCount(); count != 0 {
+			t.Errorf("Expected rollback to leave no projects, found %d", count)
+		}
+
+		// A failing savepoint only undoes its own writes
+		var outerID string
+		err = testDB.Repo.WithTx(ctx, func(tx database.RepositoryInterface) error {
+			project, err := tx.CreateProject(ctx, "tx-outer", "")
+			if err != nil {
+				return err
+			}
+			outerID = project.ID.String()
+
+			nestedErr := tx.WithTx(ctx, func(nested database.RepositoryInterface) error {
+				if _, err := nested.CreateProject(ctx, "tx-nested", ""); err != nil {
+					return err
+				}
+				return errAbort
+			})
+			if !errors.Is(nestedErr, errAbort) {
+				t.Errorf("Expected the savepoint's error, got %v", nestedErr)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to commit outer transaction: %v", err)
+		}
+		projects, err := testDB.Repo.ListProjects(ctx)
+		if err != nil {
+			t.Fatalf("Failed to list projects: %v", err)
+		}
+		if len(projects) != 1 || projects[0].ID.String() != outerID {
+			t.Errorf("Expected only the outer project to be committed, got %d projects", len(projects))
+		}
+
+		// Serialization failures are retried from the start
+		attempts := 0
+		err = testDB.Repo.WithTx(ctx, func(tx database.RepositoryInterface) error {
+			attempts++
+			if _, err := tx.CreateProject(ctx, "tx-retried", ""); err != nil {
+				return err
+			}
+			if attempts == 1 {
+				return &pgconn.PgError{Code: "40001", Message: "could not serialize access"}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Expected retried transaction to commit, got %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf("Expected 2 attempts, got %d", attempts)
+		}
+		if count := projectCount(); count != 2 {
+			t.Errorf("Expected the retried project to be committed once, found %d projects", count)
+		}
+
+		// Other errors are not retried
+		attempts = 0
+		err = testDB.Repo.WithTx(ctx, func(tx database.RepositoryInterface) error {
+			attempts++
+			_, err := tx.GetProjectByID(ctx, uuid.New())
+			return err
+		})
+		if attempts != 1 || !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("Expected a single attempt, got %d attempts and %v", attempts, err)
+		}
+	})
+}
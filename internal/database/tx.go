@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Transactions failing with one of these SQLSTATEs are retried by WithTx
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// maxTxAttempts is how often WithTx runs a transaction that keeps failing with a serialization failure
+const maxTxAttempts = 3
+
+// txRetryDelay is the pause before the first retry, multiplied by the attempt for further retries
+const txRetryDelay = 20 * time.Millisecond
+
+// ErrLockOutsideTx is returned when a transaction-scoped lock is taken outside WithTx
+var ErrLockOutsideTx = errors.New("lock requires a transaction, call it within WithTx")
+
+// querier is implemented by both the connection pool and a transaction,
+// so every repository operation runs unchanged inside WithTx
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// WithTx runs fn with a repository whose operations all belong to one transaction. The transaction
+// commits if fn returns nil and rolls back otherwise. Called on a repository that is already in a
+// transaction, fn runs within a savepoint, so its failure only undoes its own writes.
+//
+// A transaction failing with a serialization failure or deadlock is retried from the start, so fn
+// may run more than once and must not have side effects outside the database.
+func (r *Repository) WithTx(ctx context.Context, fn func(RepositoryInterface) error) error {
+	if _, nested := r.db.(pgx.Tx); nested {
+		return r.runTx(ctx, fn)
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = r.runTx(ctx, fn)
+		if !isRetryableTxError(err) || attempt == maxTxAttempts {
+			break
+		}
+
+		log.Printf("Retrying transaction after attempt %d/%d: %v", attempt, maxTxAttempts, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * txRetryDelay):
+		}
+	}
+	return err
+}
+
+// runTx begins a transaction, or a savepoint when already in one, and runs fn on it
+func (r *Repository) runTx(ctx context.Context, fn func(RepositoryInterface) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// A no-op once committed, also rolls back when fn panics
+	defer tx.Rollback(ctx)
+
+	if err := fn(&Repository{pool: r.pool, db: tx}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// isRetryableTxError reports whether a transaction failed only because it conflicted with another one
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+}
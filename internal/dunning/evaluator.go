@@ -0,0 +1,131 @@
+package dunning
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/notifications"
+	"github.com/google/uuid"
+)
+
+// DefaultInterval is how often subscriptions in dunning are evaluated
+const DefaultInterval = 5 * time.Minute
+
+// Notifier receives dunning state change events
+type Notifier interface {
+	Notify(projectID uuid.UUID, eventType string, data interface{})
+}
+
+// Evaluator periodically moves subscriptions in dunning to their next state
+type Evaluator struct {
+	db       database.RepositoryInterface
+	notifier Notifier
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewEvaluator creates an evaluator; a nil notifier disables state change events
+func NewEvaluator(db database.RepositoryInterface, notifier Notifier, interval time.Duration) *Evaluator {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Evaluator{
+		db:       db,
+		notifier: notifier,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs evaluations in the background until Stop is called
+func (e *Evaluator) Start() {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := e.Evaluate(context.Background(), time.Now()); err != nil {
+				log.Printf("Error evaluating dunning subscriptions: %v", err)
+			}
+
+			select {
+			case <-e.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the evaluator and waits for a running evaluation until ctx expires
+func (e *Evaluator) Stop(ctx context.Context) error {
+	close(e.stop)
+
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Evaluate moves every subscription in grace or retrying to its state at now and returns how many changed
+func (e *Evaluator) Evaluate(ctx context.Context, now time.Time) (int, error) {
+	subscriptions, err := e.db.ListDunningSubscriptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, sub := range subscriptions {
+		next := NextState(sub, now)
+		if next == sub.DunningState {
+			continue
+		}
+
+		ok, err := e.db.TransitionDunningState(ctx, sub.StripeSubscriptionID, sub.DunningState, next)
+		if err != nil {
+			log.Printf("Error moving subscription %s from %s to %s: %v", sub.StripeSubscriptionID, sub.DunningState, next, err)
+			continue
+		}
+		if !ok {
+			// A webhook changed the state since it was listed
+			continue
+		}
+
+		log.Printf("Subscription %s moved from %s to %s", sub.StripeSubscriptionID, sub.DunningState, next)
+		Announce(e.notifier, &sub.Subscription, sub.DunningState, next)
+		changed++
+	}
+
+	return changed, nil
+}
+
+// Announce sends a dunning state change event to the subscription's project
+func Announce(notifier Notifier, sub *database.Subscription, from, to string) {
+	if notifier == nil {
+		return
+	}
+
+	notifier.Notify(sub.ProjectID, notifications.EventDunningStateChanged, notifications.DunningData{
+		StripeSubscriptionID: sub.StripeSubscriptionID,
+		UserID:               sub.UserID,
+		ProductID:            sub.ProductID,
+		From:                 from,
+		To:                   to,
+	})
+}
@@ -0,0 +1,50 @@
+// Package dunning moves subscriptions with failed payments through grace, retrying, suspended and recovered
+package dunning
+
+import (
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+)
+
+// NextState returns the dunning state a subscription should be in at now:
+// grace ends after the project's grace period, and reaching the retry limit suspends access
+func NextState(sub *database.DunningSubscription, now time.Time) string {
+	switch sub.DunningState {
+	case database.DunningStateGrace, database.DunningStateRetrying:
+	default:
+		return sub.DunningState
+	}
+
+	if sub.MaxRetries > 0 && sub.DunningRetries >= int64(sub.MaxRetries) {
+		return database.DunningStateSuspended
+	}
+	if sub.DunningState == database.DunningStateGrace && sub.DunningStartedAt != nil &&
+		!now.Before(GraceEndsAt(*sub.DunningStartedAt, sub.GraceDays)) {
+		return database.DunningStateRetrying
+	}
+	return sub.DunningState
+}
+
+// GraceEndsAt returns when the grace period that started at startedAt ends
+func GraceEndsAt(startedAt time.Time, graceDays int) time.Time {
+	return startedAt.AddDate(0, 0, graceDays)
+}
+
+// AccessGranted reports whether the subscriber should keep access.
+// Access is kept during grace and revoked once dunning moves past it;
+// outside dunning it follows the Stripe subscription status.
+func AccessGranted(sub *database.Subscription) bool {
+	switch sub.DunningState {
+	case database.DunningStateGrace:
+		return true
+	case database.DunningStateRetrying, database.DunningStateSuspended:
+		return false
+	}
+
+	switch sub.Status {
+	case "active", "trialing", "past_due":
+		return true
+	}
+	return false
+}
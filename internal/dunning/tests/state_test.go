@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/dunning"
+)
+
+func TestNextState(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+
+	tests := []struct {
+		name      string
+		state     string
+		startedAt *time.Time
+		retries   int64
+		expected  string
+	}{
+		{"grace within period", database.DunningStateGrace, daysAgo(2), 1, database.DunningStateGrace},
+		{"grace period over", database.DunningStateGrace, daysAgo(7), 1, database.DunningStateRetrying},
+		{"grace retries exhausted", database.DunningStateGrace, daysAgo(1), 3, database.DunningStateSuspended},
+		{"retrying with retries left", database.DunningStateRetrying, daysAgo(10), 2, database.DunningStateRetrying},
+		{"retrying retries exhausted", database.DunningStateRetrying, daysAgo(10), 3, database.DunningStateSuspended},
+		{"suspended stays suspended", database.DunningStateSuspended, daysAgo(30), 3, database.DunningStateSuspended},
+		{"recovered stays recovered", database.DunningStateRecovered, daysAgo(30), 3, database.DunningStateRecovered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &database.DunningSubscription{GraceDays: 7, MaxRetries: 3}
+			sub.DunningState = tt.state
+			sub.DunningStartedAt = tt.startedAt
+			sub.DunningRetries = tt.retries
+
+			if next := dunning.NextState(sub, now); next != tt.expected {
+				t.Errorf("Expected next state '%s', got '%s'", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestAccessGranted(t *testing.T) {
+	tests := []struct {
+		status   string
+		state    string
+		expected bool
+	}{
+		{"active", "", true},
+		{"past_due", database.DunningStateGrace, true},
+		{"past_due", database.DunningStateRetrying, false},
+		{"unpaid", database.DunningStateSuspended, false},
+		{"active", database.DunningStateRecovered, true},
+		{"canceled", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status+"/"+tt.state, func(t *testing.T) {
+			sub := &database.Subscription{Status: tt.status}
+			sub.DunningState = tt.state
+
+			if granted := dunning.AccessGranted(sub); granted != tt.expected {
+				t.Errorf("Expected access granted %v, got %v", tt.expected, granted)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// DunningSettings configures how the calling project's failed subscription payments are handled
+type DunningSettings struct {
+	GraceDays  int `json:"grace_days"`  // Days access is kept after the first failed payment
+	MaxRetries int `json:"max_retries"` // Failed payment attempts before the subscription is suspended
+}
+
+// HandleDunningSettings handles GET and PUT /admin/dunning for the authenticated project
+func HandleDunningSettings(db database.RepositoryInterface, stripeSecret string, w http.ResponseWriter, r *http.Request) {
+	projectID, ok := middleware.GetProjectID(r.Context())
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusUnauthorized, "authentication_error", "UNAUTHORIZED", "Unauthorized", "Missing or invalid authentication", "", "", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var settings DunningSettings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_BODY", "Invalid request body", "Failed to decode JSON body", "", "", "")
+			return
+		}
+		if err := validateDunningSettings(settings); err != nil {
+			utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "VALIDATION_FAILED", err.Error(), "Request validation failed", "", "", "")
+			return
+		}
+		if err := db.UpdateProjectDunningSettings(r.Context(), projectID, settings.GraceDays, settings.MaxRetries); err != nil {
+			log.Printf("Failed to update dunning settings of project %s: %v", projectID, err)
+			utils.WriteDatabaseErrorResponse(w, err, "Failed to update dunning settings")
+			return
+		}
+		log.Printf("Updated dunning settings of project %s: grace %d days, %d retries", projectID, settings.GraceDays, settings.MaxRetries)
+	default:
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only GET and PUT methods are allowed", "", "", "")
+		return
+	}
+
+	project, err := db.GetProjectByID(r.Context(), projectID)
+	if err != nil {
+		log.Printf("Failed to load project %s: %v", projectID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to retrieve dunning settings")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DunningSettings{
+		GraceDays:  project.DunningGraceDays,
+		MaxRetries: project.DunningMaxRetries,
+	})
+}
+
+// validateDunningSettings validates the dunning settings
+func validateDunningSettings(settings DunningSettings) error {
+	if settings.GraceDays < 0 || settings.GraceDays > 60 {
+		return fmt.Errorf("grace_days must be between 0 and 60")
+	}
+	if settings.MaxRetries < 1 || settings.MaxRetries > 10 {
+		return fmt.Errorf("max_retries must be between 1 and 10")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+)
+
+// Limits for event listings and range replays
+const (
+	DefaultEventLimit = 50
+	MaxEventLimit     = 500
+)
+
+// DefaultEventStatuses are the statuses listed or replayed when none are requested:
+// events that have not been processed successfully
+var DefaultEventStatuses = []string{
+	database.EventStatusPending,
+	database.EventStatusFailed,
+	database.EventStatusDeadLetter,
+}
+
+// EventReplayer runs stored Stripe events through the webhook dispatch again
+type EventReplayer interface {
+	ReplayEvent(ctx context.Context, eventID, triggeredBy string) (*database.StripeEvent, error)
+}
+
+// EventSummary describes a stored Stripe event without its payload
+type EventSummary struct {
+	ID            string     `json:"id"`
+	ProjectID     *uuid.UUID `json:"project_id,omitempty"`
+	Type          string     `json:"type"`
+	Created       time.Time  `json:"created"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	ReceivedAt    time.Time  `json:"received_at"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
+}
+
+// EventsResponse lists stored Stripe events
+type EventsResponse struct {
+	Events []EventSummary `json:"events"`
+}
+
+// EventDetailResponse is a stored Stripe event with its payload and admin history
+type EventDetailResponse struct {
+	EventSummary
+	Payload json.RawMessage               `json:"payload"`
+	Actions []*database.StripeEventAction `json:"actions"`
+}
+
+// ReplayRangeRequest selects the events replayed by POST /admin/events/replay
+type ReplayRangeRequest struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Type     string    `json:"type,omitempty"`
+	Statuses []string  `json:"statuses,omitempty"`
+	Limit    int       `json:"limit,omitempty"`
+}
+
+// ReplayResult is the outcome of replaying a single event
+type ReplayResult struct {
+	EventID string `json:"event_id"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReplayRangeResponse reports the outcome of every event replayed from a range
+type ReplayRangeResponse struct {
+	Replayed  int            `json:"replayed"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Results   []ReplayResult `json:"results"`
+}
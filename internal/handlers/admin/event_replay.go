@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// HandleReplayEvent handles POST /admin/events/{event_id}/replay
+func HandleReplayEvent(replayer EventReplayer, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only POST method is allowed", "", "", "")
+		return
+	}
+
+	adminUser, ok := middleware.GetAdminUser(r.Context())
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusUnauthorized, "authentication_error", "UNAUTHORIZED", "Unauthorized", "Missing or invalid authentication", "", "", "")
+		return
+	}
+
+	eventID := r.PathValue("event_id")
+	event, err := replayer.ReplayEvent(r.Context(), eventID, adminUser)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		utils.WriteErrorResponse(w, http.StatusNotFound, "invalid_request", "EVENT_NOT_FOUND", "Event not found", "No stored event has this ID", "event_id", "", "")
+		return
+	case errors.Is(err, database.ErrStripeEventLocked):
+		utils.WriteErrorResponse(w, http.StatusConflict, "invalid_request", "EVENT_LOCKED", "Event is being processed", "Retry once the current attempt has finished", "event_id", "", "")
+		return
+	case err != nil:
+		log.Printf("Failed to replay event %s: %v", eventID, err)
+		utils.WriteErrorResponse(w, http.StatusInternalServerError, "api_error", "REPLAY_FAILED", "Internal server error", "Failed to replay event", "", "", "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summarizeEvent(event)); err != nil {
+		log.Printf("Error encoding replay response: %v", err)
+	}
+}
+
+// HandleReplayRange handles POST /admin/events/replay, replaying the selected events oldest first
+func HandleReplayRange(db database.RepositoryInterface, replayer EventReplayer, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only POST method is allowed", "", "", "")
+		return
+	}
+
+	adminUser, ok := middleware.GetAdminUser(r.Context())
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusUnauthorized, "authentication_error", "UNAUTHORIZED", "Unauthorized", "Missing or invalid authentication", "", "", "")
+		return
+	}
+
+	var req ReplayRangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_BODY", "Invalid request body", "Failed to decode JSON body", "", "", "")
+		return
+	}
+	if err := validateReplayRange(&req); err != nil {
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "VALIDATION_FAILED", err.Error(), "Request validation failed", "", "", "")
+		return
+	}
+
+	events, err := db.ListStripeEventsByStatus(r.Context(), req.Statuses, req.Type, req.From, req.To, req.Limit)
+	if err != nil {
+		log.Printf("Failed to list events to replay: %v", err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to list events")
+		return
+	}
+
+	log.Printf("Replaying %d events between %s and %s, triggered by %s", len(events), req.From.Format(time.RFC3339), req.To.Format(time.RFC3339), adminUser)
+
+	response := ReplayRangeResponse{Results: make([]ReplayResult, 0, len(events))}
+	for _, event := range events {
+		result := ReplayResult{EventID: event.ID}
+		replayed, err := replayer.ReplayEvent(r.Context(), event.ID, adminUser)
+		if err != nil {
+			result.Status = event.Status
+			result.Error = err.Error()
+		} else {
+			result.Status = replayed.Status
+			if replayed.Status != database.EventStatusProcessed {
+				result.Error = replayed.LastError
+			}
+		}
+
+		response.Replayed++
+		if result.Status == database.EventStatusProcessed {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding replay response: %v", err)
+	}
+}
+
+// HandleIgnoreEvent handles POST /admin/events/{event_id}/ignore
+func HandleIgnoreEvent(db database.RepositoryInterface, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only POST method is allowed", "", "", "")
+		return
+	}
+
+	adminUser, ok := middleware.GetAdminUser(r.Context())
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusUnauthorized, "authentication_error", "UNAUTHORIZED", "Unauthorized", "Missing or invalid authentication", "", "", "")
+		return
+	}
+
+	eventID := r.PathValue("event_id")
+	ignored, err := db.MarkStripeEventIgnored(r.Context(), eventID)
+	if err != nil {
+		log.Printf("Failed to ignore event %s: %v", eventID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to ignore event")
+		return
+	}
+
+	event, err := db.GetStripeEvent(r.Context(), eventID)
+	if errors.Is(err, database.ErrNotFound) {
+		utils.WriteErrorResponse(w, http.StatusNotFound, "invalid_request", "EVENT_NOT_FOUND", "Event not found", "No stored event has this ID", "event_id", "", "")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load stored event %s: %v", eventID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to retrieve event")
+		return
+	}
+
+	if !ignored {
+		utils.WriteErrorResponse(w, http.StatusConflict, "invalid_request", "EVENT_NOT_IGNORABLE", "Event cannot be ignored", "Event is "+event.Status, "event_id", "", "")
+		return
+	}
+
+	log.Printf("Event %s (%s) ignored by %s", event.ID, event.Type, adminUser)
+	if err := db.RecordStripeEventAction(r.Context(), &database.StripeEventAction{
+		EventID:     event.ID,
+		Action:      database.EventActionIgnore,
+		TriggeredBy: adminUser,
+		Result:      event.Status,
+	}); err != nil {
+		log.Printf("Error recording ignore of event %s: %v", event.ID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summarizeEvent(event)); err != nil {
+		log.Printf("Error encoding ignore response: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
+)
+
+// HandleListEvents handles GET /admin/events?status=&type=&from=&to=&limit=
+func HandleListEvents(db database.RepositoryInterface, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only GET method is allowed", "", "", "")
+		return
+	}
+
+	query := r.URL.Query()
+	statuses := DefaultEventStatuses
+	if status := query.Get("status"); status != "" {
+		statuses = strings.Split(status, ",")
+	}
+	if err := validateEventStatuses(statuses); err != nil {
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_STATUS", "Invalid status", err.Error(), "status", "", "")
+		return
+	}
+
+	from, ok := queryTime(r, "from", time.Unix(0, 0))
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_FROM", "Invalid from", "from must be an RFC 3339 timestamp", "from", "", "")
+		return
+	}
+	to, ok := queryTime(r, "to", time.Now().Add(time.Minute))
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_TO", "Invalid to", "to must be an RFC 3339 timestamp", "to", "", "")
+		return
+	}
+
+	limit := DefaultEventLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > MaxEventLimit {
+			utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_LIMIT", "Invalid limit", "limit must be between 1 and 500", "limit", "", "")
+			return
+		}
+		limit = parsed
+	}
+
+	events, err := db.ListStripeEventsByStatus(r.Context(), statuses, query.Get("type"), from, to, limit)
+	if err != nil {
+		log.Printf("Failed to list stored Stripe events: %v", err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to list events")
+		return
+	}
+
+	response := EventsResponse{Events: make([]EventSummary, 0, len(events))}
+	for _, event := range events {
+		response.Events = append(response.Events, summarizeEvent(event))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding events response: %v", err)
+	}
+}
+
+// HandleGetEvent handles GET /admin/events/{event_id}
+func HandleGetEvent(db database.RepositoryInterface, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only GET method is allowed", "", "", "")
+		return
+	}
+
+	eventID := r.PathValue("event_id")
+	event, err := db.GetStripeEvent(r.Context(), eventID)
+	if errors.Is(err, database.ErrNotFound) {
+		utils.WriteErrorResponse(w, http.StatusNotFound, "invalid_request", "EVENT_NOT_FOUND", "Event not found", "No stored event has this ID", "event_id", "", "")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load stored event %s: %v", eventID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to retrieve event")
+		return
+	}
+
+	actions, err := db.ListStripeEventActions(r.Context(), eventID)
+	if err != nil {
+		log.Printf("Failed to list actions of event %s: %v", eventID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to retrieve event history")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(EventDetailResponse{
+		EventSummary: summarizeEvent(event),
+		Payload:      event.Payload,
+		Actions:      actions,
+	}); err != nil {
+		log.Printf("Error encoding event response: %v", err)
+	}
+}
+
+// summarizeEvent drops the payload of a stored event
+func summarizeEvent(event *database.StripeEvent) EventSummary {
+	return EventSummary{
+		ID:            event.ID,
+		ProjectID:     event.ProjectID,
+		Type:          event.Type,
+		Created:       event.Created,
+		Status:        event.Status,
+		Attempts:      event.Attempts,
+		LastError:     event.LastError,
+		NextAttemptAt: event.NextAttemptAt,
+		ReceivedAt:    event.ReceivedAt,
+		ProcessedAt:   event.ProcessedAt,
+	}
+}
+
+// queryTime reads an RFC 3339 query parameter, returning the default when it is absent
+func queryTime(r *http.Request, name string, defaultValue time.Time) (time.Time, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, true
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	return parsed, err == nil
+}
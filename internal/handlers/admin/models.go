@@ -2,10 +2,9 @@ package admin
 
 import "time"
 
-// ProductRegistrationRequest represents the request to register products
+// ProductRegistrationRequest represents the request to register products in the authenticated project
 type ProductRegistrationRequest struct {
-	ProjectName string `json:"project_name"`
-	Plans       []Plan `json:"plans"`
+	Plans []Plan `json:"plans"`
 }
 
 // Plan represents a subscription plan to be created
@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/price"
+	"github.com/stripe/stripe-go/v72/product"
+)
+
+// createStripeProducts creates products and prices in Stripe.
+// The project_id metadata attributes the products' catalog events to the project.
+func createStripeProducts(ctx context.Context, project *database.Project, req ProductRegistrationRequest) ([]ProductResponse, error) {
+	var results []ProductResponse
+
+	for _, plan := range req.Plans {
+		// Create Stripe Product
+		productParams := &stripe.ProductParams{
+			Name:        stripe.String(fmt.Sprintf("%s - %s", project.Name, plan.Name)),
+			Description: stripe.String(plan.Description),
+		}
+
+		// Add metadata
+		productParams.Metadata = map[string]string{
+			"project_id":   project.ID.String(),
+			"project_name": project.Name,
+			"plan_name":    plan.Name,
+		}
+		if len(plan.Features) > 0 {
+			productParams.Metadata["features"] = strings.Join(plan.Features, ",")
+		}
+
+		stripeProduct, err := product.New(productParams)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create product for plan '%s': %w", plan.Name, err)
+		}
+
+		log.Printf("Created Stripe product: %s for plan '%s'", stripeProduct.ID, plan.Name)
+
+		// Create prices
+		prices := PriceResponse{}
+
+		// Monthly price
+		if plan.Pricing.Monthly > 0 {
+			monthlyPrice, err := price.New(&stripe.PriceParams{
+				Product:    stripe.String(stripeProduct.ID),
+				UnitAmount: stripe.Int64(plan.Pricing.Monthly),
+				Currency:   stripe.String("usd"),
+				Recurring: &stripe.PriceRecurringParams{
+					Interval: stripe.String("month"),
+				},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create monthly price for plan '%s': %w", plan.Name, err)
+			}
+			prices.Monthly = &PriceDetails{
+				StripePriceID: monthlyPrice.ID,
+				Amount:        monthlyPrice.UnitAmount,
+				Interval:      "month",
+				Currency:      "usd",
+			}
+			log.Printf("Created monthly price: %s for plan '%s'", monthlyPrice.ID, plan.Name)
+		}
+
+		// Yearly price (if specified)
+		if plan.Pricing.Yearly > 0 {
+			yearlyPrice, err := price.New(&stripe.PriceParams{
+				Product:    stripe.String(stripeProduct.ID),
+				UnitAmount: stripe.Int64(plan.Pricing.Yearly),
+				Currency:   stripe.String("usd"),
+				Recurring: &stripe.PriceRecurringParams{
+					Interval: stripe.String("year"),
+				},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create yearly price for plan '%s': %w", plan.Name, err)
+			}
+			prices.Yearly = &PriceDetails{
+				StripePriceID: yearlyPrice.ID,
+				Amount:        yearlyPrice.UnitAmount,
+				Interval:      "year",
+				Currency:      "usd",
+			}
+			log.Printf("Created yearly price: %s for plan '%s'", yearlyPrice.ID, plan.Name)
+		}
+
+		results = append(results, ProductResponse{
+			PlanName:        plan.Name,
+			StripeProductID: stripeProduct.ID,
+			Prices:          prices,
+			CreatedAt:       time.Now(),
+		})
+	}
+
+	return results, nil
+}
+
+// rollbackStripeProducts archives products in Stripe if database save fails
+func rollbackStripeProducts(products []ProductResponse) {
+	log.Printf("Rolling back %d Stripe products", len(products))
+	for _, prod := range products {
+		_, err := product.Update(prod.StripeProductID, &stripe.ProductParams{
+			Active: stripe.Bool(false),
+		})
+		if err != nil {
+			log.Printf("Failed to archive product %s during rollback: %v", prod.StripeProductID, err)
+		} else {
+			log.Printf("Archived product %s", prod.StripeProductID)
+		}
+	}
+}
@@ -1,13 +1,13 @@
 package admin
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+)
 
 // validateProductRequest validates the product registration request
 func validateProductRequest(req *ProductRegistrationRequest) error {
-	if req.ProjectName == "" {
-		return fmt.Errorf("project_name is required")
-	}
-
 	if len(req.Plans) == 0 {
 		return fmt.Errorf("at least one plan is required")
 	}
@@ -49,3 +49,41 @@ func validatePlan(plan *Plan, index int) error {
 
 	return nil
 }
+
+// validateEventStatuses checks that every status names a stored event status
+func validateEventStatuses(statuses []string) error {
+	for _, status := range statuses {
+		switch status {
+		case database.EventStatusPending, database.EventStatusProcessing, database.EventStatusProcessed,
+			database.EventStatusFailed, database.EventStatusDeadLetter, database.EventStatusIgnored:
+		default:
+			return fmt.Errorf("unknown event status '%s'", status)
+		}
+	}
+	return nil
+}
+
+// validateReplayRange validates a range replay request and fills in its defaults
+func validateReplayRange(req *ReplayRangeRequest) error {
+	if req.From.IsZero() || req.To.IsZero() {
+		return fmt.Errorf("from and to are required")
+	}
+	if !req.From.Before(req.To) {
+		return fmt.Errorf("from must be before to")
+	}
+
+	if len(req.Statuses) == 0 {
+		req.Statuses = DefaultEventStatuses
+	}
+	if err := validateEventStatuses(req.Statuses); err != nil {
+		return err
+	}
+
+	if req.Limit == 0 {
+		req.Limit = DefaultEventLimit
+	}
+	if req.Limit < 1 || req.Limit > MaxEventLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxEventLimit)
+	}
+	return nil
+}
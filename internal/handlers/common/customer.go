@@ -3,12 +3,17 @@ package common
 import (
 	"context"
 
-	"github.com/DraconDev/go-stripe-ms/internal/database"
 	"github.com/google/uuid"
 )
 
-// FindOrCreateStripeCustomer finds an existing Stripe customer or creates a new one
-// This wraps the database repository method for convenience
-func FindOrCreateStripeCustomer(ctx context.Context, db database.RepositoryInterface, projectID uuid.UUID, userID, email string) (string, error) {
-	return db.FindOrCreateStripeCustomer(ctx, projectID, userID, email)
+// CustomerService finds or creates the Stripe customer of a project's user.
+// It is implemented by customers.Service.
+type CustomerService interface {
+	FindOrCreateStripeCustomer(ctx context.Context, projectID uuid.UUID, userID, email string) (string, error)
+}
+
+// CustomerEraser erases the personal data stored about a project's user.
+// It is implemented by customers.Service.
+type CustomerEraser interface {
+	EraseCustomer(ctx context.Context, projectID uuid.UUID, userID string, deleteStripeCustomer bool) (bool, error)
 }
@@ -1,34 +1,88 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/DraconDev/go-stripe-ms/internal/database"
 	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
 )
 
-// HandleHealth handles GET /health - simple health check
-func HandleHealth(w http.ResponseWriter, r *http.Request) {
+// healthPingTimeout bounds the database check of the health endpoint
+const healthPingTimeout = 2 * time.Second
+
+// DatabaseHealth reports the reachability and connection pool of the database
+type DatabaseHealth struct {
+	Status string              `json:"status"`
+	Error  string              `json:"error,omitempty"`
+	Pool   *database.PoolStats `json:"pool,omitempty"`
+}
+
+// HandleHealth handles GET /health - health check including the database pool.
+// Without a database only the service itself is reported.
+func HandleHealth(db database.RepositoryInterface, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	response := struct {
-		Status    string    `json:"status"`
-		Timestamp time.Time `json:"timestamp"`
-		Service   string    `json:"service"`
+		Status    string          `json:"status"`
+		Timestamp time.Time       `json:"timestamp"`
+		Service   string          `json:"service"`
+		Database  *DatabaseHealth `json:"database,omitempty"`
 	}{
 		Status:    "healthy",
 		Timestamp: time.Now(),
 		Service:   "billing-service",
 	}
 
+	statusCode := http.StatusOK
+	if db != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), healthPingTimeout)
+		defer cancel()
+
+		response.Database = &DatabaseHealth{Status: "up", Pool: db.PoolStats()}
+		if err := db.Ping(ctx); err != nil {
+			log.Printf("Health check failed to reach database: %v", err)
+			response.Status = "unhealthy"
+			response.Database.Status = "down"
+			response.Database.Error = "database unreachable"
+			statusCode = http.StatusServiceUnavailable
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("Error encoding response for health check: %v", err)
+		return
+	}
+}
+
+// HandleMetrics handles GET /metrics - connection pool statistics
+func HandleMetrics(db database.RepositoryInterface, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := struct {
+		Timestamp    time.Time           `json:"timestamp"`
+		Service      string              `json:"service"`
+		DatabasePool *database.PoolStats `json:"database_pool"`
+	}{
+		Timestamp:    time.Now(),
+		Service:      "billing-service",
+		DatabasePool: db.PoolStats(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding response for metrics: %v", err)
 		utils.WriteErrorResponse(w, http.StatusInternalServerError, "internal_error", "ENCODING_FAILED",
 			"Failed to encode response", "An unexpected error occurred while preparing the response.", "", "", "")
 		return
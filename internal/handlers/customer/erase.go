@@ -0,0 +1,81 @@
+package customer
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DraconDev/go-stripe-ms/internal/customers"
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/common"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// ErasureResponse confirms that a user's personal data was erased
+type ErasureResponse struct {
+	UserID                string `json:"user_id"`
+	Erased                bool   `json:"erased"`
+	StripeCustomerDeleted bool   `json:"stripe_customer_deleted"`
+}
+
+// HandleEraseCustomer handles DELETE /api/v1/customers/{user_id}?delete_stripe_customer=
+func HandleEraseCustomer(eraser common.CustomerEraser, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only DELETE method is allowed", "", "", "")
+		return
+	}
+
+	// Parse URL path to extract user_id
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 { // e.g., "api/v1/customers/user_id" -> 4 parts
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_URL", "Invalid URL format", "URL must be in format /api/v1/customers/{user_id}", "", "", "")
+		return
+	}
+	userID := pathParts[3]
+
+	deleteStripeCustomer := false
+	if value := r.URL.Query().Get("delete_stripe_customer"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_PARAMETER", "Invalid delete_stripe_customer", "delete_stripe_customer must be true or false", "delete_stripe_customer", "", "")
+			return
+		}
+		deleteStripeCustomer = parsed
+	}
+
+	projectID, ok := middleware.GetProjectID(r.Context())
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusUnauthorized, "authentication_error", "UNAUTHORIZED", "Unauthorized", "Missing or invalid authentication", "", "", "")
+		return
+	}
+
+	stripeCustomerDeleted, err := eraser.EraseCustomer(r.Context(), projectID, userID, deleteStripeCustomer)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		utils.WriteErrorResponse(w, http.StatusNotFound, "not_found", "CUSTOMER_NOT_FOUND", "Customer not found", "The user has no customer in this project", "user_id", "", "")
+		return
+	case errors.Is(err, customers.ErrStripeCustomerNotDeleted):
+		log.Printf("Failed to delete Stripe customer while erasing customer data: %v", err)
+		utils.WriteErrorResponse(w, http.StatusInternalServerError, "api_error", "STRIPE_ERROR", "Failed to delete Stripe customer", "Nothing was erased; the request can be retried", "", "", "")
+		return
+	case err != nil:
+		log.Printf("Failed to erase customer data: %v", err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to erase customer data")
+		return
+	}
+
+	response := ErasureResponse{
+		UserID:                userID,
+		Erased:                true,
+		StripeCustomerDeleted: stripeCustomerDeleted,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding erasure response: %v", err)
+	}
+}
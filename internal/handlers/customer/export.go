@@ -0,0 +1,67 @@
+package customer
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// ExportResponse is the bundle of everything stored about a user in the calling project
+type ExportResponse struct {
+	UserID     string    `json:"user_id"`
+	ExportedAt time.Time `json:"exported_at"`
+	*database.CustomerExport
+}
+
+// HandleExportCustomer handles GET /api/v1/customers/{user_id}/export
+func HandleExportCustomer(db database.RepositoryInterface, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only GET method is allowed", "", "", "")
+		return
+	}
+
+	// Parse URL path to extract user_id
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 5 || pathParts[4] != "export" { // e.g., "api/v1/customers/user_id/export" -> 5 parts
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_URL", "Invalid URL format", "URL must be in format /api/v1/customers/{user_id}/export", "", "", "")
+		return
+	}
+	userID := pathParts[3]
+
+	projectID, ok := middleware.GetProjectID(r.Context())
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusUnauthorized, "authentication_error", "UNAUTHORIZED", "Unauthorized", "Missing or invalid authentication", "", "", "")
+		return
+	}
+
+	log.Printf("HandleExportCustomer called for user: %s", userID)
+
+	export, err := db.ExportCustomerData(r.Context(), projectID, userID)
+	if errors.Is(err, database.ErrNotFound) {
+		utils.WriteErrorResponse(w, http.StatusNotFound, "not_found", "CUSTOMER_NOT_FOUND", "Customer not found", "The user has no customer in this project", "user_id", "", "")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to export data of user %s: %v", userID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to export customer data")
+		return
+	}
+
+	response := ExportResponse{
+		UserID:         userID,
+		ExportedAt:     time.Now().UTC(),
+		CustomerExport: export,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding customer export response: %v", err)
+	}
+}
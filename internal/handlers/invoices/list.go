@@ -0,0 +1,99 @@
+package invoices
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// Pagination limits for invoice listings
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// InvoicesResponse lists a page of a user's invoices
+type InvoicesResponse struct {
+	UserID   string              `json:"user_id"`
+	Invoices []*database.Invoice `json:"invoices"`
+	Limit    int                 `json:"limit"`
+	Offset   int                 `json:"offset"`
+	HasMore  bool                `json:"has_more"`
+}
+
+// HandleListInvoices handles GET /api/v1/invoices/{user_id}?limit=&offset=
+func HandleListInvoices(db database.RepositoryInterface, stripeSecret string, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only GET method is allowed", "", "", "")
+		return
+	}
+
+	// Parse URL path to extract user_id
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 { // e.g., "api/v1/invoices/user_id" -> 4 parts
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_URL", "Invalid URL format", "URL must be in format /api/v1/invoices/{user_id}", "", "", "")
+		return
+	}
+	userID := pathParts[3]
+
+	limit, ok := queryInt(r, "limit", DefaultLimit)
+	if !ok || limit < 1 || limit > MaxLimit {
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_LIMIT", "Invalid limit", "limit must be between 1 and 100", "limit", "", "")
+		return
+	}
+	offset, ok := queryInt(r, "offset", 0)
+	if !ok || offset < 0 {
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_OFFSET", "Invalid offset", "offset must be zero or greater", "offset", "", "")
+		return
+	}
+
+	projectID, ok := middleware.GetProjectID(r.Context())
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusUnauthorized, "authentication_error", "UNAUTHORIZED", "Unauthorized", "Missing or invalid authentication", "", "", "")
+		return
+	}
+
+	log.Printf("HandleListInvoices called for user: %s (limit: %d, offset: %d)", userID, limit, offset)
+
+	// Fetch one extra row to know whether another page exists
+	invoices, err := db.GetInvoicesByUserID(r.Context(), projectID, userID, limit+1, offset)
+	if err != nil {
+		log.Printf("Failed to get invoices for user %s: %v", userID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to retrieve invoices")
+		return
+	}
+
+	hasMore := len(invoices) > limit
+	if hasMore {
+		invoices = invoices[:limit]
+	}
+
+	response := InvoicesResponse{
+		UserID:   userID,
+		Invoices: invoices,
+		Limit:    limit,
+		Offset:   offset,
+		HasMore:  hasMore,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding invoices response: %v", err)
+	}
+}
+
+// queryInt reads an integer query parameter, returning the default when it is absent
+func queryInt(r *http.Request, name string, defaultValue int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, true
+	}
+	parsed, err := strconv.Atoi(value)
+	return parsed, err == nil
+}
@@ -0,0 +1,59 @@
+package orders
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// OrdersResponse lists the one-time purchases of a user
+type OrdersResponse struct {
+	UserID string            `json:"user_id"`
+	Orders []*database.Order `json:"orders"`
+}
+
+// HandleListOrders handles GET /api/v1/orders/{user_id}
+func HandleListOrders(db database.RepositoryInterface, stripeSecret string, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only GET method is allowed", "", "", "")
+		return
+	}
+
+	// Parse URL path to extract user_id
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 4 { // e.g., "api/v1/orders/user_id" -> 4 parts
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_URL", "Invalid URL format", "URL must be in format /api/v1/orders/{user_id}", "", "", "")
+		return
+	}
+	userID := pathParts[3]
+
+	projectID, ok := middleware.GetProjectID(r.Context())
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusUnauthorized, "authentication_error", "UNAUTHORIZED", "Unauthorized", "Missing or invalid authentication", "", "", "")
+		return
+	}
+
+	log.Printf("HandleListOrders called for user: %s", userID)
+
+	orders, err := db.GetOrdersByUserID(r.Context(), projectID, userID)
+	if err != nil {
+		log.Printf("Failed to get orders for user %s: %v", userID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to retrieve orders")
+		return
+	}
+
+	response := OrdersResponse{
+		UserID: userID,
+		Orders: orders,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding orders response: %v", err)
+	}
+}
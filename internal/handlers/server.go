@@ -4,20 +4,26 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/DraconDev/go-stripe-ms/internal/customers"
 	"github.com/DraconDev/go-stripe-ms/internal/database"
 	"github.com/DraconDev/go-stripe-ms/internal/handlers/admin"
 	"github.com/DraconDev/go-stripe-ms/internal/handlers/billing"
 	"github.com/DraconDev/go-stripe-ms/internal/handlers/cart"
 	"github.com/DraconDev/go-stripe-ms/internal/handlers/core"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/customer"
 	"github.com/DraconDev/go-stripe-ms/internal/handlers/docs"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/invoices"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/orders"
 	"github.com/DraconDev/go-stripe-ms/internal/handlers/subscription"
 	"github.com/stripe/stripe-go/v72"
 )
 
 // HTTPServer provides HTTP REST API for billing operations
 type HTTPServer struct {
-	db           database.RepositoryInterface
-	stripeSecret string
+	db              database.RepositoryInterface
+	stripeSecret    string
+	customerService *customers.Service
+	replayer        admin.EventReplayer
 }
 
 // NewHTTPServer creates a new HTTP server instance
@@ -25,16 +31,27 @@ func NewHTTPServer(db database.RepositoryInterface, stripeSecret string) *HTTPSe
 	stripe.Key = stripeSecret
 
 	return &HTTPServer{
-		db:           db,
-		stripeSecret: stripeSecret,
+		db:              db,
+		stripeSecret:    stripeSecret,
+		customerService: customers.NewService(db, customers.NewStripeAPI(stripeSecret)),
 	}
 }
 
+// SetEventReplayer sets the replayer used by the admin event replay endpoints
+func (s *HTTPServer) SetEventReplayer(replayer admin.EventReplayer) {
+	s.replayer = replayer
+}
+
 // Wrapper methods that delegate to sub-package handler functions
 
 // HealthCheck handles GET /health
 func (s *HTTPServer) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	core.HandleHealth(w, r)
+	core.HandleHealth(s.db, w, r)
+}
+
+// Metrics handles GET /metrics
+func (s *HTTPServer) Metrics(w http.ResponseWriter, r *http.Request) {
+	core.HandleMetrics(s.db, w, r)
 }
 
 // RootHandler handles GET /
@@ -44,12 +61,12 @@ func (s *HTTPServer) RootHandler(w http.ResponseWriter, r *http.Request) {
 
 // CreateItemCheckout handles POST /api/v1/checkout/item
 func (s *HTTPServer) CreateItemCheckout(w http.ResponseWriter, r *http.Request) {
-	core.HandleItemCheckout(s.db, s.stripeSecret, w, r)
+	core.HandleItemCheckout(s.customerService, s.stripeSecret, w, r)
 }
 
 // CreateCartCheckout handles POST /api/v1/checkout/cart
 func (s *HTTPServer) CreateCartCheckout(w http.ResponseWriter, r *http.Request) {
-	cart.HandleCartCheckout(s.db, s.stripeSecret, w, r)
+	cart.HandleCartCheckout(s.customerService, s.stripeSecret, w, r)
 }
 
 // DebugHandler handles GET /debug
@@ -69,7 +86,7 @@ func (s *HTTPServer) DocsHandler(w http.ResponseWriter, r *http.Request) {
 
 // CreateSubscriptionCheckout handles POST /api/v1/checkout/subscription
 func (s *HTTPServer) CreateSubscriptionCheckout(w http.ResponseWriter, r *http.Request) {
-	subscription.HandleSubscriptionCheckout(s.db, s.stripeSecret, w, r)
+	subscription.HandleSubscriptionCheckout(s.customerService, s.stripeSecret, w, r)
 }
 
 // GetSubscriptionStatus handles GET /api/v1/subscriptions/{user_id}/{product_id}
@@ -77,6 +94,31 @@ func (s *HTTPServer) GetSubscriptionStatus(w http.ResponseWriter, r *http.Reques
 	subscription.HandleSubscriptionStatus(s.db, s.stripeSecret, w, r)
 }
 
+// GetSubscriptionHistory handles GET /api/v1/subscriptions/{user_id}/{product_id}/history
+func (s *HTTPServer) GetSubscriptionHistory(w http.ResponseWriter, r *http.Request) {
+	subscription.HandleSubscriptionHistory(s.db, s.stripeSecret, w, r)
+}
+
+// ListOrders handles GET /api/v1/orders/{user_id}
+func (s *HTTPServer) ListOrders(w http.ResponseWriter, r *http.Request) {
+	orders.HandleListOrders(s.db, s.stripeSecret, w, r)
+}
+
+// ListInvoices handles GET /api/v1/invoices/{user_id}
+func (s *HTTPServer) ListInvoices(w http.ResponseWriter, r *http.Request) {
+	invoices.HandleListInvoices(s.db, s.stripeSecret, w, r)
+}
+
+// EraseCustomer handles DELETE /api/v1/customers/{user_id}
+func (s *HTTPServer) EraseCustomer(w http.ResponseWriter, r *http.Request) {
+	customer.HandleEraseCustomer(s.customerService, w, r)
+}
+
+// ExportCustomer handles GET /api/v1/customers/{user_id}/export
+func (s *HTTPServer) ExportCustomer(w http.ResponseWriter, r *http.Request) {
+	customer.HandleExportCustomer(s.db, w, r)
+}
+
 // CreateCustomerPortal handles POST /api/v1/portal
 func (s *HTTPServer) CreateCustomerPortal(w http.ResponseWriter, r *http.Request) {
 	billing.HandleCustomerPortal(s.db, s.stripeSecret, w, r)
@@ -86,3 +128,33 @@ func (s *HTTPServer) CreateCustomerPortal(w http.ResponseWriter, r *http.Request
 func (s *HTTPServer) RegisterProducts(w http.ResponseWriter, r *http.Request) {
 	admin.HandleProductRegistration(s.db, s.stripeSecret, w, r)
 }
+
+// DunningSettings handles GET and PUT /admin/dunning
+func (s *HTTPServer) DunningSettings(w http.ResponseWriter, r *http.Request) {
+	admin.HandleDunningSettings(s.db, s.stripeSecret, w, r)
+}
+
+// ListEvents handles GET /admin/events
+func (s *HTTPServer) ListEvents(w http.ResponseWriter, r *http.Request) {
+	admin.HandleListEvents(s.db, w, r)
+}
+
+// GetEvent handles GET /admin/events/{event_id}
+func (s *HTTPServer) GetEvent(w http.ResponseWriter, r *http.Request) {
+	admin.HandleGetEvent(s.db, w, r)
+}
+
+// ReplayEvent handles POST /admin/events/{event_id}/replay
+func (s *HTTPServer) ReplayEvent(w http.ResponseWriter, r *http.Request) {
+	admin.HandleReplayEvent(s.replayer, w, r)
+}
+
+// ReplayEvents handles POST /admin/events/replay
+func (s *HTTPServer) ReplayEvents(w http.ResponseWriter, r *http.Request) {
+	admin.HandleReplayRange(s.db, s.replayer, w, r)
+}
+
+// IgnoreEvent handles POST /admin/events/{event_id}/ignore
+func (s *HTTPServer) IgnoreEvent(w http.ResponseWriter, r *http.Request) {
+	admin.HandleIgnoreEvent(s.db, w, r)
+}
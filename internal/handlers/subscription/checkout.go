@@ -6,7 +6,6 @@ import (
 	"log"
 	"net/http"
 
-	"github.com/DraconDev/go-stripe-ms/internal/database"
 	"github.com/DraconDev/go-stripe-ms/internal/handlers/common"
 	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
 	"github.com/DraconDev/go-stripe-ms/internal/middleware"
@@ -25,7 +24,7 @@ type SubscriptionCheckoutRequest struct {
 }
 
 // HandleSubscriptionCheckout handles POST /api/v1/checkout/subscription
-func HandleSubscriptionCheckout(db database.RepositoryInterface, stripeSecret string, w http.ResponseWriter, r *http.Request) {
+func HandleSubscriptionCheckout(customerService common.CustomerService, stripeSecret string, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only POST method is allowed", "", "", "")
 		return
@@ -53,7 +52,7 @@ func HandleSubscriptionCheckout(db database.RepositoryInterface, stripeSecret st
 	log.Printf("HandleSubscriptionCheckout called for user: %s, price: %s", req.UserID, req.PriceID)
 
 	// Find or create Stripe customer
-	stripeCustomerID, err := common.FindOrCreateStripeCustomer(r.Context(), db, projectID, req.UserID, req.Email)
+	stripeCustomerID, err := customerService.FindOrCreateStripeCustomer(r.Context(), projectID, req.UserID, req.Email)
 	if err != nil {
 		log.Printf("Failed to find or create Stripe customer for user %s: %v", req.UserID, err)
 		utils.WriteErrorResponse(w, http.StatusInternalServerError, "api_error", "CUSTOMER_ERROR", "Failed to create or find customer", err.Error(), "user_id", "", "")
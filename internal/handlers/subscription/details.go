@@ -0,0 +1,46 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/dunning"
+)
+
+// addSubscriptionDetails adds status, trial, cancellation, pause and dunning details
+// so apps can show banners and gate access without calling Stripe.
+// It fails rather than leave out access_granted, which clients gate on.
+func addSubscriptionDetails(ctx context.Context, db database.RepositoryInterface, stripeSubID string, response map[string]interface{}) error {
+	subscription, err := db.GetSubscriptionByStripeID(ctx, stripeSubID)
+	if err != nil {
+		return fmt.Errorf("failed to load subscription: %w", err)
+	}
+
+	var pauseCollection interface{}
+	if subscription.PauseBehavior != "" {
+		pauseCollection = map[string]interface{}{
+			"behavior":   subscription.PauseBehavior,
+			"resumes_at": subscription.PauseResumesAt,
+		}
+	}
+
+	response["status"] = subscription.Status
+	response["trial_start"] = subscription.TrialStart
+	response["trial_end"] = subscription.TrialEnd
+	response["cancel_at_period_end"] = subscription.CancelAtPeriodEnd
+	response["canceled_at"] = subscription.CanceledAt
+	response["ended_at"] = subscription.EndedAt
+	response["pause_collection"] = pauseCollection
+	response["dunning_state"] = subscription.DunningState
+	response["access_granted"] = dunning.AccessGranted(subscription)
+
+	if subscription.DunningState == database.DunningStateGrace && subscription.DunningStartedAt != nil {
+		project, err := db.GetProjectByID(ctx, subscription.ProjectID)
+		if err != nil {
+			return fmt.Errorf("failed to load dunning settings of project %s: %w", subscription.ProjectID, err)
+		}
+		response["grace_period_ends_at"] = dunning.GraceEndsAt(*subscription.DunningStartedAt, project.DunningGraceDays)
+	}
+	return nil
+}
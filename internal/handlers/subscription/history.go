@@ -0,0 +1,102 @@
+package subscription
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// Pagination limits for subscription history listings
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 200
+)
+
+// HistoryResponse lists a page of the status and billing period changes of a user's subscription to a product
+type HistoryResponse struct {
+	UserID    string                        `json:"user_id"`
+	ProductID string                        `json:"product_id"`
+	Events    []*database.SubscriptionEvent `json:"events"`
+	Limit     int                           `json:"limit"`
+	Offset    int                           `json:"offset"`
+	HasMore   bool                          `json:"has_more"`
+}
+
+// HandleSubscriptionHistory handles GET /api/v1/subscriptions/{user_id}/{product_id}/history?limit=&offset=
+func HandleSubscriptionHistory(db database.RepositoryInterface, stripeSecret string, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteErrorResponse(w, http.StatusMethodNotAllowed, "method_not_allowed", "METHOD_NOT_ALLOWED", "Method not allowed", "Only GET method is allowed", "", "", "")
+		return
+	}
+
+	// Parse URL path to extract user_id and product_id
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 6 || pathParts[5] != "history" { // e.g., "api/v1/subscriptions/user_id/product_id/history" -> 6 parts
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_URL", "Invalid URL format", "URL must be in format /api/v1/subscriptions/{user_id}/{product_id}/history", "", "", "")
+		return
+	}
+	userID := pathParts[3]
+	productID := pathParts[4]
+
+	limit, ok := queryInt(r, "limit", DefaultHistoryLimit)
+	if !ok || limit < 1 || limit > MaxHistoryLimit {
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_LIMIT", "Invalid limit", "limit must be between 1 and 200", "limit", "", "")
+		return
+	}
+	offset, ok := queryInt(r, "offset", 0)
+	if !ok || offset < 0 {
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "INVALID_OFFSET", "Invalid offset", "offset must be zero or greater", "offset", "", "")
+		return
+	}
+
+	projectID, ok := middleware.GetProjectID(r.Context())
+	if !ok {
+		utils.WriteErrorResponse(w, http.StatusUnauthorized, "authentication_error", "UNAUTHORIZED", "Unauthorized", "Missing or invalid authentication", "", "", "")
+		return
+	}
+
+	log.Printf("HandleSubscriptionHistory called for user: %s, product: %s (limit: %d, offset: %d)", userID, productID, limit, offset)
+
+	// Fetch one extra entry to know whether another page exists
+	events, err := db.ListSubscriptionEvents(r.Context(), projectID, userID, productID, limit+1, offset)
+	if err != nil {
+		log.Printf("Failed to get subscription history for user %s, product %s: %v", userID, productID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to retrieve subscription history")
+		return
+	}
+
+	hasMore := len(events) > limit
+	if hasMore {
+		events = events[:limit]
+	}
+
+	response := HistoryResponse{
+		UserID:    userID,
+		ProductID: productID,
+		Events:    events,
+		Limit:     limit,
+		Offset:    offset,
+		HasMore:   hasMore,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding subscription history response: %v", err)
+	}
+}
+
+// queryInt reads an integer query parameter, returning the default when it is absent
+func queryInt(r *http.Request, name string, defaultValue int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, true
+	}
+	parsed, err := strconv.Atoi(value)
+	return parsed, err == nil
+}
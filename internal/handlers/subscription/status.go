@@ -2,6 +2,7 @@ package subscription
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings" // Added for URL parsing
@@ -12,7 +13,6 @@ import (
 
 	// Added, though not directly used in this snippet, it's in the provided import block
 	"github.com/DraconDev/go-stripe-ms/internal/middleware"
-	"github.com/jackc/pgx/v5"         // Added for pgx.ErrNoRows check
 	"github.com/stripe/stripe-go/v72" // Kept for helper functions
 	// Kept for helper functions
 )
@@ -44,8 +44,8 @@ func HandleSubscriptionStatus(db database.RepositoryInterface, stripeSecret stri
 	// For now, assuming it returns stripeSubID, customerID, periodEnd, exists, err
 	stripeSubID, customerID, periodEnd, exists, err := db.GetSubscriptionStatus(r.Context(), projectID, userID, productID)
 	if err != nil {
-		// Check if it's a "no rows" error - this is normal when subscription doesn't exist
-		if err == pgx.ErrNoRows {
+		// A missing row is normal when the subscription doesn't exist
+		if errors.Is(err, database.ErrNotFound) {
 			// Return exists: false
 			response := map[string]interface{}{
 				"exists": false,
@@ -54,9 +54,8 @@ func HandleSubscriptionStatus(db database.RepositoryInterface, stripeSecret stri
 			json.NewEncoder(w).Encode(response)
 			return
 		}
-		// For other errors, return 500
 		log.Printf("Failed to get subscription status for user %s, product %s: %v", userID, productID, err)
-		utils.WriteErrorResponse(w, http.StatusInternalServerError, "api_error", "DATABASE_ERROR", "Internal server error", "Failed to retrieve subscription status", "", "", "")
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to retrieve subscription status")
 		return
 	}
 
@@ -77,6 +76,11 @@ func HandleSubscriptionStatus(db database.RepositoryInterface, stripeSecret stri
 		"customer_id":            customerID,
 		"period_end":             periodEnd,
 	}
+	if err := addSubscriptionDetails(r.Context(), db, stripeSubID, response); err != nil {
+		log.Printf("Failed to load details of subscription %s: %v", stripeSubID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "Failed to retrieve subscription status")
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+func TestProductRegistrationConflictsWithinProject(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		owner, err := testDB.Repo.CreateProject(ctx, "Registration Owner", "")
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+		if err := testDB.Repo.CreateRegisteredProduct(ctx, &database.RegisteredProduct{
+			ProjectID:       owner.ID,
+			PlanName:        "Pro Plan",
+			StripeProductID: "prod_test_registered",
+			Currency:        "usd",
+			Features:        []byte("{}"),
+		}); err != nil {
+			t.Fatalf("Failed to register product: %v", err)
+		}
+
+		server := handlers.NewHTTPServer(testDB.Repo, "sk_test_dummy")
+		bodyBytes, _ := json.Marshal(map[string]interface{}{
+			"plans": []map[string]interface{}{
+				{"name": "Pro Plan", "pricing": map[string]interface{}{"monthly": 2900}},
+			},
+		})
+		req := httptest.NewRequest(http.MethodPost, "/admin/products/register", bytes.NewReader(bodyBytes))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.ProjectIDKey, owner.ID))
+
+		// Registering the plan again is rejected before anything is created in Stripe
+		w := httptest.NewRecorder()
+		server.RegisterProducts(w, req)
+		if w.Code != http.StatusConflict {
+			t.Fatalf("Expected status code %d, got %d. Response: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response["existing_product_id"] != "prod_test_registered" {
+			t.Errorf("Expected existing_product_id='prod_test_registered', got: %v", response["existing_product_id"])
+		}
+	})
+}
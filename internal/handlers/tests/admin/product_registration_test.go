@@ -35,7 +35,6 @@ func TestProductRegistrationIntegration(t *testing.T) {
 			{
 				name: "Valid product registration",
 				requestBody: map[string]interface{}{
-					"project_name": "test-project",
 					"plans": []map[string]interface{}{
 						{
 							"name":        "Pro Plan",
@@ -52,7 +51,7 @@ func TestProductRegistrationIntegration(t *testing.T) {
 				setupAuth:          true,
 			},
 			{
-				name: "Missing project_name",
+				name: "Missing project authentication",
 				requestBody: map[string]interface{}{
 					"plans": []map[string]interface{}{
 						{
@@ -63,14 +62,13 @@ func TestProductRegistrationIntegration(t *testing.T) {
 						},
 					},
 				},
-				expectedStatusCode: http.StatusBadRequest,
-				setupAuth:          true,
+				expectedStatusCode: http.StatusUnauthorized,
+				setupAuth:          false,
 			},
 			{
 				name: "Missing plans",
 				requestBody: map[string]interface{}{
-					"project_name": "test-project",
-					"plans":        []map[string]interface{}{},
+					"plans": []map[string]interface{}{},
 				},
 				expectedStatusCode: http.StatusBadRequest,
 				setupAuth:          true,
@@ -78,7 +76,6 @@ func TestProductRegistrationIntegration(t *testing.T) {
 			{
 				name: "Invalid pricing (no monthly or yearly)",
 				requestBody: map[string]interface{}{
-					"project_name": "test-project",
 					"plans": []map[string]interface{}{
 						{
 							"name": "Pro Plan",
@@ -138,9 +135,9 @@ func TestProductRegistrationIntegration(t *testing.T) {
 						return
 					}
 
-					// Verify project_id field
-					if projectID, ok := response["project_id"].(string); !ok || projectID != "test-project" {
-						t.Errorf("Expected project_id='test-project', got: %v", response["project_id"])
+					// Verify the products were registered in the authenticated project
+					if projectID, ok := response["project_id"].(string); !ok || projectID != project.ID.String() {
+						t.Errorf("Expected project_id='%s', got: %v", project.ID, response["project_id"])
 					}
 
 					// Verify products array
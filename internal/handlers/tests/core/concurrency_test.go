@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// TestConcurrentRequests serves authenticated requests in parallel, as net/http does, against one
+// repository. Run with -race to check the repository is safe for concurrent use.
+func TestConcurrentRequests(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		server := handlers.NewHTTPServer(testDB.Repo, "sk_test_dummy")
+		auth := middleware.NewAPIKeyAuth(testDB.Repo)
+		mux := http.NewServeMux()
+		mux.HandleFunc("/health", server.HealthCheck)
+		mux.Handle("/api/v1/subscriptions/{user_id}/{product_id}", auth.Middleware(http.HandlerFunc(server.GetSubscriptionStatus)))
+		mux.Handle("/api/v1/orders/{user_id}", auth.Middleware(http.HandlerFunc(server.ListOrders)))
+		mux.Handle("/api/v1/invoices/{user_id}", auth.Middleware(http.HandlerFunc(server.ListInvoices)))
+
+		paths := []string{
+			"/health",
+			fmt.Sprintf("/api/v1/subscriptions/%s/premium_plan", customer.UserID),
+			fmt.Sprintf("/api/v1/orders/%s", customer.UserID),
+			fmt.Sprintf("/api/v1/invoices/%s", customer.UserID),
+		}
+
+		const workers, requestsPerWorker = 16, 10
+		var wg sync.WaitGroup
+		failures := make(chan string, workers*requestsPerWorker)
+		for worker := 0; worker < workers; worker++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				for i := 0; i < requestsPerWorker; i++ {
+					path := paths[(worker+i)%len(paths)]
+					req := httptest.NewRequest(http.MethodGet, path, nil)
+					req.Header.Set("X-API-Key", project.APIKey)
+					w := httptest.NewRecorder()
+					mux.ServeHTTP(w, req)
+					if w.Code != http.StatusOK {
+						failures <- fmt.Sprintf("%s: status %d: %s", path, w.Code, w.Body.String())
+					}
+				}
+			}(worker)
+		}
+		wg.Wait()
+		close(failures)
+
+		for failure := range failures {
+			t.Error(failure)
+		}
+
+		// The pool served the requests and reports them on /health
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		var health struct {
+			Status   string `json:"status"`
+			Database struct {
+				Status string              `json:"status"`
+				Pool   *database.PoolStats `json:"pool"`
+			} `json:"database"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+			t.Fatalf("Failed to unmarshal health response: %v", err)
+		}
+		if health.Database.Status != "up" || health.Database.Pool == nil {
+			t.Fatalf("Expected database up with pool statistics, got %+v", health.Database)
+		}
+		// An in-memory repository has no connection pool to count acquisitions of
+		if testDB.Pool != nil && health.Database.Pool.AcquireCount < workers*requestsPerWorker {
+			t.Errorf("Expected at least %d pool acquisitions, got %d", workers*requestsPerWorker, health.Database.Pool.AcquireCount)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/customer"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// TestCustomerExportAndErasureIntegration tests exporting and then erasing a user's data with real database
+func TestCustomerExportAndErasureIntegration(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		project, testCustomer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+		server := handlers.NewHTTPServer(testDB.Repo, "")
+
+		// do sends a request on behalf of the test project
+		do := func(method, path string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.ProjectIDKey, project.ID))
+			w := httptest.NewRecorder()
+			handler(w, req)
+			return w
+		}
+		exportPath := "/api/v1/customers/" + testCustomer.UserID + "/export"
+		erasePath := "/api/v1/customers/" + testCustomer.UserID
+
+		w := do(http.MethodGet, exportPath, server.ExportCustomer)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d exporting, got %d", http.StatusOK, w.Code)
+		}
+		var export customer.ExportResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &export); err != nil {
+			t.Fatalf("Failed to unmarshal export: %v", err)
+		}
+		if export.UserID != testCustomer.UserID || export.CustomerExport == nil || export.Customer.Email != testCustomer.Email {
+			t.Errorf("Expected the export to hold the customer, got %+v", export)
+		}
+		// CreateTestData gives the customer a premium_plan subscription
+		if len(export.Subscriptions) != 1 || export.Subscriptions[0].ProductID != "premium_plan" {
+			t.Errorf("Expected the export to hold the subscription, got %+v", export.Subscriptions)
+		}
+
+		tests := []struct {
+			name               string
+			method             string
+			path               string
+			handler            http.HandlerFunc
+			expectedStatusCode int
+		}{
+			{"Export with wrong method", http.MethodPost, exportPath, server.ExportCustomer, http.StatusMethodNotAllowed},
+			{"Export of unknown user", http.MethodGet, "/api/v1/customers/nonexistent/export", server.ExportCustomer, http.StatusNotFound},
+			{"Erase with wrong method", http.MethodGet, erasePath, server.EraseCustomer, http.StatusMethodNotAllowed},
+			{"Erase with invalid flag", http.MethodDelete, erasePath + "?delete_stripe_customer=maybe", server.EraseCustomer, http.StatusBadRequest},
+			{"Erase of unknown user", http.MethodDelete, "/api/v1/customers/nonexistent", server.EraseCustomer, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if w := do(tt.method, tt.path, tt.handler); w.Code != tt.expectedStatusCode {
+					t.Errorf("Expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+				}
+			})
+		}
+
+		w = do(http.MethodDelete, erasePath+"?delete_stripe_customer=false", server.EraseCustomer)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d erasing, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var erasure customer.ErasureResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &erasure); err != nil {
+			t.Fatalf("Failed to unmarshal erasure: %v", err)
+		}
+		if !erasure.Erased || erasure.StripeCustomerDeleted {
+			t.Errorf("Expected an erasure without Stripe deletion, got %+v", erasure)
+		}
+
+		// The user ID no longer leads to any data
+		if w := do(http.MethodGet, exportPath, server.ExportCustomer); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d exporting an erased user, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := do(http.MethodDelete, erasePath, server.EraseCustomer); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d erasing an erased user, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -12,7 +12,7 @@ import (
 
 // TestDatabaseOperationsIntegration tests database operations directly
 func TestDatabaseOperationsIntegration(t *testing.T) {
-	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
 		ctx := context.Background()
 
 		// Create a test project
@@ -38,16 +38,16 @@ func TestDatabaseOperationsIntegration(t *testing.T) {
 		stripeCustomerID2 := fmt.Sprintf("cus_test_%d_2", timestamp)
 		stripeSubID := fmt.Sprintf("sub_test_%d", timestamp)
 
-		t.Run("FindOrCreateStripeCustomer", func(t *testing.T) {
+		t.Run("EnsureCustomer", func(t *testing.T) {
 			// Test customer creation
-			customerID, err := testDB.Repo.FindOrCreateStripeCustomer(ctx, projectID,
+			created, err := testDB.Repo.EnsureCustomer(ctx, projectID,
 				testUserID1, fmt.Sprintf("test%d@example.com", timestamp))
 			if err != nil {
 				t.Fatalf("Failed to create customer: %v", err)
 			}
 
-			if customerID == "" {
-				t.Fatal("Customer ID should not be empty")
+			if created.StripeCustomerID != "" {
+				t.Errorf("Expected no Stripe customer ID until one is linked, got '%s'", created.StripeCustomerID)
 			}
 
 			// Test customer retrieval
@@ -61,12 +61,14 @@ func TestDatabaseOperationsIntegration(t *testing.T) {
 			if customer.UserID != testUserID1 {
 				t.Errorf("Expected user ID '%s', got '%s'", testUserID1, customer.UserID)
 			}
-			// Note: stripe_customer_id may be NULL until Stripe API is called
+			if customer.ID != created.ID {
+				t.Errorf("Expected customer '%s', got '%s'", created.ID, customer.ID)
+			}
 		})
 
 		t.Run("CreateSubscription", func(t *testing.T) {
 			// First create a customer
-			_, err := testDB.Repo.FindOrCreateStripeCustomer(ctx, projectID,
+			_, err := testDB.Repo.EnsureCustomer(ctx, projectID,
 				testUserID2, fmt.Sprintf("test%d_2@example.com", timestamp))
 			if err != nil {
 				t.Fatalf("Failed to create customer: %v", err)
@@ -86,8 +88,8 @@ func TestDatabaseOperationsIntegration(t *testing.T) {
 
 			// Create subscription
 			now := time.Now()
-			err = testDB.Repo.CreateSubscription(ctx, projectID, customer.ID.String(), stripeSubID,
-				"pro_plan", "price_789", testUserID2, "active", now, now.AddDate(0, 0, 30))
+			_, err = testDB.Repo.CreateSubscription(ctx, projectID, customer.ID.String(), stripeSubID,
+				"pro_plan", "price_789", testUserID2, "active", now, now.AddDate(0, 0, 30), now)
 			if err != nil {
 				t.Fatalf("Failed to create subscription: %v", err)
 			}
@@ -112,7 +114,7 @@ func TestDatabaseOperationsIntegration(t *testing.T) {
 
 		t.Run("UpdateSubscriptionStatus", func(t *testing.T) {
 			// Create customer and subscription first
-			_, err := testDB.Repo.FindOrCreateStripeCustomer(ctx, projectID,
+			_, err := testDB.Repo.EnsureCustomer(ctx, projectID,
 				testUserID3, fmt.Sprintf("test%d_3@example.com", timestamp))
 			if err != nil {
 				t.Fatalf("Failed to create customer: %v", err)
@@ -132,18 +134,21 @@ func TestDatabaseOperationsIntegration(t *testing.T) {
 			now := time.Now()
 			// Use a different subscription ID to avoid conflicts
 			stripeSubID2 := fmt.Sprintf("sub_test_%d_2", timestamp)
-			err = testDB.Repo.CreateSubscription(ctx, projectID, customer3.ID.String(), stripeSubID2,
-				"enterprise_plan", "price_999", testUserID3, "active", now, now.AddDate(0, 0, 30))
+			_, err = testDB.Repo.CreateSubscription(ctx, projectID, customer3.ID.String(), stripeSubID2,
+				"enterprise_plan", "price_999", testUserID3, "active", now, now.AddDate(0, 0, 30), now)
 			if err != nil {
 				t.Fatalf("Failed to create subscription: %v", err)
 			}
 
 			// Update subscription status
 			newPeriodEnd := now.AddDate(0, 1, 0)
-			err = testDB.Repo.UpdateSubscriptionStatus(ctx, stripeSubID2, "canceled", newPeriodEnd)
+			applied, err := testDB.Repo.UpdateSubscriptionStatus(ctx, stripeSubID2, "canceled", newPeriodEnd, now.Add(time.Second))
 			if err != nil {
 				t.Fatalf("Failed to update subscription status: %v", err)
 			}
+			if !applied {
+				t.Fatal("Expected status update to be applied")
+			}
 
 			// Verify update
 			_, _, _, exists, err := testDB.Repo.GetSubscriptionStatus(ctx, projectID, testUserID3, "enterprise_plan")
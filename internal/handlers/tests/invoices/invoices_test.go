@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/invoices"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// TestListInvoicesIntegration tests paginated invoice listing with real database
+func TestListInvoicesIntegration(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		// Three monthly invoices, the newest one still open
+		start := time.Now().AddDate(0, -3, 0).Truncate(time.Second)
+		for i := 0; i < 3; i++ {
+			status := "paid"
+			if i == 2 {
+				status = "open"
+			}
+			_, err := testDB.Repo.UpsertInvoice(context.Background(), &database.Invoice{
+				ProjectID:       project.ID,
+				CustomerID:      customer.ID,
+				UserID:          customer.UserID,
+				StripeInvoiceID: fmt.Sprintf("in_test_list_%d", i),
+				Status:          status,
+				AmountDue:       2900,
+				Currency:        "usd",
+				PeriodStart:     start.AddDate(0, i, 0),
+				PeriodEnd:       start.AddDate(0, i+1, 0),
+				LastEventAt:     time.Now(),
+			})
+			if err != nil {
+				t.Fatalf("Failed to create invoice: %v", err)
+			}
+		}
+
+		server := handlers.NewHTTPServer(testDB.Repo, "")
+
+		tests := []struct {
+			name               string
+			query              string
+			userID             string
+			expectedStatusCode int
+			expectedIDs        []string
+			expectedHasMore    bool
+		}{
+			{
+				name:               "First page",
+				query:              "?limit=2",
+				userID:             customer.UserID,
+				expectedStatusCode: http.StatusOK,
+				expectedIDs:        []string{"in_test_list_2", "in_test_list_1"},
+				expectedHasMore:    true,
+			},
+			{
+				name:               "Last page",
+				query:              "?limit=2&offset=2",
+				userID:             customer.UserID,
+				expectedStatusCode: http.StatusOK,
+				expectedIDs:        []string{"in_test_list_0"},
+			},
+			{
+				name:               "Default page size",
+				userID:             customer.UserID,
+				expectedStatusCode: http.StatusOK,
+				expectedIDs:        []string{"in_test_list_2", "in_test_list_1", "in_test_list_0"},
+			},
+			{
+				name:               "User without invoices",
+				userID:             "nonexistent",
+				expectedStatusCode: http.StatusOK,
+				expectedIDs:        []string{},
+			},
+			{
+				name:               "Limit too large",
+				query:              fmt.Sprintf("?limit=%d", invoices.MaxLimit+1),
+				userID:             customer.UserID,
+				expectedStatusCode: http.StatusBadRequest,
+			},
+			{
+				name:               "Negative offset",
+				query:              "?offset=-1",
+				userID:             customer.UserID,
+				expectedStatusCode: http.StatusBadRequest,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/invoices/"+tt.userID+tt.query, nil)
+				ctx := context.WithValue(req.Context(), middleware.ProjectIDKey, project.ID)
+				req = req.WithContext(ctx)
+
+				w := httptest.NewRecorder()
+				server.ListInvoices(w, req)
+
+				if w.Code != tt.expectedStatusCode {
+					t.Fatalf("Expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+				}
+				if tt.expectedStatusCode != http.StatusOK {
+					return
+				}
+
+				var response invoices.InvoicesResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if len(response.Invoices) != len(tt.expectedIDs) {
+					t.Fatalf("Expected %d invoices, got %d", len(tt.expectedIDs), len(response.Invoices))
+				}
+				for i, id := range tt.expectedIDs {
+					if response.Invoices[i].StripeInvoiceID != id {
+						t.Errorf("Expected invoice %d to be '%s', got '%s'", i, id, response.Invoices[i].StripeInvoiceID)
+					}
+				}
+				if response.HasMore != tt.expectedHasMore {
+					t.Errorf("Expected has_more %v, got %v", tt.expectedHasMore, response.HasMore)
+				}
+			})
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,7 +14,7 @@ import (
 
 // TestAPIKeyAuth_Middleware tests the API key authentication middleware
 func TestAPIKeyAuth_Middleware(t *testing.T) {
-	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
 		// Create a test project with a known API key
 		// Setup test database with a known project
 		project, customer, err := testDB.CreateTestData()
@@ -75,3 +78,56 @@ func TestAPIKeyAuth_Middleware(t *testing.T) {
 		}
 	})
 }
+
+// failingProjectRepository fails every API key lookup with err
+type failingProjectRepository struct {
+	database.RepositoryInterface
+	err error
+}
+
+func (r *failingProjectRepository) GetProjectByAPIKey(ctx context.Context, apiKey string) (*database.Project, error) {
+	return nil, r.err
+}
+
+// TestAPIKeyAuth_DatabaseErrors tests that database failures are not reported as invalid API keys
+func TestAPIKeyAuth_DatabaseErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Unknown API Key",
+			err:            fmt.Errorf("no rows in result set: %w", database.ErrNotFound),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Database Unavailable",
+			err:            fmt.Errorf("failed to connect: %w", database.ErrUnavailable),
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "Other Database Error",
+			err:            errors.New("column does not exist"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &failingProjectRepository{RepositoryInterface: database.NewMemoryRepository(), err: tt.err}
+			handler := middleware.NewAPIKeyAuth(repo).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Error("Expected the request not to reach the handler")
+			}))
+
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.Header.Set("X-API-Key", "sk_test_key")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// TestListOrdersIntegration tests order listing with real database
+func TestListOrdersIntegration(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		order := &database.Order{
+			ProjectID:               project.ID,
+			CustomerID:              customer.ID,
+			UserID:                  customer.UserID,
+			StripeCheckoutSessionID: "cs_test_orders_list",
+			PaymentType:             "item",
+			PaymentStatus:           "paid",
+			AmountTotal:             1999,
+			Currency:                "usd",
+			Items: []*database.OrderItem{
+				{StripePriceID: "price_test_item", StripeProductID: "prod_test_item", Quantity: 1, AmountTotal: 1999, Currency: "usd"},
+			},
+		}
+		if err := testDB.Repo.CreateOrder(context.Background(), order); err != nil {
+			t.Fatalf("Failed to create order: %v", err)
+		}
+
+		server := handlers.NewHTTPServer(testDB.Repo, "")
+
+		tests := []struct {
+			name               string
+			path               string
+			expectedStatusCode int
+			expectedOrders     int
+		}{
+			{
+				name:               "User with one order",
+				path:               fmt.Sprintf("/api/v1/orders/%s", customer.UserID),
+				expectedStatusCode: http.StatusOK,
+				expectedOrders:     1,
+			},
+			{
+				name:               "User without orders",
+				path:               "/api/v1/orders/nonexistent",
+				expectedStatusCode: http.StatusOK,
+				expectedOrders:     0,
+			},
+			{
+				name:               "Invalid URL format",
+				path:               "/api/v1/orders/",
+				expectedStatusCode: http.StatusBadRequest,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+				ctx := context.WithValue(req.Context(), middleware.ProjectIDKey, project.ID)
+				req = req.WithContext(ctx)
+
+				w := httptest.NewRecorder()
+				server.ListOrders(w, req)
+
+				if w.Code != tt.expectedStatusCode {
+					t.Fatalf("Expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+				}
+				if tt.expectedStatusCode != http.StatusOK {
+					return
+				}
+
+				var response struct {
+					Orders []database.Order `json:"orders"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if len(response.Orders) != tt.expectedOrders {
+					t.Errorf("Expected %d orders, got %d", tt.expectedOrders, len(response.Orders))
+				}
+			})
+		}
+	})
+}
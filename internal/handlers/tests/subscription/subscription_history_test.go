@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/subscription"
+	"github.com/DraconDev/go-stripe-ms/internal/middleware"
+)
+
+// TestGetSubscriptionHistoryIntegration tests paginated subscription history listing with real database
+func TestGetSubscriptionHistoryIntegration(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		// CreateTestData gives the customer a premium_plan subscription; walk it through three statuses
+		stripeSubID, _, periodEnd, _, err := testDB.Repo.GetSubscriptionStatus(ctx, project.ID, customer.UserID, "premium_plan")
+		if err != nil {
+			t.Fatalf("Failed to get subscription status: %v", err)
+		}
+		eventAt := time.Now().Truncate(time.Second)
+		for i, status := range []string{"active", "past_due", "canceled"} {
+			eventAt = eventAt.Add(time.Minute)
+			if _, err := testDB.Repo.UpdateSubscriptionStatus(ctx, stripeSubID, status, periodEnd, eventAt); err != nil {
+				t.Fatalf("Failed to update subscription: %v", err)
+			}
+			if _, err := testDB.Repo.RecordSubscriptionEvent(ctx, stripeSubID, fmt.Sprintf("evt_test_history_%d", i), "customer.subscription.updated", eventAt); err != nil {
+				t.Fatalf("Failed to record subscription history: %v", err)
+			}
+		}
+
+		server := handlers.NewHTTPServer(testDB.Repo, "")
+
+		tests := []struct {
+			name               string
+			path               string
+			expectedStatusCode int
+			expectedEventIDs   []string
+			expectedHasMore    bool
+		}{
+			{
+				name:               "First page",
+				path:               "/api/v1/subscriptions/" + customer.UserID + "/premium_plan/history?limit=2",
+				expectedStatusCode: http.StatusOK,
+				expectedEventIDs:   []string{"evt_test_history_2", "evt_test_history_1"},
+				expectedHasMore:    true,
+			},
+			{
+				name:               "Last page",
+				path:               "/api/v1/subscriptions/" + customer.UserID + "/premium_plan/history?limit=2&offset=2",
+				expectedStatusCode: http.StatusOK,
+				expectedEventIDs:   []string{"evt_test_history_0"},
+			},
+			{
+				name:               "Product without history",
+				path:               "/api/v1/subscriptions/" + customer.UserID + "/basic_plan/history",
+				expectedStatusCode: http.StatusOK,
+				expectedEventIDs:   []string{},
+			},
+			{
+				name:               "Limit too large",
+				path:               fmt.Sprintf("/api/v1/subscriptions/%s/premium_plan/history?limit=%d", customer.UserID, subscription.MaxHistoryLimit+1),
+				expectedStatusCode: http.StatusBadRequest,
+			},
+			{
+				name:               "Invalid URL format",
+				path:               "/api/v1/subscriptions/" + customer.UserID + "/premium_plan/events",
+				expectedStatusCode: http.StatusBadRequest,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+				ctx := context.WithValue(req.Context(), middleware.ProjectIDKey, project.ID)
+				req = req.WithContext(ctx)
+
+				w := httptest.NewRecorder()
+				server.GetSubscriptionHistory(w, req)
+
+				if w.Code != tt.expectedStatusCode {
+					t.Fatalf("Expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+				}
+				if tt.expectedStatusCode != http.StatusOK {
+					return
+				}
+
+				var response subscription.HistoryResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if len(response.Events) != len(tt.expectedEventIDs) {
+					t.Fatalf("Expected %d history entries, got %d", len(tt.expectedEventIDs), len(response.Events))
+				}
+				for i, id := range tt.expectedEventIDs {
+					if response.Events[i].SourceEventID != id {
+						t.Errorf("Expected entry %d to come from '%s', got '%s'", i, id, response.Events[i].SourceEventID)
+					}
+				}
+				if response.HasMore != tt.expectedHasMore {
+					t.Errorf("Expected has_more %v, got %v", tt.expectedHasMore, response.HasMore)
+				}
+			})
+		}
+	})
+}
@@ -16,7 +16,7 @@ import (
 
 // TestGetSubscriptionStatusIntegration tests subscription status retrieval with real database
 func TestGetSubscriptionStatusIntegration(t *testing.T) {
-	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
 		// Setup test data
 		project, customer, err := testDB.CreateTestData()
 		if err != nil {
@@ -39,6 +39,10 @@ func TestGetSubscriptionStatusIntegration(t *testing.T) {
 				name:               "Valid subscription request with real data",
 				path:               "", // Will be set dynamically
 				expectedStatusCode: http.StatusOK,
+				expectedResponse: map[string]interface{}{
+					"exists":               true,
+					"cancel_at_period_end": false,
+				},
 			},
 			{
 				name:               "Non-existent subscription",
@@ -132,3 +136,42 @@ func TestGetSubscriptionStatusIntegration(t *testing.T) {
 		}
 	})
 }
+
+// failingSubscriptionRepository fails every subscription lookup by Stripe ID with err
+type failingSubscriptionRepository struct {
+	database.RepositoryInterface
+	err error
+}
+
+func (r *failingSubscriptionRepository) GetSubscriptionByStripeID(ctx context.Context, stripeSubID string) (*database.Subscription, error) {
+	return nil, r.err
+}
+
+// TestGetSubscriptionStatus_DetailsUnavailable tests that a failing detail lookup is not reported as a subscription without access
+func TestGetSubscriptionStatus_DetailsUnavailable(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		repo := &failingSubscriptionRepository{RepositoryInterface: testDB.Repo, err: fmt.Errorf("failed to connect: %w", database.ErrUnavailable)}
+		server := handlers.NewHTTPServer(repo, "")
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/subscriptions/%s/premium_plan", customer.UserID), nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.ProjectIDKey, project.ID))
+		w := httptest.NewRecorder()
+		server.GetSubscriptionStatus(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusServiceUnavailable, w.Code, w.Body.String())
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if _, ok := response["exists"]; ok {
+			t.Errorf("Expected an error response, got %v", response)
+		}
+	})
+}
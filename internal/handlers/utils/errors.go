@@ -2,10 +2,13 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
 )
 
 // Enhanced input validation
@@ -72,6 +75,23 @@ func WriteErrorResponse(w http.ResponseWriter, statusCode int, errorType, code,
 	}
 }
 
+// WriteDatabaseErrorResponse writes the error response of a failed repository operation.
+// The status follows the repository error: 404 for database.ErrNotFound, 409 for database.ErrConflict,
+// 503 for database.ErrUnavailable and 500 for anything else.
+func WriteDatabaseErrorResponse(w http.ResponseWriter, err error, description string) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		WriteErrorResponse(w, http.StatusNotFound, "not_found", "RESOURCE_NOT_FOUND", "Resource not found", description, "", "", "")
+	case errors.Is(err, database.ErrConflict):
+		WriteErrorResponse(w, http.StatusConflict, "conflict_error", "CONFLICT", "Request conflicts with the current state", description, "", "", "")
+	case errors.Is(err, database.ErrUnavailable):
+		w.Header().Set("Retry-After", "5")
+		WriteErrorResponse(w, http.StatusServiceUnavailable, "api_error", "SERVICE_UNAVAILABLE", "Service temporarily unavailable", description, "", "", "")
+	default:
+		WriteErrorResponse(w, http.StatusInternalServerError, "api_error", "DATABASE_ERROR", "Internal server error", description, "", "", "")
+	}
+}
+
 func writeValidationError(w http.ResponseWriter, field, message, requestID, environment string) {
 	WriteErrorResponse(w, http.StatusBadRequest, "validation_error", "VALIDATION_FAILED",
 		"Request validation failed", message, field, requestID, environment)
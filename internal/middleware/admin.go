@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+)
+
+const (
+	// AdminUserKey is the context key for the operator named in X-Admin-User
+	AdminUserKey contextKey = "adminUser"
+)
+
+// AdminKeyAuth middleware protects service-wide admin endpoints with the service API key
+type AdminKeyAuth struct {
+	apiKey string
+}
+
+// NewAdminKeyAuth creates a new admin authentication middleware for the service API key
+func NewAdminKeyAuth(apiKey string) *AdminKeyAuth {
+	return &AdminKeyAuth{apiKey: apiKey}
+}
+
+// Middleware validates the X-API-Key header against the service key and requires X-Admin-User
+// so that every admin action can be attributed to an operator
+func (a *AdminKeyAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-API-Key")
+		if apiKey == "" {
+			http.Error(w, `{"error":"Missing X-API-Key header"}`, http.StatusUnauthorized)
+			return
+		}
+		if a.apiKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(a.apiKey)) != 1 {
+			http.Error(w, `{"error":"Invalid API key"}`, http.StatusUnauthorized)
+			return
+		}
+
+		adminUser := r.Header.Get("X-Admin-User")
+		if adminUser == "" {
+			http.Error(w, `{"error":"Missing X-Admin-User header"}`, http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), AdminUserKey, adminUser)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetAdminUser retrieves the operator name from the context
+func GetAdminUser(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(AdminUserKey).(string)
+	return user, ok
+}
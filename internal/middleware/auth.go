@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/DraconDev/go-stripe-ms/internal/database"
@@ -37,9 +39,20 @@ func (a *APIKeyAuth) Middleware(next http.Handler) http.Handler {
 
 		// Check if API key matches a project
 		project, err := a.repo.GetProjectByAPIKey(r.Context(), apiKey)
-		if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotFound):
 			http.Error(w, `{"error":"Invalid API key"}`, http.StatusUnauthorized)
 			return
+		case errors.Is(err, database.ErrUnavailable):
+			// The key may well be valid; clients should retry rather than treat it as revoked
+			log.Printf("Database unavailable verifying API key: %v", err)
+			w.Header().Set("Retry-After", "5")
+			http.Error(w, `{"error":"Service temporarily unavailable"}`, http.StatusServiceUnavailable)
+			return
+		case err != nil:
+			log.Printf("Error verifying API key: %v", err)
+			http.Error(w, `{"error":"Failed to verify API key"}`, http.StatusInternalServerError)
+			return
 		}
 
 		if !project.IsActive {
@@ -0,0 +1,139 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+)
+
+// Headers sent with every outbound webhook
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventIDHeader   = "X-Webhook-Event-ID"
+	EventTypeHeader = "X-Webhook-Event-Type"
+)
+
+// deliver makes one delivery attempt of a claimed notification, then removes it once it is delivered
+// or out of attempts, and schedules its retry otherwise
+func (n *Notifier) deliver(ctx context.Context, notification *database.PendingNotification) {
+	project, err := n.db.GetProjectByID(ctx, notification.ProjectID)
+	if errors.Is(err, database.ErrNotFound) {
+		n.remove(ctx, notification)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load project %s for %s notification: %v", notification.ProjectID, notification.EventType, err)
+		n.retry(ctx, notification, err.Error())
+		return
+	}
+	if project.WebhookURL == "" {
+		n.remove(ctx, notification)
+		return
+	}
+
+	delivery := n.send(ctx, project, notification)
+	if err := n.db.RecordWebhookDelivery(ctx, delivery); err != nil {
+		log.Printf("Failed to record webhook delivery for event %s: %v", notification.ID, err)
+	}
+	if delivery.Status == database.DeliveryStatusRetrying {
+		n.retry(ctx, notification, delivery.Error)
+		return
+	}
+	n.remove(ctx, notification)
+}
+
+// retry schedules the next attempt of a notification after exponential backoff: baseDelay,
+// 2*baseDelay, 4*baseDelay, ... A notification out of attempts is removed instead.
+func (n *Notifier) retry(ctx context.Context, notification *database.PendingNotification, lastError string) {
+	if notification.Attempts >= n.maxAttempts {
+		log.Printf("Giving up on event %s to project %s after %d attempts", notification.ID, notification.ProjectID, notification.Attempts)
+		n.remove(ctx, notification)
+		return
+	}
+
+	delay := n.baseDelay * time.Duration(1<<(notification.Attempts-1))
+	if err := n.db.RescheduleNotification(ctx, notification.ID, lastError, time.Now().Add(delay)); err != nil {
+		log.Printf("Failed to schedule retry of event %s: %v", notification.ID, err)
+	}
+}
+
+// remove deletes a notification that needs no further attempts
+func (n *Notifier) remove(ctx context.Context, notification *database.PendingNotification) {
+	if err := n.db.DeleteNotification(ctx, notification.ID); err != nil {
+		log.Printf("Failed to remove notification %s: %v", notification.ID, err)
+	}
+}
+
+// send performs a single delivery attempt and describes its outcome
+func (n *Notifier) send(ctx context.Context, project *database.Project, notification *database.PendingNotification) *database.WebhookDelivery {
+	delivery := &database.WebhookDelivery{
+		ProjectID: project.ID,
+		EventID:   notification.ID,
+		EventType: notification.EventType,
+		URL:       project.WebhookURL,
+		Payload:   notification.Payload,
+		Attempt:   notification.Attempts,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, project.WebhookURL, bytes.NewReader(notification.Payload))
+	if err != nil {
+		delivery.Status = database.DeliveryStatusFailed
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, notification.ID.String())
+	req.Header.Set(EventTypeHeader, notification.EventType)
+	req.Header.Set(SignatureHeader, Sign(notification.Payload, project.WebhookSigningSecret, time.Now()))
+
+	start := time.Now()
+	resp, err := n.client.Do(req)
+	delivery.LatencyMS = time.Since(start).Milliseconds()
+
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+		// Drain the body so the connection can be reused
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		delivery.ResponseCode = resp.StatusCode
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			delivery.Status = database.DeliveryStatusSucceeded
+			return delivery
+		}
+		delivery.Error = fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+	}
+
+	delivery.Status = database.DeliveryStatusRetrying
+	if notification.Attempts >= n.maxAttempts {
+		delivery.Status = database.DeliveryStatusFailed
+	}
+	log.Printf("Webhook delivery of event %s to project %s failed (attempt %d/%d): %s",
+		notification.ID, project.ID, notification.Attempts, n.maxAttempts, delivery.Error)
+
+	return delivery
+}
+
+// Sign builds the signature header value for a payload: "t=<unix>,v1=<hex hmac-sha256>".
+// The HMAC covers "<unix>.<payload>", so receivers can reject replayed deliveries.
+func Sign(payload []byte, secret string, timestamp time.Time) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "t=" + unix + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
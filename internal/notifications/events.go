@@ -0,0 +1,37 @@
+package notifications
+
+// Normalized event types sent to project webhook URLs
+const (
+	EventSubscriptionCreated     = "subscription.created"
+	EventSubscriptionUpdated     = "subscription.updated"
+	EventSubscriptionCanceled    = "subscription.canceled"
+	EventDunningStateChanged     = "subscription.dunning_state_changed"
+	EventOrderCompleted          = "order.completed"
+	EventInvoicePaymentSucceeded = "invoice.payment_succeeded"
+	EventInvoicePaymentFailed    = "invoice.payment_failed"
+	EventCustomerUpdated         = "customer.updated"
+	EventCustomerDeleted         = "customer.deleted"
+)
+
+// InvoiceData is the payload of invoice notifications
+type InvoiceData struct {
+	StripeInvoiceID string `json:"stripe_invoice_id"`
+	UserID          string `json:"user_id"`
+	Amount          int64  `json:"amount"`
+}
+
+// DunningData is the payload of dunning state change notifications
+type DunningData struct {
+	StripeSubscriptionID string `json:"stripe_subscription_id"`
+	UserID               string `json:"user_id"`
+	ProductID            string `json:"product_id"`
+	From                 string `json:"from,omitempty"`
+	To                   string `json:"to"`
+}
+
+// CustomerDeletedData is the payload of customer deletion notifications
+type CustomerDeletedData struct {
+	UserID               string   `json:"user_id"`
+	StripeCustomerID     string   `json:"stripe_customer_id"`
+	EndedSubscriptionIDs []string `json:"ended_subscription_ids"` // Subscriptions ended along with the customer
+}
@@ -0,0 +1,188 @@
+// Package notifications delivers normalized billing events to project webhook URLs
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+)
+
+// Default delivery settings
+const (
+	DefaultMaxAttempts  = 5
+	DefaultBaseDelay    = 2 * time.Second
+	DefaultWorkers      = 4
+	defaultPollInterval = time.Second
+	deliveryLease       = time.Minute // How long a claimed notification stays locked to its worker
+	requestTimeout      = 10 * time.Second
+)
+
+// Event is the normalized payload POSTed to a project's webhook URL
+type Event struct {
+	ID        uuid.UUID   `json:"id"`
+	Type      string      `json:"type"`
+	ProjectID uuid.UUID   `json:"project_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Notifier sends signed events to project webhook URLs, retrying with exponential backoff.
+// Events are stored in pending_notifications until delivered, so retries survive a restart.
+type Notifier struct {
+	db           database.RepositoryInterface
+	client       *http.Client
+	maxAttempts  int
+	baseDelay    time.Duration
+	pollInterval time.Duration
+	wake         chan struct{}
+	stop         chan struct{}
+	stopOnce     sync.Once
+	wg           sync.WaitGroup
+}
+
+// NewNotifier creates a notifier; non-positive settings fall back to the defaults
+func NewNotifier(db database.RepositoryInterface, maxAttempts int, baseDelay time.Duration) *Notifier {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	if baseDelay <= 0 {
+		baseDelay = DefaultBaseDelay
+	}
+
+	// Poll at least as often as the first retry is due
+	pollInterval := defaultPollInterval
+	if baseDelay < pollInterval {
+		pollInterval = baseDelay
+	}
+
+	return &Notifier{
+		db:           db,
+		client:       &http.Client{Timeout: requestTimeout},
+		maxAttempts:  maxAttempts,
+		baseDelay:    baseDelay,
+		pollInterval: pollInterval,
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+	}
+}
+
+// Notify stores an event for delivery to the project's webhook URL and wakes a worker to send it.
+// Projects without a webhook URL are skipped when the event is delivered.
+func (n *Notifier) Notify(projectID uuid.UUID, eventType string, data interface{}) {
+	event := Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		ProjectID: projectID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s notification: %v", event.Type, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	err = n.db.EnqueueNotification(ctx, &database.PendingNotification{
+		ID:        event.ID,
+		ProjectID: projectID,
+		EventType: eventType,
+		Payload:   payload,
+	})
+	if err != nil {
+		log.Printf("Failed to store %s notification for project %s: %v", event.Type, projectID, err)
+		return
+	}
+
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start launches the workers delivering stored notifications, including those left by a previous run
+func (n *Notifier) Start() {
+	for i := 0; i < DefaultWorkers; i++ {
+		n.wg.Add(1)
+		go n.work()
+	}
+	log.Printf("Project notifier started with %d workers", DefaultWorkers)
+}
+
+// Close stops the workers and waits for in-flight deliveries until ctx expires.
+// Undelivered notifications stay stored and are delivered once a notifier starts again.
+func (n *Notifier) Close(ctx context.Context) error {
+	n.stopOnce.Do(func() { close(n.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work delivers due notifications one at a time, sleeping until woken or the next poll while none are due
+func (n *Notifier) work() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case <-n.stop:
+			return
+		default:
+		}
+
+		delivered, err := n.deliverNext(context.Background())
+		if err != nil {
+			log.Printf("Error claiming pending notifications: %v", err)
+		}
+		if delivered {
+			continue
+		}
+
+		select {
+		case <-n.stop:
+			return
+		case <-n.wake:
+		case <-time.After(n.pollInterval):
+		}
+	}
+}
+
+// DeliverPending attempts every due notification once and returns how many were attempted
+func (n *Notifier) DeliverPending(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		delivered, err := n.deliverNext(ctx)
+		if err != nil || !delivered {
+			return count, err
+		}
+		count++
+	}
+}
+
+// deliverNext claims a single due notification and attempts it, reporting whether one was claimed
+func (n *Notifier) deliverNext(ctx context.Context) (bool, error) {
+	claimed, err := n.db.ClaimNotifications(ctx, 1, deliveryLease)
+	if err != nil || len(claimed) == 0 {
+		return false, err
+	}
+
+	n.deliver(ctx, claimed[0])
+	return true, nil
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/notifications"
+)
+
+func TestNotifierDeliversSignedEventsWithRetries(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+
+		var calls int32
+		var secret string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+
+			// Verify the signature the same way a project would
+			header := r.Header.Get(notifications.SignatureHeader)
+			timestamp, err := strconv.ParseInt(strings.TrimPrefix(strings.Split(header, ",")[0], "t="), 10, 64)
+			if err != nil {
+				t.Errorf("Malformed signature header %q: %v", header, err)
+			}
+			if expected := notifications.Sign(body, secret, time.Unix(timestamp, 0)); header != expected {
+				t.Errorf("Expected signature %q, got %q", expected, header)
+			}
+			if r.Header.Get(notifications.EventTypeHeader) != notifications.EventOrderCompleted {
+				t.Errorf("Expected event type %s, got %s", notifications.EventOrderCompleted, r.Header.Get(notifications.EventTypeHeader))
+			}
+
+			// Fail the first attempt to exercise the retry path
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		project, err := testDB.Repo.CreateProject(ctx, "notifier-test-project", server.URL)
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+		secret = project.WebhookSigningSecret
+
+		notifier := notifications.NewNotifier(testDB.Repo, 3, 10*time.Millisecond)
+		notifier.Start()
+		notifier.Notify(project.ID, notifications.EventOrderCompleted, map[string]string{"user_id": "user_1"})
+
+		// Wait for the retry to go through before closing
+		deadline := time.Now().Add(5 * time.Second)
+		for atomic.LoadInt32(&calls) < 2 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := notifier.Close(closeCtx); err != nil {
+			t.Fatalf("Failed to close notifier: %v", err)
+		}
+
+		deliveries, err := testDB.Repo.ListWebhookDeliveries(ctx, project.ID, 10)
+		if err != nil {
+			t.Fatalf("Failed to list deliveries: %v", err)
+		}
+		if len(deliveries) != 2 {
+			t.Fatalf("Expected 2 recorded deliveries, got %d", len(deliveries))
+		}
+
+		statuses := map[string]int{}
+		for _, delivery := range deliveries {
+			statuses[delivery.Status] = delivery.Attempt
+		}
+		if statuses[database.DeliveryStatusRetrying] != 1 {
+			t.Errorf("Expected first attempt to be recorded as retrying, got %v", statuses)
+		}
+		if statuses[database.DeliveryStatusSucceeded] != 2 {
+			t.Errorf("Expected second attempt to be recorded as succeeded, got %v", statuses)
+		}
+	})
+}
+
+func TestNotifierRetriesSurviveRestart(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		project, err := testDB.Repo.CreateProject(ctx, "notifier-restart-project", server.URL)
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+
+		// The first attempt fails, then the notifier shuts down before the retry is due
+		before := notifications.NewNotifier(testDB.Repo, 3, 10*time.Millisecond)
+		before.Notify(project.ID, notifications.EventOrderCompleted, map[string]string{"user_id": "user_1"})
+		if attempted, err := before.DeliverPending(ctx); err != nil || attempted != 1 {
+			t.Fatalf("Expected one delivery attempt, got %d: %v", attempted, err)
+		}
+		if err := before.Close(ctx); err != nil {
+			t.Fatalf("Failed to close notifier: %v", err)
+		}
+
+		// A notifier of the next run picks the stored retry up
+		time.Sleep(20 * time.Millisecond)
+		after := notifications.NewNotifier(testDB.Repo, 3, 10*time.Millisecond)
+		if attempted, err := after.DeliverPending(ctx); err != nil || attempted != 1 {
+			t.Fatalf("Expected the retry to be attempted after the restart, got %d: %v", attempted, err)
+		}
+		if calls := atomic.LoadInt32(&calls); calls != 2 {
+			t.Errorf("Expected 2 requests, got %d", calls)
+		}
+
+		deliveries, err := testDB.Repo.ListWebhookDeliveries(ctx, project.ID, 10)
+		if err != nil {
+			t.Fatalf("Failed to list deliveries: %v", err)
+		}
+		if len(deliveries) != 2 || deliveries[0].Status != database.DeliveryStatusSucceeded || deliveries[0].Attempt != 2 {
+			t.Fatalf("Expected the second attempt to succeed, got %d deliveries", len(deliveries))
+		}
+
+		// A delivered notification is not sent again
+		time.Sleep(20 * time.Millisecond)
+		if attempted, err := after.DeliverPending(ctx); err != nil || attempted != 0 {
+			t.Errorf("Expected nothing left to deliver, got %d: %v", attempted, err)
+		}
+	})
+}
+
+func TestSignIsStable(t *testing.T) {
+	payload := []byte(`{"id":"evt"}`)
+	timestamp := time.Unix(1700000000, 0)
+
+	first := notifications.Sign(payload, "pwhsec_test", timestamp)
+	if first != notifications.Sign(payload, "pwhsec_test", timestamp) {
+		t.Error("Expected identical signatures for identical input")
+	}
+	if !strings.HasPrefix(first, "t=1700000000,v1=") {
+		t.Errorf("Unexpected signature format: %s", first)
+	}
+	if first == notifications.Sign(payload, "pwhsec_other", timestamp) {
+		t.Error("Expected different secrets to produce different signatures")
+	}
+}
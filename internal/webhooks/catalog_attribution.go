@@ -0,0 +1,111 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// attributeProduct returns the project and plan a Stripe product belongs to, uuid.Nil if it cannot be
+// attributed. Events received on a project's own endpoint always belong to that project; otherwise the
+// project_id metadata set at registration is used, then a project_name set in the dashboard, falling
+// back to the existing catalog entry.
+func (h *StripeWebhookHandler) attributeProduct(ctx context.Context, product *stripe.Product, existing *database.RegisteredProduct) (uuid.UUID, string, error) {
+	projectID, err := h.productProject(ctx, product, existing)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	planName := product.Metadata["plan_name"]
+	if planName == "" && existing != nil {
+		planName = existing.PlanName
+	}
+	if planName == "" {
+		planName = product.Name
+	}
+
+	return projectID, planName, nil
+}
+
+// productProject returns the project a Stripe product belongs to, uuid.Nil if it cannot be attributed
+func (h *StripeWebhookHandler) productProject(ctx context.Context, product *stripe.Product, existing *database.RegisteredProduct) (uuid.UUID, error) {
+	if project, ok := projectFromContext(ctx); ok {
+		return project.ID, nil
+	}
+
+	projectID, err := uuid.Parse(product.Metadata["project_id"])
+	if err != nil {
+		projectID, err = h.projectByName(ctx, product.Metadata["project_name"])
+		if err != nil || projectID != uuid.Nil {
+			return projectID, err
+		}
+		if existing != nil {
+			return existing.ProjectID, nil
+		}
+		return uuid.Nil, nil
+	}
+
+	// Metadata naming a project that does not exist would fail the event on every attempt
+	_, err = h.db.GetProjectByID(ctx, projectID)
+	if errors.Is(err, database.ErrNotFound) {
+		log.Printf("Skipping unknown project %s named in the metadata of product %s", projectID, product.ID)
+		return uuid.Nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error loading project %s: %w", projectID, err)
+	}
+	return projectID, nil
+}
+
+// projectByName returns the project with exactly the given name, uuid.Nil if no project or several have it
+func (h *StripeWebhookHandler) projectByName(ctx context.Context, name string) (uuid.UUID, error) {
+	if name == "" {
+		return uuid.Nil, nil
+	}
+
+	projects, err := h.db.ListProjects(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error loading projects: %w", err)
+	}
+
+	projectID := uuid.Nil
+	for _, project := range projects {
+		if project.Name != name {
+			continue
+		}
+		if projectID != uuid.Nil {
+			log.Printf("Skipping project name '%s' shared by several projects", name)
+			return uuid.Nil, nil
+		}
+		projectID = project.ID
+	}
+	return projectID, nil
+}
+
+// ownsProduct reports whether the project an event was received for may change a catalog entry.
+// Events on the service-wide endpoint may change any entry; events on a project's own endpoint
+// only change the project's entries, so a project cannot alter another project's plans.
+func (h *StripeWebhookHandler) ownsProduct(ctx context.Context, stripeProductID string) (bool, error) {
+	project, ok := projectFromContext(ctx)
+	if !ok {
+		return true, nil
+	}
+
+	existing, err := h.db.GetRegisteredProductByStripeID(ctx, stripeProductID)
+	if errors.Is(err, database.ErrNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error loading product %s: %w", stripeProductID, err)
+	}
+	if existing.ProjectID != project.ID {
+		log.Printf("Skipping product %s of another project for project %s", stripeProductID, project.ID)
+		return false, nil
+	}
+	return true, nil
+}
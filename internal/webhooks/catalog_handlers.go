@@ -0,0 +1,132 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// handleProductEvent mirrors a product created or updated in Stripe into registered_products
+func (h *StripeWebhookHandler) handleProductEvent(ctx context.Context, event stripe.Event) error {
+	var product stripe.Product
+	if err := json.Unmarshal(event.Data.Raw, &product); err != nil {
+		return fmt.Errorf("error unmarshaling product event: %w", err)
+	}
+
+	existing, err := h.db.GetRegisteredProductByStripeID(ctx, product.ID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return fmt.Errorf("error loading product %s: %w", product.ID, err)
+	}
+
+	projectID, planName, err := h.attributeProduct(ctx, &product, existing)
+	if err != nil {
+		return err
+	}
+	if projectID == uuid.Nil {
+		log.Printf("Skipping product %s, no project_id or project_name metadata to attribute it to a project", product.ID)
+		return nil
+	}
+
+	eventAt := time.Unix(event.Created, 0)
+	catalogEntry := &database.RegisteredProduct{
+		ProjectID:       projectID,
+		PlanName:        planName,
+		StripeProductID: product.ID,
+		Description:     product.Description,
+		Active:          product.Active,
+	}
+	if !product.Active {
+		catalogEntry.ArchivedAt = &eventAt
+	}
+
+	applied, err := h.db.SyncRegisteredProduct(ctx, catalogEntry, eventAt)
+	if err != nil {
+		return fmt.Errorf("error syncing product %s: %w", product.ID, err)
+	}
+	if !applied {
+		log.Printf("Skipping event %s for product %s, it is stale or the product belongs to another project", event.ID, product.ID)
+		return nil
+	}
+
+	log.Printf("Synced product %s as plan '%s' of project %s (active: %v)", product.ID, planName, projectID, product.Active)
+	return nil
+}
+
+// handleProductDeleted archives a product deleted in Stripe
+func (h *StripeWebhookHandler) handleProductDeleted(ctx context.Context, event stripe.Event) error {
+	var product stripe.Product
+	if err := json.Unmarshal(event.Data.Raw, &product); err != nil {
+		return fmt.Errorf("error unmarshaling product event: %w", err)
+	}
+
+	owned, err := h.ownsProduct(ctx, product.ID)
+	if err != nil || !owned {
+		return err
+	}
+
+	known, err := h.db.ArchiveRegisteredProduct(ctx, product.ID, time.Unix(event.Created, 0))
+	if err != nil {
+		return fmt.Errorf("error archiving product %s: %w", product.ID, err)
+	}
+	if !known {
+		log.Printf("Skipping deletion of unknown product %s", product.ID)
+		return nil
+	}
+
+	log.Printf("Archived deleted product %s", product.ID)
+	return nil
+}
+
+// handlePriceEvent keeps the monthly and yearly prices of a registered product current.
+// Active recurring prices take their interval's slot; an archived price is removed from its slot.
+func (h *StripeWebhookHandler) handlePriceEvent(ctx context.Context, event stripe.Event) error {
+	var price stripe.Price
+	if err := json.Unmarshal(event.Data.Raw, &price); err != nil {
+		return fmt.Errorf("error unmarshaling price event: %w", err)
+	}
+
+	if price.Product == nil || price.Product.ID == "" {
+		return fmt.Errorf("price %s has no product", price.ID)
+	}
+	if price.Recurring == nil {
+		log.Printf("Skipping one-time price %s of product %s", price.ID, price.Product.ID)
+		return nil
+	}
+
+	interval := string(price.Recurring.Interval)
+	if interval != database.PriceIntervalMonth && interval != database.PriceIntervalYear {
+		log.Printf("Skipping price %s of product %s with unsupported interval '%s'", price.ID, price.Product.ID, interval)
+		return nil
+	}
+
+	owned, err := h.ownsProduct(ctx, price.Product.ID)
+	if err != nil || !owned {
+		return err
+	}
+
+	eventAt := time.Unix(event.Created, 0)
+	var changed bool
+	if price.Active {
+		changed, err = h.db.SetRegisteredProductPrice(ctx, price.Product.ID, interval, price.ID, price.UnitAmount, string(price.Currency), eventAt)
+	} else {
+		changed, err = h.db.ClearRegisteredProductPrice(ctx, price.Product.ID, interval, price.ID, eventAt)
+	}
+	if err != nil {
+		return fmt.Errorf("error syncing price %s: %w", price.ID, err)
+	}
+	if !changed {
+		log.Printf("Skipping event %s for price %s, it is stale or product %s is not in the catalog or uses another price",
+			event.ID, price.ID, price.Product.ID)
+		return nil
+	}
+
+	log.Printf("Synced %sly price %s of product %s (active: %v)", interval, price.ID, price.Product.ID, price.Active)
+	return nil
+}
@@ -0,0 +1,166 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// handleChargeRefunded records the refunds of a charge and flags a fully refunded order
+func (h *StripeWebhookHandler) handleChargeRefunded(ctx context.Context, event stripe.Event) error {
+	var charge stripe.Charge
+	if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+		return fmt.Errorf("error unmarshaling charge event: %w", err)
+	}
+
+	var invoiceID string
+	if charge.Invoice != nil {
+		invoiceID = charge.Invoice.ID
+	}
+	links, err := h.loadChargeLinks(ctx, charge.ID, paymentIntentID(charge.PaymentIntent), invoiceID)
+	if err != nil || links == nil {
+		return err
+	}
+
+	if charge.Refunds != nil {
+		for _, refund := range charge.Refunds.Data {
+			if refund.Charge == nil {
+				refund.Charge = &stripe.Charge{ID: charge.ID}
+			}
+			if refund.PaymentIntent == nil {
+				refund.PaymentIntent = charge.PaymentIntent
+			}
+			if err := h.recordRefund(ctx, links, refund); err != nil {
+				return err
+			}
+		}
+	}
+
+	if links.OrderID != nil {
+		var refundedAt *time.Time
+		if charge.Refunded {
+			eventAt := time.Unix(event.Created, 0)
+			refundedAt = &eventAt
+		}
+		if err := h.db.MarkOrderRefunded(ctx, *links.OrderID, charge.AmountRefunded, refundedAt); err != nil {
+			return fmt.Errorf("error marking order %s refunded: %w", links.OrderID, err)
+		}
+	}
+
+	log.Printf("Charge %s refunded %d of %d (fully refunded: %v)", charge.ID, charge.AmountRefunded, charge.Amount, charge.Refunded)
+	return nil
+}
+
+// handleChargeRefundUpdated records a status change of a single refund
+func (h *StripeWebhookHandler) handleChargeRefundUpdated(ctx context.Context, event stripe.Event) error {
+	var refund stripe.Refund
+	if err := json.Unmarshal(event.Data.Raw, &refund); err != nil {
+		return fmt.Errorf("error unmarshaling refund event: %w", err)
+	}
+	if refund.Charge == nil {
+		return fmt.Errorf("refund %s has no charge", refund.ID)
+	}
+
+	links, err := h.loadChargeLinks(ctx, refund.Charge.ID, paymentIntentID(refund.PaymentIntent), "")
+	if err != nil || links == nil {
+		return err
+	}
+
+	return h.recordRefund(ctx, links, &refund)
+}
+
+// handleDisputeEvent records the latest state of a dispute (created, updated, closed, funds_*)
+func (h *StripeWebhookHandler) handleDisputeEvent(ctx context.Context, event stripe.Event) error {
+	var dispute stripe.Dispute
+	if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+		return fmt.Errorf("error unmarshaling dispute event: %w", err)
+	}
+	if dispute.Charge == nil {
+		return fmt.Errorf("dispute %s has no charge", dispute.ID)
+	}
+
+	links, err := h.loadChargeLinks(ctx, dispute.Charge.ID, paymentIntentID(dispute.PaymentIntent), "")
+	if err != nil || links == nil {
+		return err
+	}
+
+	applied, err := h.db.UpsertDispute(ctx, &database.Dispute{
+		ProjectID:             links.ProjectID,
+		CustomerID:            links.CustomerID,
+		UserID:                links.UserID,
+		StripeDisputeID:       dispute.ID,
+		StripeChargeID:        dispute.Charge.ID,
+		StripePaymentIntentID: paymentIntentID(dispute.PaymentIntent),
+		OrderID:               links.OrderID,
+		InvoiceID:             links.InvoiceID,
+		Amount:                dispute.Amount,
+		Currency:              string(dispute.Currency),
+		Status:                string(dispute.Status),
+		Reason:                string(dispute.Reason),
+		LastEventAt:           time.Unix(event.Created, 0),
+	})
+	if err != nil {
+		return fmt.Errorf("error recording dispute in database: %w", err)
+	}
+	if !applied {
+		log.Printf("Skipping stale event %s (%s) for dispute %s: a newer event was already applied", event.ID, event.Type, dispute.ID)
+		return nil
+	}
+
+	log.Printf("Dispute %s on charge %s is now %s (%s)", dispute.ID, dispute.Charge.ID, dispute.Status, event.Type)
+	return nil
+}
+
+// recordRefund stores a refund against the order or invoice its charge paid for
+func (h *StripeWebhookHandler) recordRefund(ctx context.Context, links *database.ChargeLinks, refund *stripe.Refund) error {
+	err := h.db.UpsertRefund(ctx, &database.Refund{
+		ProjectID:             links.ProjectID,
+		CustomerID:            links.CustomerID,
+		UserID:                links.UserID,
+		StripeRefundID:        refund.ID,
+		StripeChargeID:        refund.Charge.ID,
+		StripePaymentIntentID: paymentIntentID(refund.PaymentIntent),
+		OrderID:               links.OrderID,
+		InvoiceID:             links.InvoiceID,
+		Amount:                refund.Amount,
+		Currency:              string(refund.Currency),
+		Status:                string(refund.Status),
+		Reason:                string(refund.Reason),
+	})
+	if err != nil {
+		return fmt.Errorf("error recording refund %s in database: %w", refund.ID, err)
+	}
+	return nil
+}
+
+// loadChargeLinks finds the order or invoice of a charge, returning nil for charges this service did not
+// create. An event received on a project endpoint only finds the project's orders and invoices.
+func (h *StripeWebhookHandler) loadChargeLinks(ctx context.Context, chargeID, paymentIntentID, invoiceID string) (*database.ChargeLinks, error) {
+	links, err := h.db.GetChargeLinks(ctx, paymentIntentID, invoiceID)
+	if errors.Is(err, database.ErrNotFound) {
+		log.Printf("Skipping charge %s, no order or invoice found for it", chargeID)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading order or invoice of charge %s: %w", chargeID, err)
+	}
+	if project, ok := projectFromContext(ctx); ok && links.ProjectID != project.ID {
+		log.Printf("Skipping charge %s, its order or invoice belongs to another project", chargeID)
+		return nil, nil
+	}
+	return links, nil
+}
+
+// paymentIntentID returns the ID of an optional payment intent
+func paymentIntentID(paymentIntent *stripe.PaymentIntent) string {
+	if paymentIntent == nil {
+		return ""
+	}
+	return paymentIntent.ID
+}
@@ -0,0 +1,97 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/notifications"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// handleCheckoutSessionCompleted records one-time purchases from completed checkout sessions
+func (h *StripeWebhookHandler) handleCheckoutSessionCompleted(ctx context.Context, event stripe.Event) error {
+	var session stripe.CheckoutSession
+	if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+		return fmt.Errorf("error unmarshaling checkout session event: %w", err)
+	}
+
+	// Subscription checkouts are recorded from customer.subscription.* events
+	if session.Mode == stripe.CheckoutSessionModeSubscription {
+		log.Printf("Checkout session %s is a subscription checkout, nothing to record", session.ID)
+		return nil
+	}
+
+	if session.Customer == nil || session.Customer.ID == "" {
+		return fmt.Errorf("checkout session %s has no customer", session.ID)
+	}
+
+	customer, err := h.getCustomerByStripeID(ctx, session.Customer.ID)
+	if err != nil {
+		return fmt.Errorf("customer not found: %s: %w", session.Customer.ID, err)
+	}
+
+	lineItems, err := h.checkoutLineItems(ctx, &session)
+	if err != nil {
+		return fmt.Errorf("error fetching line items for checkout session %s: %w", session.ID, err)
+	}
+
+	order := &database.Order{
+		ProjectID:               customer.ProjectID,
+		CustomerID:              customer.ID,
+		UserID:                  customer.UserID,
+		StripeCheckoutSessionID: session.ID,
+		PaymentType:             session.Metadata["payment_type"],
+		PaymentStatus:           string(session.PaymentStatus),
+		AmountTotal:             session.AmountTotal,
+		Currency:                string(session.Currency),
+	}
+	if session.PaymentIntent != nil {
+		order.StripePaymentIntentID = session.PaymentIntent.ID
+	}
+
+	for _, lineItem := range lineItems {
+		item := &database.OrderItem{
+			Description: lineItem.Description,
+			Quantity:    lineItem.Quantity,
+			AmountTotal: lineItem.AmountTotal,
+			Currency:    string(lineItem.Currency),
+		}
+		if lineItem.Price != nil {
+			item.StripePriceID = lineItem.Price.ID
+			if lineItem.Price.Product != nil {
+				item.StripeProductID = lineItem.Price.Product.ID
+			}
+		}
+		// Single item checkouts carry the product in metadata
+		if item.StripeProductID == "" {
+			item.StripeProductID = session.Metadata["product_id"]
+		}
+		order.Items = append(order.Items, item)
+	}
+
+	if err := h.db.CreateOrder(ctx, order); err != nil {
+		return fmt.Errorf("error creating order in database: %w", err)
+	}
+
+	log.Printf("Recorded order for checkout session %s (user: %s, items: %d)", session.ID, customer.UserID, len(order.Items))
+	h.notify(order.ProjectID, notifications.EventOrderCompleted, order)
+	return nil
+}
+
+// checkoutLineItems returns the session's line items, fetching them from Stripe when the payload omits them
+func (h *StripeWebhookHandler) checkoutLineItems(ctx context.Context, session *stripe.CheckoutSession) ([]*stripe.LineItem, error) {
+	if session.LineItems != nil && len(session.LineItems.Data) > 0 {
+		return session.LineItems.Data, nil
+	}
+
+	var lineItems []*stripe.LineItem
+	iter := h.stripeClient(ctx).CheckoutSessions.ListLineItems(session.ID, &stripe.CheckoutSessionListLineItemsParams{})
+	for iter.Next() {
+		lineItems = append(lineItems, iter.LineItem())
+	}
+
+	return lineItems, iter.Err()
+}
@@ -0,0 +1,89 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/notifications"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// handleCustomerUpdated syncs the email, name and default payment method of a Stripe customer
+func (h *StripeWebhookHandler) handleCustomerUpdated(ctx context.Context, event stripe.Event) error {
+	var stripeCustomer stripe.Customer
+	if err := json.Unmarshal(event.Data.Raw, &stripeCustomer); err != nil {
+		return fmt.Errorf("error unmarshaling customer event: %w", err)
+	}
+
+	customer, err := h.getCustomerByStripeID(ctx, stripeCustomer.ID)
+	if errors.Is(err, database.ErrNotFound) {
+		log.Printf("Skipping update of customer %s, not a customer of this service", stripeCustomer.ID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error loading customer %s: %w", stripeCustomer.ID, err)
+	}
+
+	defaultPaymentMethod := ""
+	if stripeCustomer.InvoiceSettings != nil && stripeCustomer.InvoiceSettings.DefaultPaymentMethod != nil {
+		defaultPaymentMethod = stripeCustomer.InvoiceSettings.DefaultPaymentMethod.ID
+	}
+
+	applied, err := h.db.UpdateCustomerDetails(ctx, customer.ID, stripeCustomer.Email, stripeCustomer.Name,
+		defaultPaymentMethod, time.Unix(event.Created, 0))
+	if err != nil {
+		return fmt.Errorf("error updating customer %s: %w", stripeCustomer.ID, err)
+	}
+	if !applied {
+		log.Printf("Skipping stale event %s for customer %s", event.ID, stripeCustomer.ID)
+		return nil
+	}
+
+	log.Printf("Synced customer %s (user: %s)", stripeCustomer.ID, customer.UserID)
+	h.notifyCustomer(ctx, notifications.EventCustomerUpdated, customer.ProjectID, customer.UserID)
+	return nil
+}
+
+// handleCustomerDeleted detaches a deleted Stripe customer and ends its subscriptions
+func (h *StripeWebhookHandler) handleCustomerDeleted(ctx context.Context, event stripe.Event) error {
+	var stripeCustomer stripe.Customer
+	if err := json.Unmarshal(event.Data.Raw, &stripeCustomer); err != nil {
+		return fmt.Errorf("error unmarshaling customer event: %w", err)
+	}
+
+	customer, err := h.getCustomerByStripeID(ctx, stripeCustomer.ID)
+	if errors.Is(err, database.ErrNotFound) {
+		log.Printf("Skipping deletion of customer %s, already detached or not a customer of this service", stripeCustomer.ID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error loading customer %s: %w", stripeCustomer.ID, err)
+	}
+
+	ended, err := h.db.DetachStripeCustomer(ctx, customer.ID, time.Unix(event.Created, 0))
+	if err != nil {
+		return fmt.Errorf("error detaching customer %s: %w", stripeCustomer.ID, err)
+	}
+
+	for _, stripeSubID := range ended {
+		if err := h.recordSubscriptionHistory(ctx, event, stripeSubID); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Detached deleted customer %s (user: %s), ended %d subscriptions", stripeCustomer.ID, customer.UserID, len(ended))
+	for _, stripeSubID := range ended {
+		h.notifySubscription(ctx, notifications.EventSubscriptionCanceled, stripeSubID)
+	}
+	h.notify(customer.ProjectID, notifications.EventCustomerDeleted, notifications.CustomerDeletedData{
+		UserID:               customer.UserID,
+		StripeCustomerID:     stripeCustomer.ID,
+		EndedSubscriptionIDs: ended,
+	})
+	return nil
+}
@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/dunning"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// startDunning puts the subscription of an invoice whose payment failed into grace
+func (h *StripeWebhookHandler) startDunning(ctx context.Context, event stripe.Event, invoice *stripe.Invoice, customer *database.Customer) error {
+	if invoice.Subscription == nil || invoice.Subscription.ID == "" {
+		return nil
+	}
+
+	sub, err := h.loadDunningSubscription(ctx, invoice.Subscription.ID, customer)
+	if err != nil || sub == nil {
+		return err
+	}
+
+	started, err := h.db.StartDunning(ctx, sub.StripeSubscriptionID, time.Unix(event.Created, 0), invoice.AttemptCount)
+	if err != nil {
+		return fmt.Errorf("error starting dunning for subscription %s: %w", sub.StripeSubscriptionID, err)
+	}
+	if started {
+		log.Printf("Subscription %s entered grace after failed payment of invoice %s", sub.StripeSubscriptionID, invoice.ID)
+		dunning.Announce(h.notifier, sub, sub.DunningState, database.DunningStateGrace)
+	}
+	return nil
+}
+
+// recoverDunning marks the subscription of a paid invoice as recovered if it was in dunning
+func (h *StripeWebhookHandler) recoverDunning(ctx context.Context, invoice *stripe.Invoice, customer *database.Customer) error {
+	if invoice.Subscription == nil || invoice.Subscription.ID == "" {
+		return nil
+	}
+
+	sub, err := h.loadDunningSubscription(ctx, invoice.Subscription.ID, customer)
+	if err != nil || sub == nil {
+		return err
+	}
+
+	switch sub.DunningState {
+	case database.DunningStateGrace, database.DunningStateRetrying, database.DunningStateSuspended:
+	default:
+		return nil
+	}
+
+	recovered, err := h.db.TransitionDunningState(ctx, sub.StripeSubscriptionID, sub.DunningState, database.DunningStateRecovered)
+	if err != nil {
+		return fmt.Errorf("error recovering subscription %s: %w", sub.StripeSubscriptionID, err)
+	}
+	if recovered {
+		log.Printf("Subscription %s recovered from %s after invoice %s was paid", sub.StripeSubscriptionID, sub.DunningState, invoice.ID)
+		dunning.Announce(h.notifier, sub, sub.DunningState, database.DunningStateRecovered)
+	}
+	return nil
+}
+
+// loadDunningSubscription loads the subscription an invoice of customer names, returning nil if it is
+// not stored yet or belongs to another customer
+func (h *StripeWebhookHandler) loadDunningSubscription(ctx context.Context, stripeSubID string, customer *database.Customer) (*database.Subscription, error) {
+	sub, err := h.getSubscriptionByStripeID(ctx, stripeSubID)
+	if errors.Is(err, database.ErrNotFound) {
+		log.Printf("Skipping dunning update, subscription %s not found", stripeSubID)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading subscription %s: %w", stripeSubID, err)
+	}
+	if sub.CustomerID != customer.ID {
+		log.Printf("Skipping dunning update, subscription %s does not belong to customer %s", stripeSubID, customer.StripeCustomerID)
+		return nil, nil
+	}
+	return sub, nil
+}
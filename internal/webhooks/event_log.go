@@ -0,0 +1,59 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// Acknowledgement statuses returned to Stripe
+const (
+	ackQueued    = "queued"
+	ackDuplicate = "duplicate"
+)
+
+// handleEvent durably queues a verified event and acknowledges it; a Queue processes it later
+func (h *StripeWebhookHandler) handleEvent(ctx context.Context, w http.ResponseWriter, event stripe.Event, payload []byte, projectID *uuid.UUID) {
+	if event.ID == "" {
+		utils.WriteErrorResponse(w, http.StatusBadRequest, "invalid_request", "MISSING_EVENT_ID", "Event ID is required", "Stripe events must carry an ID", "id", "", "")
+		return
+	}
+
+	queued, err := h.db.EnqueueStripeEvent(ctx, &database.StripeEvent{
+		ID:        event.ID,
+		ProjectID: projectID,
+		Type:      event.Type,
+		Created:   time.Unix(event.Created, 0),
+		Payload:   payload,
+	})
+	if err != nil {
+		// Not acknowledging the event makes Stripe deliver it again later
+		log.Printf("Error queueing Stripe event %s: %v", event.ID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "The event could not be stored")
+		return
+	}
+
+	if !queued {
+		log.Printf("Skipping already received event %s (%s)", event.ID, event.Type)
+		writeWebhookAck(w, ackDuplicate)
+		return
+	}
+
+	log.Printf("Queued event %s (%s)", event.ID, event.Type)
+	writeWebhookAck(w, ackQueued)
+}
+
+// writeWebhookAck acknowledges receipt of an event to Stripe
+func writeWebhookAck(w http.ResponseWriter, status string) {
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, `{"status": %q}`, status); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
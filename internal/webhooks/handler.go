@@ -1,11 +1,8 @@
 package webhooks
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"time"
@@ -16,107 +13,109 @@ import (
 
 // StripeWebhookHandler handles incoming Stripe webhook events
 type StripeWebhookHandler struct {
-	db     database.RepositoryInterface
-	secret string
+	db           database.RepositoryInterface
+	stripeSecret string
+	verifier     *SignatureVerifier
+	notifier     EventNotifier
 }
 
-// NewStripeWebhookHandler creates a new Stripe webhook handler
+// NewStripeWebhookHandler creates a new Stripe webhook handler.
+// webhookSecret may hold several comma separated secrets while a secret is being rotated.
 func NewStripeWebhookHandler(db database.RepositoryInterface, stripeSecret, webhookSecret string) *StripeWebhookHandler {
 	stripe.Key = stripeSecret
-	
+
 	return &StripeWebhookHandler{
-		db:     db,
-		secret: webhookSecret,
+		db:           db,
+		stripeSecret: stripeSecret,
+		verifier:     NewSignatureVerifier(ParseSecrets(webhookSecret), DefaultSignatureTolerance),
 	}
 }
 
-// HandleWebhook processes incoming Stripe webhook events
-func (h *StripeWebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context() // Use request context for proper cancellation and timeout handling
-	log.Println("Received Stripe webhook event")
+// SetSignatureTolerance overrides how old a signed payload may be before it is rejected
+func (h *StripeWebhookHandler) SetSignatureTolerance(tolerance time.Duration) {
+	h.verifier = NewSignatureVerifier(h.verifier.secrets, tolerance)
+}
 
-	// Set content type for Stripe
-	w.Header().Set("Content-Type", "application/json")
+// processEvent dispatches a Stripe event to the handler for its type. All writes of the event
+// happen in one transaction, and its notifications are only sent once that transaction commits.
+func (h *StripeWebhookHandler) processEvent(ctx context.Context, event stripe.Event) error {
+	log.Printf("Processing event type: %s", event.Type)
 
-	// Read body bytes for potential signature verification
-	bodyBytes, err := io.ReadAll(r.Body)
+	// WithTimeout ensures we have sufficient time for database operations
+	processingCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var pending *pendingNotifier
+	err := h.db.WithTx(processingCtx, func(tx database.RepositoryInterface) error {
+		// A retried transaction starts over with no notifications
+		pending = &pendingNotifier{}
+		return h.inTx(tx, pending).dispatch(processingCtx, event)
+	})
 	if err != nil {
-		log.Printf("Error reading webhook body: %v", err)
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
-	}
-	
-	// Reset body for JSON decoding
-	r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-	
-	signature := r.Header.Get("Stripe-Signature")
-
-	// For development/testing, we can skip signature verification if no secret is set
-	if h.secret == "" {
-		log.Println("Warning: No webhook secret configured, skipping signature verification")
-	} else {
-		// Verify webhook signature (simplified for development)
-		// In production, use stripe.ConstructEvent with proper webhook secret
-		if !h.verifySignature(bodyBytes, signature) {
-			log.Printf("Webhook signature verification failed")
-			http.Error(w, "Invalid signature", http.StatusBadRequest)
-			return
-		}
+		return err
 	}
 
-	// For development without signature verification
-	var event stripe.Event
-	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-		log.Printf("Error decoding webhook event: %v", err)
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
-	}
-	
-	h.processEvent(ctx, w, event)
+	pending.flush(h.notifier)
+	return nil
 }
 
-// verifySignature is a simplified signature verification for development
-func (h *StripeWebhookHandler) verifySignature(body []byte, signature string) bool {
-	// In production, implement proper HMAC verification
-	// For now, accept all signatures in development
-	return true
+// inTx returns a copy of the handler that writes through tx and holds notifications in pending
+func (h *StripeWebhookHandler) inTx(tx database.RepositoryInterface, pending *pendingNotifier) *StripeWebhookHandler {
+	scoped := *h
+	scoped.db = tx
+	if h.notifier != nil {
+		scoped.notifier = pending
+	}
+	return &scoped
 }
 
-// processEvent handles different types of Stripe events
-func (h *StripeWebhookHandler) processEvent(ctx context.Context, w http.ResponseWriter, event stripe.Event) {
-	log.Printf("Processing event type: %s", event.Type)
-
-	// WithTimeout ensures we have sufficient time for database operations
-	processingCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
+// dispatch runs the handler for the event's type
+func (h *StripeWebhookHandler) dispatch(processingCtx context.Context, event stripe.Event) error {
 	switch event.Type {
+	case "checkout.session.completed":
+		return h.handleCheckoutSessionCompleted(processingCtx, event)
 	case "customer.subscription.created":
-		h.handleCustomerSubscriptionCreated(processingCtx, event)
+		return h.handleCustomerSubscriptionCreated(processingCtx, event)
 	case "customer.subscription.updated":
-		h.handleCustomerSubscriptionUpdated(processingCtx, event)
+		return h.handleCustomerSubscriptionUpdated(processingCtx, event)
 	case "customer.subscription.deleted":
-		h.handleCustomerSubscriptionDeleted(processingCtx, event)
+		return h.handleCustomerSubscriptionDeleted(processingCtx, event)
+	case "invoice.finalized", "invoice.paid", "invoice.voided", "invoice.marked_uncollectible":
+		return h.handleInvoiceEvent(processingCtx, event)
 	case "invoice.payment_succeeded":
-		h.handleInvoicePaymentSucceeded(event)
+		return h.handleInvoicePaymentSucceeded(processingCtx, event)
 	case "invoice.payment_failed":
-		h.handleInvoicePaymentFailed(event)
+		return h.handleInvoicePaymentFailed(processingCtx, event)
+	case "charge.refunded":
+		return h.handleChargeRefunded(processingCtx, event)
+	case "charge.refund.updated":
+		return h.handleChargeRefundUpdated(processingCtx, event)
+	case "charge.dispute.created", "charge.dispute.updated", "charge.dispute.closed",
+		"charge.dispute.funds_withdrawn", "charge.dispute.funds_reinstated":
+		return h.handleDisputeEvent(processingCtx, event)
+	case "customer.updated":
+		return h.handleCustomerUpdated(processingCtx, event)
+	case "customer.deleted":
+		return h.handleCustomerDeleted(processingCtx, event)
+	case "product.created", "product.updated":
+		return h.handleProductEvent(processingCtx, event)
+	case "product.deleted":
+		return h.handleProductDeleted(processingCtx, event)
+	case "price.created", "price.updated":
+		return h.handlePriceEvent(processingCtx, event)
 	case "payment_method.attached":
-		h.handlePaymentMethodAttached(event)
+		return h.handlePaymentMethodAttached(event)
 	default:
 		log.Printf("Unhandled event type: %s", event.Type)
 	}
 
-	// Always return 200 OK to acknowledge receipt
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write([]byte(`{"status": "processed"}`));  err != nil {
-		log.Printf("Error writing response: %v", err)
-	}
+	return nil
 }
 
 // SetupRoutes sets up the webhook routes
 func (h *StripeWebhookHandler) SetupRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/webhooks/stripe", h.HandleWebhook)
+	mux.HandleFunc("/webhooks/stripe/{project_id}", h.HandleProjectWebhook)
 }
 
 // HealthCheck returns the health status of the webhook handler
@@ -124,9 +123,9 @@ func (h *StripeWebhookHandler) HealthCheck() error {
 	// Test database connection with a timeout context
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	
-	if err := h.db.InitializeTables(ctx); err != nil {
+
+	if err := h.db.Ping(ctx); err != nil {
 		return fmt.Errorf("database health check failed: %w", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/notifications"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// handleInvoiceEvent records invoice lifecycle events (finalized, paid, voided, marked_uncollectible)
+func (h *StripeWebhookHandler) handleInvoiceEvent(ctx context.Context, event stripe.Event) error {
+	invoice, customer, applied, err := h.recordInvoice(ctx, event)
+	if err != nil || !applied {
+		return err
+	}
+
+	log.Printf("Invoice %s is now %s (%s)", invoice.ID, invoice.Status, event.Type)
+	if event.Type == "invoice.paid" {
+		return h.recoverDunning(ctx, invoice, customer)
+	}
+	return nil
+}
+
+// handleInvoicePaymentSucceeded processes successful payment events
+func (h *StripeWebhookHandler) handleInvoicePaymentSucceeded(ctx context.Context, event stripe.Event) error {
+	invoice, customer, applied, err := h.recordInvoice(ctx, event)
+	if err != nil || !applied {
+		return err
+	}
+
+	log.Printf("Payment succeeded for invoice: %s, amount: %d", invoice.ID, invoice.AmountPaid)
+	if err := h.recoverDunning(ctx, invoice, customer); err != nil {
+		return err
+	}
+	h.notifyInvoice(ctx, notifications.EventInvoicePaymentSucceeded, invoice.ID, invoice.Customer.ID, invoice.AmountPaid)
+	return nil
+}
+
+// handleInvoicePaymentFailed processes failed payment events
+func (h *StripeWebhookHandler) handleInvoicePaymentFailed(ctx context.Context, event stripe.Event) error {
+	invoice, customer, applied, err := h.recordInvoice(ctx, event)
+	if err != nil || !applied {
+		return err
+	}
+
+	log.Printf("Payment failed for invoice: %s, amount: %d", invoice.ID, invoice.AmountDue)
+	if err := h.startDunning(ctx, event, invoice, customer); err != nil {
+		return err
+	}
+	h.notifyInvoice(ctx, notifications.EventInvoicePaymentFailed, invoice.ID, invoice.Customer.ID, invoice.AmountDue)
+	return nil
+}
+
+// recordInvoice stores the invoice carried by an event in the ledger and returns the decoded invoice with
+// its customer. It reports false when a newer event of the invoice was already applied, so the event
+// must have no further effect.
+func (h *StripeWebhookHandler) recordInvoice(ctx context.Context, event stripe.Event) (*stripe.Invoice, *database.Customer, bool, error) {
+	var invoice stripe.Invoice
+	if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
+		return nil, nil, false, fmt.Errorf("error unmarshaling invoice event: %w", err)
+	}
+
+	if invoice.Customer == nil || invoice.Customer.ID == "" {
+		return nil, nil, false, fmt.Errorf("invoice %s has no customer", invoice.ID)
+	}
+
+	customer, err := h.getCustomerByStripeID(ctx, invoice.Customer.ID)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("customer not found: %s: %w", invoice.Customer.ID, err)
+	}
+
+	eventAt := time.Unix(event.Created, 0)
+	record := &database.Invoice{
+		ProjectID:          customer.ProjectID,
+		CustomerID:         customer.ID,
+		UserID:             customer.UserID,
+		StripeInvoiceID:    invoice.ID,
+		Number:             invoice.Number,
+		Status:             string(invoice.Status),
+		AmountDue:          invoice.AmountDue,
+		AmountPaid:         invoice.AmountPaid,
+		AmountRemaining:    invoice.AmountRemaining,
+		Currency:           string(invoice.Currency),
+		HostedInvoiceURL:   invoice.HostedInvoiceURL,
+		InvoicePDF:         invoice.InvoicePDF,
+		PeriodStart:        time.Unix(invoice.PeriodStart, 0),
+		PeriodEnd:          time.Unix(invoice.PeriodEnd, 0),
+		AttemptCount:       invoice.AttemptCount,
+		NextPaymentAttempt: unixTime(invoice.NextPaymentAttempt),
+		PaidAt:             unixTime(invoice.StatusTransitions.PaidAt),
+		LastEventAt:        eventAt,
+	}
+	if invoice.Subscription != nil {
+		record.StripeSubscriptionID = invoice.Subscription.ID
+	}
+	if invoice.PaymentIntent != nil {
+		record.StripePaymentIntentID = invoice.PaymentIntent.ID
+	}
+	if event.Type == "invoice.payment_failed" {
+		record.PaymentFailedAt = &eventAt
+	}
+
+	applied, err := h.db.UpsertInvoice(ctx, record)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("error recording invoice in database: %w", err)
+	}
+	if !applied {
+		log.Printf("Skipping stale event %s (%s) for invoice %s: a newer event was already applied", event.ID, event.Type, invoice.ID)
+	}
+
+	return &invoice, customer, applied, nil
+}
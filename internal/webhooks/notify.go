@@ -0,0 +1,102 @@
+package webhooks
+
+import (
+	"context"
+	"log"
+
+	"github.com/DraconDev/go-stripe-ms/internal/notifications"
+	"github.com/google/uuid"
+)
+
+// EventNotifier forwards normalized billing events to the owning project
+type EventNotifier interface {
+	Notify(projectID uuid.UUID, eventType string, data interface{})
+}
+
+// SetNotifier enables project notifications for processed events
+func (h *StripeWebhookHandler) SetNotifier(notifier EventNotifier) {
+	h.notifier = notifier
+}
+
+// pendingNotifier holds the notifications of an event until its transaction has committed
+type pendingNotifier struct {
+	notifications []pendingNotification
+}
+
+type pendingNotification struct {
+	projectID uuid.UUID
+	eventType string
+	data      interface{}
+}
+
+// Notify records a notification to send once the transaction commits
+func (p *pendingNotifier) Notify(projectID uuid.UUID, eventType string, data interface{}) {
+	p.notifications = append(p.notifications, pendingNotification{projectID, eventType, data})
+}
+
+// flush sends the held notifications in the order they were raised
+func (p *pendingNotifier) flush(notifier EventNotifier) {
+	if notifier == nil {
+		return
+	}
+	for _, n := range p.notifications {
+		notifier.Notify(n.projectID, n.eventType, n.data)
+	}
+}
+
+// notify sends an event to the project if notifications are enabled
+func (h *StripeWebhookHandler) notify(projectID uuid.UUID, eventType string, data interface{}) {
+	if h.notifier == nil {
+		return
+	}
+	h.notifier.Notify(projectID, eventType, data)
+}
+
+// notifySubscription sends the stored state of a subscription to its project
+func (h *StripeWebhookHandler) notifySubscription(ctx context.Context, eventType, stripeSubID string) {
+	if h.notifier == nil {
+		return
+	}
+
+	subscription, err := h.getSubscriptionByStripeID(ctx, stripeSubID)
+	if err != nil {
+		log.Printf("Skipping %s notification, subscription %s not found: %v", eventType, stripeSubID, err)
+		return
+	}
+
+	h.notifier.Notify(subscription.ProjectID, eventType, subscription)
+}
+
+// notifyCustomer sends the stored state of a customer to its project
+func (h *StripeWebhookHandler) notifyCustomer(ctx context.Context, eventType string, projectID uuid.UUID, userID string) {
+	if h.notifier == nil {
+		return
+	}
+
+	customer, err := h.db.GetCustomerByUserID(ctx, projectID, userID)
+	if err != nil {
+		log.Printf("Skipping %s notification, customer of user %s not found: %v", eventType, userID, err)
+		return
+	}
+
+	h.notifier.Notify(projectID, eventType, customer)
+}
+
+// notifyInvoice sends an invoice payment outcome to the project owning the Stripe customer
+func (h *StripeWebhookHandler) notifyInvoice(ctx context.Context, eventType, invoiceID, stripeCustomerID string, amount int64) {
+	if h.notifier == nil {
+		return
+	}
+
+	customer, err := h.getCustomerByStripeID(ctx, stripeCustomerID)
+	if err != nil {
+		log.Printf("Skipping %s notification, customer %s not found: %v", eventType, stripeCustomerID, err)
+		return
+	}
+
+	h.notifier.Notify(customer.ProjectID, eventType, notifications.InvoiceData{
+		StripeInvoiceID: invoiceID,
+		UserID:          customer.UserID,
+		Amount:          amount,
+	})
+}
@@ -0,0 +1,92 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/client"
+)
+
+type contextKey string
+
+// projectKey is the context key for the project an event was received for
+const projectKey contextKey = "project"
+
+// errOtherProject marks records an event received on a project endpoint may not read or write
+var errOtherProject = errors.New("belongs to another project")
+
+// withProject scopes event processing to a project's customers and Stripe account
+func withProject(ctx context.Context, project *database.Project) context.Context {
+	return context.WithValue(ctx, projectKey, project)
+}
+
+// projectFromContext returns the project an event is processed for, if it came in on a project endpoint
+func projectFromContext(ctx context.Context) (*database.Project, bool) {
+	project, ok := ctx.Value(projectKey).(*database.Project)
+	return project, ok
+}
+
+// processStoredEvent decodes a stored event and dispatches it within the scope of its project
+func (h *StripeWebhookHandler) processStoredEvent(ctx context.Context, stored *database.StripeEvent) error {
+	var event stripe.Event
+	if err := json.Unmarshal(stored.Payload, &event); err != nil {
+		return fmt.Errorf("error decoding stored event: %w", err)
+	}
+
+	if stored.ProjectID != nil {
+		project, err := h.db.GetProjectByID(ctx, *stored.ProjectID)
+		if err != nil {
+			return fmt.Errorf("error loading project %s: %w", *stored.ProjectID, err)
+		}
+		ctx = withProject(ctx, project)
+	}
+
+	return h.processEvent(ctx, event)
+}
+
+// stripeClient returns a Stripe API client for the account the event was sent from
+func (h *StripeWebhookHandler) stripeClient(ctx context.Context) *client.API {
+	if project, ok := projectFromContext(ctx); ok && project.StripeSecretKey != "" {
+		return client.New(project.StripeSecretKey, nil)
+	}
+	return client.New(h.stripeSecret, nil)
+}
+
+// getCustomerByStripeID retrieves customer from database by Stripe customer ID,
+// limited to the event's project when it was received on a project endpoint
+func (h *StripeWebhookHandler) getCustomerByStripeID(ctx context.Context, stripeCustomerID string) (*database.Customer, error) {
+	if project, ok := projectFromContext(ctx); ok {
+		return h.db.GetProjectCustomerByStripeID(ctx, project.ID, stripeCustomerID)
+	}
+	return h.db.GetCustomerByStripeID(ctx, stripeCustomerID)
+}
+
+// getSubscriptionByStripeID retrieves a subscription by its Stripe subscription ID. An event received
+// on a project endpoint only sees the project's subscriptions; another project's is not found.
+func (h *StripeWebhookHandler) getSubscriptionByStripeID(ctx context.Context, stripeSubID string) (*database.Subscription, error) {
+	sub, err := h.db.GetSubscriptionByStripeID(ctx, stripeSubID)
+	if err != nil {
+		return nil, err
+	}
+	if project, ok := projectFromContext(ctx); ok && sub.ProjectID != project.ID {
+		return nil, fmt.Errorf("subscription %s %w: %w", stripeSubID, errOtherProject, database.ErrNotFound)
+	}
+	return sub, nil
+}
+
+// checkSubscriptionProject fails when an event received on a project endpoint names another project's
+// subscription, so its writes by Stripe subscription ID cannot reach that project
+func (h *StripeWebhookHandler) checkSubscriptionProject(ctx context.Context, stripeSubID string) error {
+	if _, ok := projectFromContext(ctx); !ok {
+		return nil
+	}
+	_, err := h.getSubscriptionByStripeID(ctx, stripeSubID)
+	if errors.Is(err, errOtherProject) || !errors.Is(err, database.ErrNotFound) {
+		return err
+	}
+	return nil
+}
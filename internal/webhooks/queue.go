@@ -0,0 +1,191 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+)
+
+// Default queue settings
+const (
+	DefaultQueueWorkers      = 4
+	DefaultQueuePollInterval = time.Second
+	DefaultQueueMaxAttempts  = 8
+	DefaultQueueBaseBackoff  = 5 * time.Second
+	DefaultQueueMaxBackoff   = time.Hour
+	DefaultQueueLease        = 5 * time.Minute
+)
+
+// QueueConfig configures the event processing queue; zero values fall back to the defaults
+type QueueConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	MaxAttempts  int           // Attempts before an event is dead-lettered
+	BaseBackoff  time.Duration // Delay before the first retry, doubled for each further attempt
+	MaxBackoff   time.Duration
+	Lease        time.Duration // How long a claimed event stays locked to its worker
+}
+
+// Queue processes stored Stripe events with a pool of workers
+type Queue struct {
+	handler *StripeWebhookHandler
+	config  QueueConfig
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewQueue creates a queue that processes events through the handler
+func NewQueue(handler *StripeWebhookHandler, config QueueConfig) *Queue {
+	if config.Workers <= 0 {
+		config.Workers = DefaultQueueWorkers
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultQueuePollInterval
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultQueueMaxAttempts
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = DefaultQueueBaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultQueueMaxBackoff
+	}
+	if config.Lease <= 0 {
+		config.Lease = DefaultQueueLease
+	}
+
+	return &Queue{
+		handler: handler,
+		config:  config,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start launches the worker pool
+func (q *Queue) Start() {
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	log.Printf("Webhook queue started with %d workers", q.config.Workers)
+}
+
+// Stop stops claiming new events and waits for in-flight events until ctx expires
+func (q *Queue) Stop(ctx context.Context) error {
+	close(q.stop)
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work claims and processes events one at a time, sleeping while the queue is empty
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		processed, err := q.processNext(context.Background())
+		if err != nil {
+			log.Printf("Error claiming queued events: %v", err)
+		}
+		if processed {
+			continue
+		}
+
+		select {
+		case <-q.stop:
+			return
+		case <-time.After(q.config.PollInterval):
+		}
+	}
+}
+
+// ProcessPending processes due events until none are left and returns how many were handled
+func (q *Queue) ProcessPending(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		processed, err := q.processNext(ctx)
+		if err != nil || !processed {
+			return count, err
+		}
+		count++
+	}
+}
+
+// processNext claims a single due event and processes it, reporting whether one was claimed
+func (q *Queue) processNext(ctx context.Context) (bool, error) {
+	events, err := q.handler.db.ClaimStripeEvents(ctx, 1, q.config.Lease)
+	if err != nil || len(events) == 0 {
+		return false, err
+	}
+
+	q.process(ctx, events[0])
+	return true, nil
+}
+
+// process runs a claimed event through the handler and records the outcome
+func (q *Queue) process(ctx context.Context, stored *database.StripeEvent) {
+	err := q.handler.processStoredEvent(ctx, stored)
+	if err == nil {
+		if markErr := q.handler.db.MarkStripeEventProcessed(ctx, stored.ID); markErr != nil {
+			log.Printf("Error marking event %s as processed: %v", stored.ID, markErr)
+		}
+		return
+	}
+
+	if _, markErr := q.fail(ctx, stored, err); markErr != nil {
+		log.Printf("Error recording failure of event %s: %v", stored.ID, markErr)
+	}
+}
+
+// fail records a processing failure of a claimed event: it is retried after a backoff until its
+// attempts are exhausted, then dead-lettered. It returns the status the event was left in.
+func (q *Queue) fail(ctx context.Context, stored *database.StripeEvent, err error) (string, error) {
+	if stored.Attempts >= q.config.MaxAttempts {
+		log.Printf("Dead-lettering event %s (%s) after %d attempts: %v", stored.ID, stored.Type, stored.Attempts, err)
+		if markErr := q.handler.db.MarkStripeEventDeadLetter(ctx, stored.ID, err.Error()); markErr != nil {
+			return "", fmt.Errorf("error dead-lettering event %s: %w", stored.ID, markErr)
+		}
+		return database.EventStatusDeadLetter, nil
+	}
+
+	delay := q.backoff(stored.Attempts)
+	log.Printf("Error processing event %s (%s), attempt %d/%d, retrying in %s: %v",
+		stored.ID, stored.Type, stored.Attempts, q.config.MaxAttempts, delay, err)
+	if markErr := q.handler.db.MarkStripeEventFailed(ctx, stored.ID, err.Error(), time.Now().Add(delay)); markErr != nil {
+		return "", fmt.Errorf("error marking event %s as failed: %w", stored.ID, markErr)
+	}
+	return database.EventStatusFailed, nil
+}
+
+// backoff returns the delay before the next attempt: BaseBackoff * 2^(attempt-1), capped at MaxBackoff
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.config.BaseBackoff
+	for i := 1; i < attempt && delay < q.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.config.MaxBackoff {
+		delay = q.config.MaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,110 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/handlers/utils"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// HandleWebhook processes incoming Stripe webhook events of the service-wide Stripe account
+func (h *StripeWebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received Stripe webhook event")
+
+	// Set content type for Stripe
+	w.Header().Set("Content-Type", "application/json")
+
+	h.receive(w, r, h.verifier, nil)
+}
+
+// HandleProjectWebhook processes Stripe webhook events sent by a project's own Stripe account
+// to /webhooks/stripe/{project_id}, verified with the project's webhook secret
+func (h *StripeWebhookHandler) HandleProjectWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	projectID, err := uuid.Parse(r.PathValue("project_id"))
+	if err != nil {
+		utils.WriteErrorResponse(w, http.StatusNotFound, "invalid_request", "PROJECT_NOT_FOUND", "Project not found", "Invalid project ID", "project_id", "", "")
+		return
+	}
+	log.Printf("Received Stripe webhook event for project %s", projectID)
+
+	project, err := h.db.GetProjectByID(r.Context(), projectID)
+	if errors.Is(err, database.ErrNotFound) || (err == nil && !project.IsActive) {
+		utils.WriteErrorResponse(w, http.StatusNotFound, "invalid_request", "PROJECT_NOT_FOUND", "Project not found", "No active project has this ID", "project_id", "", "")
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading project %s: %v", projectID, err)
+		utils.WriteDatabaseErrorResponse(w, err, "The project could not be loaded")
+		return
+	}
+
+	// Unlike the service-wide endpoint, a project endpoint is never left unverified
+	verifier := NewSignatureVerifier(ParseSecrets(project.StripeWebhookSecret), h.verifier.tolerance)
+	if !verifier.Enabled() {
+		utils.WriteErrorResponse(w, http.StatusNotFound, "invalid_request", "WEBHOOK_NOT_CONFIGURED", "Webhook not configured", "The project has no Stripe webhook secret", "project_id", "", "")
+		return
+	}
+
+	h.receive(w, r, verifier, &project.ID)
+}
+
+// receive verifies a webhook request and queues its event, attributing it to projectID when set
+func (h *StripeWebhookHandler) receive(w http.ResponseWriter, r *http.Request, verifier *SignatureVerifier, projectID *uuid.UUID) {
+	ctx := r.Context() // Use request context for proper cancellation and timeout handling
+
+	// Read body bytes for potential signature verification
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading webhook body: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	// Reset body for JSON decoding
+	r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+
+	signature := r.Header.Get("Stripe-Signature")
+
+	// For development/testing, we can skip signature verification if no secret is set
+	if !verifier.Enabled() {
+		log.Println("Warning: No webhook secret configured, skipping signature verification")
+	} else if err := verifier.Verify(bodyBytes, signature); err != nil {
+		log.Printf("Webhook signature verification failed: %v", err)
+		writeSignatureError(w, err)
+		return
+	}
+
+	var event stripe.Event
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		log.Printf("Error decoding webhook event: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	h.handleEvent(ctx, w, event, bodyBytes, projectID)
+}
+
+// writeSignatureError maps a signature verification failure to a distinct error code
+func writeSignatureError(w http.ResponseWriter, err error) {
+	code := "INVALID_SIGNATURE"
+	message := "Invalid signature"
+	switch {
+	case errors.Is(err, ErrMalformedSignatureHeader):
+		code = "MALFORMED_SIGNATURE_HEADER"
+		message = "Malformed Stripe-Signature header"
+	case errors.Is(err, ErrTimestampExpired):
+		code = "SIGNATURE_EXPIRED"
+		message = "Signature timestamp outside tolerance"
+	}
+
+	utils.WriteErrorResponse(w, http.StatusBadRequest, "signature_error", code, message, err.Error(), "", "", "")
+}
@@ -0,0 +1,66 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+)
+
+// replayLease is how long a replayed event is held before a worker may claim it again
+const replayLease = 5 * time.Minute
+
+// ReplayEvent runs a stored event through the same dispatch as the queue, whatever its status,
+// and records who triggered it. Handlers upsert by Stripe ID and skip events older than the
+// stored state, so replaying an event again leaves the same result. A failed replay of a
+// dead-lettered event leaves it dead-lettered; any other event goes back to the queue's retries
+// with the attempts it has left. It returns the event with its new status.
+func (q *Queue) ReplayEvent(ctx context.Context, eventID, triggeredBy string) (*database.StripeEvent, error) {
+	h := q.handler
+	previous, err := h.db.GetStripeEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := h.db.ClaimStripeEvent(ctx, eventID, replayLease)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.processStoredEvent(ctx, stored)
+
+	action := &database.StripeEventAction{
+		EventID:     stored.ID,
+		Action:      database.EventActionReplay,
+		TriggeredBy: triggeredBy,
+		Result:      database.EventStatusProcessed,
+	}
+	if err != nil {
+		log.Printf("Replay of event %s (%s) by %s failed: %v", stored.ID, stored.Type, triggeredBy, err)
+		action.Error = err.Error()
+		if previous.Status == database.EventStatusDeadLetter {
+			action.Result = database.EventStatusDeadLetter
+			if markErr := h.db.MarkStripeEventDeadLetter(ctx, stored.ID, err.Error()); markErr != nil {
+				return nil, fmt.Errorf("error dead-lettering event %s: %w", stored.ID, markErr)
+			}
+		} else {
+			result, markErr := q.fail(ctx, stored, err)
+			if markErr != nil {
+				return nil, markErr
+			}
+			action.Result = result
+		}
+	} else {
+		log.Printf("Replayed event %s (%s) triggered by %s", stored.ID, stored.Type, triggeredBy)
+		if markErr := h.db.MarkStripeEventProcessed(ctx, stored.ID); markErr != nil {
+			return nil, fmt.Errorf("error marking event %s as processed: %w", stored.ID, markErr)
+		}
+	}
+
+	if err := h.db.RecordStripeEventAction(ctx, action); err != nil {
+		log.Printf("Error recording replay of event %s: %v", stored.ID, err)
+	}
+
+	return h.db.GetStripeEvent(ctx, stored.ID)
+}
@@ -3,139 +3,149 @@ package webhooks
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/notifications"
 	"github.com/stripe/stripe-go/v72"
 )
 
 // handleCustomerSubscriptionCreated processes subscription creation events
-func (h *StripeWebhookHandler) handleCustomerSubscriptionCreated(ctx context.Context, event stripe.Event) {
-	var subscription struct {
-		ID       string              `json:"id"`
-		Customer struct{ ID string } `json:"customer"`
-		Status   string              `json:"status"`
-		Items    struct {
-			Data []struct {
-				Price struct {
-					ID      string `json:"id"`
-					Product string `json:"product"`
-				} `json:"price"`
-			} `json:"data"`
-		} `json:"items"`
-		CurrentPeriodStart int64 `json:"current_period_start"`
-		CurrentPeriodEnd   int64 `json:"current_period_end"`
-	}
-
+func (h *StripeWebhookHandler) handleCustomerSubscriptionCreated(ctx context.Context, event stripe.Event) error {
+	var subscription subscriptionPayload
 	if err := json.Unmarshal(event.Data.Raw, &subscription); err != nil {
-		log.Printf("Error unmarshaling subscription event: %v", err)
-		return
+		return fmt.Errorf("error unmarshaling subscription event: %w", err)
 	}
 
 	log.Printf("Subscription created: %s for customer: %s", subscription.ID, subscription.Customer.ID)
 
-	// Get customer details
-	customer, err := h.getCustomerByStripeID(ctx, subscription.Customer.ID)
-	if err != nil {
-		log.Printf("Customer not found: %s", subscription.Customer.ID)
-		return
-	}
-
-	// Extract product and price information
-	var productID, priceID string
-	if len(subscription.Items.Data) > 0 {
-		priceID = subscription.Items.Data[0].Price.ID
-		productID = subscription.Items.Data[0].Price.Product
+	applied, err := h.applySubscriptionEvent(ctx, event, &subscription, subscription.Status)
+	if err != nil || !applied {
+		return err
 	}
 
-	// Create subscription in database
-	err = h.db.CreateSubscription(
-		ctx,
-		customer.ProjectID,
-		customer.ID.String(),
-		subscription.ID,
-		productID,
-		priceID,
-		customer.UserID,
-		subscription.Status,
-		time.Unix(subscription.CurrentPeriodStart, 0),
-		time.Unix(subscription.CurrentPeriodEnd, 0),
-	)
-
-	if err != nil {
-		log.Printf("Error creating subscription in database: %v", err)
-	} else {
-		log.Printf("Successfully created subscription in database")
-	}
+	log.Printf("Successfully created subscription in database")
+	h.notifySubscription(ctx, notifications.EventSubscriptionCreated, subscription.ID)
+	return nil
 }
 
 // handleCustomerSubscriptionUpdated processes subscription update events
-func (h *StripeWebhookHandler) handleCustomerSubscriptionUpdated(ctx context.Context, event stripe.Event) {
-	var subscription struct {
-		ID               string `json:"id"`
-		Status           string `json:"status"`
-		CurrentPeriodEnd int64  `json:"current_period_end"`
-	}
-
+func (h *StripeWebhookHandler) handleCustomerSubscriptionUpdated(ctx context.Context, event stripe.Event) error {
+	var subscription subscriptionPayload
 	if err := json.Unmarshal(event.Data.Raw, &subscription); err != nil {
-		log.Printf("Error unmarshaling subscription event: %v", err)
-		return
+		return fmt.Errorf("error unmarshaling subscription event: %w", err)
 	}
 
 	log.Printf("Subscription updated: %s, status: %s", subscription.ID, subscription.Status)
 
-	// Update subscription status with timeout
-	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	err := h.db.UpdateSubscriptionStatus(
-		timeoutCtx,
-		subscription.ID,
-		subscription.Status,
-		time.Unix(subscription.CurrentPeriodEnd, 0),
-	)
-
-	if err != nil {
-		log.Printf("Error updating subscription in database: %v", err)
-	} else {
-		log.Printf("Successfully updated subscription in database")
+	applied, err := h.applySubscriptionEvent(ctx, event, &subscription, subscription.Status)
+	if err != nil || !applied {
+		return err
 	}
+
+	log.Printf("Successfully updated subscription in database")
+	h.notifySubscription(ctx, notifications.EventSubscriptionUpdated, subscription.ID)
+	return nil
 }
 
 // handleCustomerSubscriptionDeleted processes subscription deletion events
-func (h *StripeWebhookHandler) handleCustomerSubscriptionDeleted(ctx context.Context, event stripe.Event) {
-	var subscription struct {
-		ID               string `json:"id"`
-		CurrentPeriodEnd int64  `json:"current_period_end"`
-	}
-
+func (h *StripeWebhookHandler) handleCustomerSubscriptionDeleted(ctx context.Context, event stripe.Event) error {
+	var subscription subscriptionPayload
 	if err := json.Unmarshal(event.Data.Raw, &subscription); err != nil {
-		log.Printf("Error unmarshaling subscription event: %v", err)
-		return
+		return fmt.Errorf("error unmarshaling subscription event: %w", err)
 	}
 
 	log.Printf("Subscription deleted: %s", subscription.ID)
 
-	// Update subscription status to canceled with timeout
+	applied, err := h.applySubscriptionEvent(ctx, event, &subscription, database.SubscriptionStatusCanceled)
+	if err != nil || !applied {
+		return err
+	}
+
+	log.Printf("Successfully marked subscription as canceled")
+	h.notifySubscription(ctx, notifications.EventSubscriptionCanceled, subscription.ID)
+	return nil
+}
+
+// applySubscriptionEvent writes the subscription state carried by an event, reporting whether it was applied.
+// Full payloads are upserted so events can arrive in any order; payloads without a customer only update
+// the status of an existing subscription. Stale events are logged and skipped without an error.
+func (h *StripeWebhookHandler) applySubscriptionEvent(ctx context.Context, event stripe.Event, subscription *subscriptionPayload, status string) (bool, error) {
+	eventAt := time.Unix(event.Created, 0)
+
+	// Write with timeout
 	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	err := h.db.UpdateSubscriptionStatus(
-		timeoutCtx,
-		subscription.ID,
-		"canceled",
-		time.Unix(subscription.CurrentPeriodEnd, 0),
-	)
+	if err := h.checkSubscriptionProject(timeoutCtx, subscription.ID); err != nil {
+		return false, fmt.Errorf("error checking project of subscription %s: %w", subscription.ID, err)
+	}
 
-	if err != nil {
-		log.Printf("Error updating subscription status to canceled: %v", err)
+	var (
+		applied   bool
+		customer  *database.Customer
+		productID string
+	)
+	if subscription.Customer.ID == "" {
+		var err error
+		applied, err = h.db.UpdateSubscriptionStatus(
+			timeoutCtx,
+			subscription.ID,
+			status,
+			time.Unix(subscription.CurrentPeriodEnd, 0),
+			eventAt,
+		)
+		if err != nil {
+			return false, fmt.Errorf("error updating subscription in database: %w", err)
+		}
 	} else {
-		log.Printf("Successfully marked subscription as canceled")
+		// Get customer details
+		var err error
+		customer, err = h.getCustomerByStripeID(timeoutCtx, subscription.Customer.ID)
+		if err != nil {
+			return false, fmt.Errorf("customer not found: %s: %w", subscription.Customer.ID, err)
+		}
+
+		// Extract product and price information
+		var priceID string
+		if len(subscription.Items.Data) > 0 {
+			priceID = subscription.Items.Data[0].Price.ID
+			productID = subscription.Items.Data[0].Price.Product
+		}
+
+		applied, err = h.db.CreateSubscription(
+			timeoutCtx,
+			customer.ProjectID,
+			customer.ID.String(),
+			subscription.ID,
+			productID,
+			priceID,
+			customer.UserID,
+			status,
+			time.Unix(subscription.CurrentPeriodStart, 0),
+			time.Unix(subscription.CurrentPeriodEnd, 0),
+			eventAt,
+		)
+		if err != nil {
+			return false, fmt.Errorf("error writing subscription to database: %w", err)
+		}
+		if applied {
+			if err := h.syncSubscriptionItems(timeoutCtx, subscription); err != nil {
+				return false, err
+			}
+			if err := h.db.UpdateSubscriptionLifecycle(timeoutCtx, subscription.ID, subscription.lifecycle()); err != nil {
+				return false, fmt.Errorf("error updating subscription lifecycle: %w", err)
+			}
+		}
 	}
-}
 
-// getCustomerByStripeID retrieves customer from database by Stripe customer ID
-func (h *StripeWebhookHandler) getCustomerByStripeID(ctx context.Context, stripeCustomerID string) (*database.Customer, error) {
-	return h.db.GetCustomerByStripeID(ctx, stripeCustomerID)
+	if !applied {
+		return false, h.skipSubscriptionEvent(ctx, event, subscription.ID, status, customer, productID)
+	}
+	if err := h.recordSubscriptionHistory(timeoutCtx, event, subscription.ID); err != nil {
+		return false, err
+	}
+	return true, nil
 }
@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// skipSubscriptionEvent logs why a subscription event was not applied. An unknown subscription is
+// returned as an error so the event is retried once the subscription exists, unless the customer's
+// subscription to the product was already replaced by a newer one. The customer is nil for events
+// without one.
+func (h *StripeWebhookHandler) skipSubscriptionEvent(ctx context.Context, event stripe.Event, stripeSubID, status string, customer *database.Customer, productID string) error {
+	current, err := h.getSubscriptionByStripeID(ctx, stripeSubID)
+	if errors.Is(err, database.ErrNotFound) {
+		replacement, err := h.replacingSubscription(ctx, stripeSubID, customer, productID)
+		if err != nil {
+			return fmt.Errorf("error loading subscription to product %s: %w", productID, err)
+		}
+		if replacement == "" {
+			return fmt.Errorf("subscription not found: %s", stripeSubID)
+		}
+		log.Printf("Skipping stale event %s (%s) for subscription %s: it was replaced by the newer subscription %s",
+			event.ID, event.Type, stripeSubID, replacement)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error loading subscription %s: %w", stripeSubID, err)
+	}
+
+	log.Printf("Skipping stale event %s (%s) for subscription %s: %s",
+		event.ID, event.Type, stripeSubID, staleEventReason(current, status, time.Unix(event.Created, 0)))
+	return nil
+}
+
+// replacingSubscription returns the Stripe ID of the subscription holding the customer's subscription
+// to the product in place of stripeSubID, empty if there is none
+func (h *StripeWebhookHandler) replacingSubscription(ctx context.Context, stripeSubID string, customer *database.Customer, productID string) (string, error) {
+	if customer == nil {
+		return "", nil
+	}
+
+	heldBy, _, _, exists, err := h.db.GetSubscriptionStatus(ctx, customer.ProjectID, customer.UserID, productID)
+	if errors.Is(err, database.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !exists || heldBy == stripeSubID {
+		return "", nil
+	}
+	return heldBy, nil
+}
+
+// staleEventReason describes why an event would move the stored subscription state backwards
+func staleEventReason(current *database.Subscription, status string, eventAt time.Time) string {
+	if current.LastEventAt != nil && eventAt.Before(*current.LastEventAt) {
+		return fmt.Sprintf("event created at %s is older than the last applied event at %s",
+			eventAt.UTC().Format(time.RFC3339), current.LastEventAt.UTC().Format(time.RFC3339))
+	}
+	if database.IsTerminalSubscriptionStatus(current.Status) && !database.IsTerminalSubscriptionStatus(status) {
+		return fmt.Sprintf("subscription is already %s and cannot become %s", current.Status, status)
+	}
+	return "a newer event for the same product was already applied"
+}
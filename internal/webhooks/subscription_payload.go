@@ -0,0 +1,79 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+)
+
+// expandableID is a reference to a Stripe object, sent as its ID or, when expanded, as the object
+type expandableID struct{ ID string }
+
+// UnmarshalJSON accepts both "cus_123" and {"id": "cus_123", ...}
+func (e *expandableID) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &e.ID); err == nil {
+		return nil
+	}
+	var object struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	e.ID = object.ID
+	return nil
+}
+
+// subscriptionPayload is the subset of a Stripe subscription object used by the handlers
+type subscriptionPayload struct {
+	ID       string       `json:"id"`
+	Customer expandableID `json:"customer"`
+	Status   string       `json:"status"`
+	Items    struct {
+		Data []struct {
+			ID       string `json:"id"`
+			Quantity int64  `json:"quantity"`
+			Price    struct {
+				ID      string `json:"id"`
+				Product string `json:"product"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+	CurrentPeriodStart int64 `json:"current_period_start"`
+	CurrentPeriodEnd   int64 `json:"current_period_end"`
+	TrialStart         int64 `json:"trial_start"`
+	TrialEnd           int64 `json:"trial_end"`
+	CancelAtPeriodEnd  bool  `json:"cancel_at_period_end"`
+	CanceledAt         int64 `json:"canceled_at"`
+	EndedAt            int64 `json:"ended_at"`
+	PauseCollection    *struct {
+		Behavior  string `json:"behavior"`
+		ResumesAt int64  `json:"resumes_at"`
+	} `json:"pause_collection"`
+}
+
+// lifecycle extracts the trial, cancellation and pause state; unset Stripe timestamps stay nil
+func (s *subscriptionPayload) lifecycle() database.SubscriptionLifecycle {
+	lifecycle := database.SubscriptionLifecycle{
+		TrialStart:        unixTime(s.TrialStart),
+		TrialEnd:          unixTime(s.TrialEnd),
+		CancelAtPeriodEnd: s.CancelAtPeriodEnd,
+		CanceledAt:        unixTime(s.CanceledAt),
+		EndedAt:           unixTime(s.EndedAt),
+	}
+	if s.PauseCollection != nil {
+		lifecycle.PauseBehavior = s.PauseCollection.Behavior
+		lifecycle.PauseResumesAt = unixTime(s.PauseCollection.ResumesAt)
+	}
+	return lifecycle
+}
+
+// unixTime converts a Stripe timestamp, where 0 means unset, to a time pointer
+func unixTime(timestamp int64) *time.Time {
+	if timestamp == 0 {
+		return nil
+	}
+	t := time.Unix(timestamp, 0)
+	return &t
+}
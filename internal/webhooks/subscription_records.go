@@ -0,0 +1,39 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// recordSubscriptionHistory adds the state an event left a subscription in to its history
+func (h *StripeWebhookHandler) recordSubscriptionHistory(ctx context.Context, event stripe.Event, stripeSubID string) error {
+	if _, err := h.db.RecordSubscriptionEvent(ctx, stripeSubID, event.ID, event.Type, time.Unix(event.Created, 0)); err != nil {
+		return fmt.Errorf("error recording history of subscription %s: %w", stripeSubID, err)
+	}
+	return nil
+}
+
+// syncSubscriptionItems stores every item of the subscription so add-ons and multi-product plans are tracked
+func (h *StripeWebhookHandler) syncSubscriptionItems(ctx context.Context, subscription *subscriptionPayload) error {
+	items := make([]*database.SubscriptionItem, 0, len(subscription.Items.Data))
+	for _, data := range subscription.Items.Data {
+		if data.ID == "" {
+			continue
+		}
+		items = append(items, &database.SubscriptionItem{
+			StripeSubscriptionItemID: data.ID,
+			PriceID:                  data.Price.ID,
+			ProductID:                data.Price.Product,
+			Quantity:                 data.Quantity,
+		})
+	}
+
+	if err := h.db.SyncSubscriptionItems(ctx, subscription.ID, items); err != nil {
+		return fmt.Errorf("error syncing subscription items: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestCatalogProjectIsolation(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		mux := http.NewServeMux()
+		handler.SetupRoutes(mux)
+
+		owner, err := testDB.Repo.CreateProject(ctx, "Catalog Owner", "")
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+		intruder, err := testDB.Repo.CreateProject(ctx, "Catalog Intruder", "")
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+		if err := testDB.Repo.UpdateProjectStripeSettings(ctx, intruder.ID, "sk_test_intruder", testProjectWebhookSecret); err != nil {
+			t.Fatalf("Failed to set Stripe settings: %v", err)
+		}
+
+		if err := testDB.Repo.CreateRegisteredProduct(ctx, &database.RegisteredProduct{
+			ProjectID:          owner.ID,
+			PlanName:           "Pro",
+			StripeProductID:    "prod_test_isolated",
+			StripePriceMonthly: "price_test_isolated",
+			MonthlyAmount:      2900,
+			Currency:           "usd",
+			Features:           []byte("{}"),
+		}); err != nil {
+			t.Fatalf("Failed to register product: %v", err)
+		}
+
+		now := time.Now()
+		renamed := stripe.Event{
+			ID: "evt_test_isolated_product", Type: "product.updated", Created: now.Unix(),
+			Data: &stripe.EventData{Raw: json.RawMessage(`{
+				"id": "prod_test_isolated", "name": "Hijacked", "active": false, "metadata": {"plan_name": "Hijacked"}
+			}`)},
+		}
+		repriced := stripe.Event{
+			ID: "evt_test_isolated_price", Type: "price.created", Created: now.Unix(),
+			Data: &stripe.EventData{Raw: json.RawMessage(`{
+				"id": "price_test_hijacked", "product": "prod_test_isolated", "active": true,
+				"unit_amount": 1, "currency": "usd", "recurring": {"interval": "month"}
+			}`)},
+		}
+		deleted := stripe.Event{
+			ID: "evt_test_isolated_deleted", Type: "product.deleted", Created: now.Unix(),
+			Data: &stripe.EventData{Raw: json.RawMessage(`{"id": "prod_test_isolated", "deleted": true}`)},
+		}
+
+		// Events on the intruder's own endpoint cannot change the owner's plan
+		for _, event := range []stripe.Event{renamed, repriced, deleted} {
+			body, _ := json.Marshal(event)
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe/"+intruder.ID.String(), bytes.NewReader(body))
+			req.Header.Set("Stripe-Signature", webhooks.SignPayload(body, testProjectWebhookSecret, time.Now()))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d for %s, got %d", http.StatusOK, event.ID, w.Code)
+			}
+		}
+
+		// Metadata naming the intruder does not move the owner's plan either
+		deliverEvent(t, handler, stripe.Event{
+			ID: "evt_test_isolated_metadata", Type: "product.updated", Created: now.Add(time.Minute).Unix(),
+			Data: &stripe.EventData{Raw: json.RawMessage(fmt.Sprintf(`{
+				"id": "prod_test_isolated", "name": "Moved", "active": true,
+				"metadata": {"project_id": "%s", "plan_name": "Moved"}
+			}`, intruder.ID))},
+		})
+		processQueuedEvents(t, handler)
+
+		product, err := testDB.Repo.GetRegisteredProductByStripeID(ctx, "prod_test_isolated")
+		if err != nil {
+			t.Fatalf("Failed to get product: %v", err)
+		}
+		if product.ProjectID != owner.ID || product.PlanName != "Pro" || !product.Active {
+			t.Errorf("Expected the owner's active Pro plan to be untouched, got project %s plan '%s' active %v",
+				product.ProjectID, product.PlanName, product.Active)
+		}
+		if product.StripePriceMonthly != "price_test_isolated" || product.MonthlyAmount != 2900 {
+			t.Errorf("Expected the owner's price to be untouched, got %s at %d", product.StripePriceMonthly, product.MonthlyAmount)
+		}
+
+		plans, err := testDB.Repo.GetRegisteredProductsByProject(ctx, intruder.ID)
+		if err != nil {
+			t.Fatalf("Failed to list products: %v", err)
+		}
+		if len(plans) != 0 {
+			t.Errorf("Expected the intruder's catalog to stay empty, got %d plans", len(plans))
+		}
+	})
+}
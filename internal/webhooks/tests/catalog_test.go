@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestCatalogSync(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+
+		project, err := testDB.Repo.CreateProject(ctx, "Catalog Project", "")
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+		for _, name := range []string{"Shared Catalog Project", "Shared Catalog Project"} {
+			if _, err := testDB.Repo.CreateProject(ctx, name, ""); err != nil {
+				t.Fatalf("Failed to create project: %v", err)
+			}
+		}
+
+		now := time.Now()
+		catalogEvent := func(id, eventType string, created time.Time, raw string) stripe.Event {
+			return stripe.Event{
+				ID:      id,
+				Type:    eventType,
+				Created: created.Unix(),
+				Data:    &stripe.EventData{Raw: json.RawMessage(raw)},
+			}
+		}
+
+		metadata := fmt.Sprintf(`{"project_id": "%s", "plan_name": "Pro"}`, project.ID)
+		events := []stripe.Event{
+			catalogEvent("evt_test_product_created", "product.created", now.Add(-3*time.Minute), `{
+				"id": "prod_test_catalog", "name": "Catalog - Pro", "description": "Pro plan", "active": true,
+				"metadata": `+metadata+`
+			}`),
+			catalogEvent("evt_test_price_monthly", "price.created", now.Add(-2*time.Minute), `{
+				"id": "price_test_monthly", "product": "prod_test_catalog", "active": true,
+				"unit_amount": 1900, "currency": "usd", "recurring": {"interval": "month"}
+			}`),
+			catalogEvent("evt_test_price_yearly", "price.created", now.Add(-2*time.Minute), `{
+				"id": "price_test_yearly", "product": "prod_test_catalog", "active": true,
+				"unit_amount": 19000, "currency": "usd", "recurring": {"interval": "year"}
+			}`),
+			catalogEvent("evt_test_price_archived", "price.updated", now.Add(-time.Minute), `{
+				"id": "price_test_yearly", "product": "prod_test_catalog", "active": false,
+				"unit_amount": 19000, "currency": "usd", "recurring": {"interval": "year"}
+			}`),
+			catalogEvent("evt_test_product_archived", "product.updated", now, `{
+				"id": "prod_test_catalog", "name": "Catalog - Pro", "description": "Retired", "active": false,
+				"metadata": `+metadata+`
+			}`),
+			// No metadata and not in the catalog: cannot be attributed to a project
+			catalogEvent("evt_test_product_unattributed", "product.created", now, `{
+				"id": "prod_test_unattributed", "name": "Dashboard product", "active": true
+			}`),
+			// Created in the dashboard with only the project's name
+			catalogEvent("evt_test_product_named", "product.created", now, `{
+				"id": "prod_test_named", "name": "Dashboard - Team", "active": true,
+				"metadata": {"project_name": "Catalog Project", "plan_name": "Team"}
+			}`),
+			// A name shared by several projects cannot be attributed
+			catalogEvent("evt_test_product_ambiguous", "product.created", now, `{
+				"id": "prod_test_ambiguous", "name": "Dashboard - Shared", "active": true,
+				"metadata": {"project_name": "Shared Catalog Project"}
+			}`),
+		}
+		for _, event := range events {
+			deliverEvent(t, handler, event)
+		}
+		processQueuedEvents(t, handler)
+
+		product, err := testDB.Repo.GetRegisteredProductByStripeID(ctx, "prod_test_catalog")
+		if err != nil {
+			t.Fatalf("Failed to get synced product: %v", err)
+		}
+		if product.ProjectID != project.ID || product.PlanName != "Pro" {
+			t.Errorf("Expected product attributed to %s/Pro, got %s/%s", project.ID, product.ProjectID, product.PlanName)
+		}
+		if product.StripePriceMonthly != "price_test_monthly" || product.MonthlyAmount != 1900 {
+			t.Errorf("Expected monthly price price_test_monthly at 1900, got %s at %d", product.StripePriceMonthly, product.MonthlyAmount)
+		}
+		if product.StripePriceYearly != "" {
+			t.Errorf("Expected archived yearly price to be cleared, got %s", product.StripePriceYearly)
+		}
+		if product.Active || product.ArchivedAt == nil {
+			t.Error("Expected product to be archived")
+		}
+		if product.Description != "Retired" {
+			t.Errorf("Expected description 'Retired', got '%s'", product.Description)
+		}
+
+		if _, err := testDB.Repo.GetRegisteredProductByStripeID(ctx, "prod_test_unattributed"); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("Expected unattributed product to be skipped, got %v", err)
+		}
+		named, err := testDB.Repo.GetRegisteredProductByStripeID(ctx, "prod_test_named")
+		if err != nil {
+			t.Fatalf("Failed to get product attributed by project name: %v", err)
+		}
+		if named.ProjectID != project.ID || named.PlanName != "Team" {
+			t.Errorf("Expected product attributed to %s/Team, got %s/%s", project.ID, named.ProjectID, named.PlanName)
+		}
+		if _, err := testDB.Repo.GetRegisteredProductByStripeID(ctx, "prod_test_ambiguous"); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("Expected product with an ambiguous project name to be skipped, got %v", err)
+		}
+
+		// A price event older than the last applied catalog event does not overwrite the newer price
+		deliverEvent(t, handler, catalogEvent("evt_test_price_late", "price.updated", now.Add(-150*time.Second), `{
+			"id": "price_test_monthly_old", "product": "prod_test_catalog", "active": true,
+			"unit_amount": 900, "currency": "usd", "recurring": {"interval": "month"}
+		}`))
+		processQueuedEvents(t, handler)
+
+		product, err = testDB.Repo.GetRegisteredProductByStripeID(ctx, "prod_test_catalog")
+		if err != nil {
+			t.Fatalf("Failed to get synced product: %v", err)
+		}
+		if product.StripePriceMonthly != "price_test_monthly" || product.MonthlyAmount != 1900 {
+			t.Errorf("Expected the late price event to be skipped, got %s at %d", product.StripePriceMonthly, product.MonthlyAmount)
+		}
+
+		// Deleting keeps the catalog entry but leaves it archived
+		deliverEvent(t, handler, catalogEvent("evt_test_product_deleted", "product.deleted", now.Add(time.Minute), `{
+			"id": "prod_test_catalog", "deleted": true
+		}`))
+		processQueuedEvents(t, handler)
+
+		product, err = testDB.Repo.GetRegisteredProductByStripeID(ctx, "prod_test_catalog")
+		if err != nil {
+			t.Fatalf("Failed to get deleted product: %v", err)
+		}
+		if product.Active {
+			t.Error("Expected deleted product to stay archived")
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestCheckoutSessionCompletedCreatesOrder(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+
+		event := stripe.Event{
+			ID:      "evt_test_checkout_completed",
+			Type:    "checkout.session.completed",
+			Created: time.Now().Unix(),
+			Data: &stripe.EventData{
+				Raw: json.RawMessage(`{
+					"id": "cs_test_cart_123",
+					"object": "checkout.session",
+					"mode": "payment",
+					"customer": "` + customer.StripeCustomerID + `",
+					"payment_intent": "pi_test_123",
+					"payment_status": "paid",
+					"amount_total": 4500,
+					"currency": "usd",
+					"metadata": {"user_id": "` + customer.UserID + `", "payment_type": "cart"},
+					"line_items": {
+						"object": "list",
+						"data": [
+							{"id": "li_1", "quantity": 2, "amount_total": 3000, "currency": "usd",
+							 "price": {"id": "price_test_a", "product": "prod_test_a"}},
+							{"id": "li_2", "quantity": 1, "amount_total": 1500, "currency": "usd",
+							 "price": {"id": "price_test_b", "product": "prod_test_b"}}
+						]
+					}
+				}`),
+			},
+		}
+
+		w := httptest.NewRecorder()
+		handler.HandleWebhook(w, newSignedWebhookRequest(event))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		processQueuedEvents(t, handler)
+
+		orders, err := testDB.Repo.GetOrdersByUserID(ctx, project.ID, customer.UserID)
+		if err != nil {
+			t.Fatalf("Failed to get orders: %v", err)
+		}
+		if len(orders) != 1 {
+			t.Fatalf("Expected 1 order, got %d", len(orders))
+		}
+
+		order := orders[0]
+		if order.StripeCheckoutSessionID != "cs_test_cart_123" {
+			t.Errorf("Expected checkout session 'cs_test_cart_123', got '%s'", order.StripeCheckoutSessionID)
+		}
+		if order.PaymentType != "cart" || order.PaymentStatus != "paid" || order.AmountTotal != 4500 {
+			t.Errorf("Unexpected order fields: type=%s status=%s total=%d", order.PaymentType, order.PaymentStatus, order.AmountTotal)
+		}
+		if len(order.Items) != 2 {
+			t.Fatalf("Expected 2 order items, got %d", len(order.Items))
+		}
+		if order.Items[0].StripeProductID != "prod_test_a" || order.Items[0].Quantity != 2 {
+			t.Errorf("Unexpected first item: product=%s quantity=%d", order.Items[0].StripeProductID, order.Items[0].Quantity)
+		}
+	})
+}
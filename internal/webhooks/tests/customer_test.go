@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/notifications"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// recordingNotifier collects the event types sent to projects
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (n *recordingNotifier) Notify(projectID uuid.UUID, eventType string, data interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, eventType)
+}
+
+func TestCustomerUpdatedAndDeleted(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		notifier := &recordingNotifier{}
+		handler.SetNotifier(notifier)
+
+		now := time.Now()
+		customerEvent := func(id, eventType string, created time.Time, raw string) stripe.Event {
+			return stripe.Event{
+				ID:      id,
+				Type:    eventType,
+				Created: created.Unix(),
+				Data:    &stripe.EventData{Raw: json.RawMessage(raw)},
+			}
+		}
+
+		// The newer update arrives first; the older one must not overwrite it
+		events := []stripe.Event{
+			customerEvent("evt_test_customer_new", "customer.updated", now, `{
+				"id": "`+customer.StripeCustomerID+`",
+				"email": "new@example.com",
+				"name": "New Name",
+				"invoice_settings": {"default_payment_method": "pm_test_new"}
+			}`),
+			customerEvent("evt_test_customer_old", "customer.updated", now.Add(-time.Minute), `{
+				"id": "`+customer.StripeCustomerID+`",
+				"email": "old@example.com",
+				"name": "Old Name"
+			}`),
+		}
+		for _, event := range events {
+			deliverEvent(t, handler, event)
+			processQueuedEvents(t, handler)
+		}
+
+		updated, err := testDB.Repo.GetCustomerByUserID(ctx, project.ID, customer.UserID)
+		if err != nil {
+			t.Fatalf("Failed to get customer: %v", err)
+		}
+		if updated.Email != "new@example.com" || updated.Name != "New Name" || updated.DefaultPaymentMethod != "pm_test_new" {
+			t.Errorf("Expected customer synced from newest event, got email=%s name=%s payment method=%s",
+				updated.Email, updated.Name, updated.DefaultPaymentMethod)
+		}
+
+		deliverEvent(t, handler, customerEvent("evt_test_customer_deleted", "customer.deleted", now.Add(time.Minute), `{
+			"id": "`+customer.StripeCustomerID+`",
+			"deleted": true
+		}`))
+		processQueuedEvents(t, handler)
+
+		deleted, err := testDB.Repo.GetCustomerByUserID(ctx, project.ID, customer.UserID)
+		if err != nil {
+			t.Fatalf("Failed to get customer: %v", err)
+		}
+		if deleted.StripeCustomerID != "" {
+			t.Errorf("Expected Stripe customer ID to be detached, got %s", deleted.StripeCustomerID)
+		}
+		if _, err := testDB.Repo.GetCustomerByStripeID(ctx, customer.StripeCustomerID); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("Expected no customer for deleted Stripe ID, got %v", err)
+		}
+
+		// CreateTestData gives the customer an active subscription
+		stripeSubID, _, _, _, err := testDB.Repo.GetSubscriptionStatus(ctx, project.ID, customer.UserID, "premium_plan")
+		if err != nil {
+			t.Fatalf("Failed to get subscription status: %v", err)
+		}
+		subscription, err := testDB.Repo.GetSubscriptionByStripeID(ctx, stripeSubID)
+		if err != nil {
+			t.Fatalf("Failed to get subscription: %v", err)
+		}
+		if subscription.Status != database.SubscriptionStatusCanceled || subscription.EndedAt == nil {
+			t.Errorf("Expected subscription to end with the deleted customer, got status '%s'", subscription.Status)
+		}
+
+		notifier.mu.Lock()
+		defer notifier.mu.Unlock()
+		expected := map[string]bool{
+			notifications.EventCustomerUpdated:      false,
+			notifications.EventSubscriptionCanceled: false,
+			notifications.EventCustomerDeleted:      false,
+		}
+		for _, eventType := range notifier.events {
+			if _, ok := expected[eventType]; ok {
+				expected[eventType] = true
+			}
+		}
+		for eventType, sent := range expected {
+			if !sent {
+				t.Errorf("Expected a %s notification", eventType)
+			}
+		}
+	})
+}
+
+// deliverEvent sends a signed event to the webhook endpoint and expects it to be accepted
+func deliverEvent(t *testing.T, handler *webhooks.StripeWebhookHandler, event stripe.Event) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.HandleWebhook(w, newSignedWebhookRequest(event))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d for %s, got %d", http.StatusOK, event.ID, w.Code)
+	}
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/dunning"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestDunningLifecycle(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+		if err := testDB.Repo.UpdateProjectDunningSettings(ctx, project.ID, 3, 2); err != nil {
+			t.Fatalf("Failed to update dunning settings: %v", err)
+		}
+
+		start := time.Now().Add(-time.Hour).Truncate(time.Second)
+		if _, err := testDB.Repo.CreateSubscription(ctx, project.ID, customer.ID.String(), "sub_test_dunning", "prod_test_dunning", "price_test_dunning",
+			customer.UserID, "past_due", start, start.Add(30*24*time.Hour), start); err != nil {
+			t.Fatalf("Failed to create subscription: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		evaluator := dunning.NewEvaluator(testDB.Repo, nil, 0)
+
+		sendInvoiceEvent := func(t *testing.T, invoiceID, eventType, fields string, created time.Time) {
+			t.Helper()
+			event := stripe.Event{
+				ID:      "evt_test_dunning_" + invoiceID + "_" + eventType + "_" + createTimestamp(created),
+				Type:    eventType,
+				Created: created.Unix(),
+				Data: &stripe.EventData{
+					Raw: json.RawMessage(`{
+						"id": "` + invoiceID + `",
+						"customer": "` + customer.StripeCustomerID + `",
+						"subscription": "sub_test_dunning",
+						"amount_due": 2900,
+						"currency": "usd",
+						` + fields + `
+					}`),
+				},
+			}
+
+			w := httptest.NewRecorder()
+			handler.HandleWebhook(w, newSignedWebhookRequest(event))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+			}
+			processQueuedEvents(t, handler)
+		}
+
+		expectState := func(t *testing.T, expected string, accessGranted bool) {
+			t.Helper()
+			sub, err := testDB.Repo.GetSubscriptionByStripeID(ctx, "sub_test_dunning")
+			if err != nil {
+				t.Fatalf("Failed to get subscription: %v", err)
+			}
+			if sub.DunningState != expected {
+				t.Errorf("Expected dunning state '%s', got '%s'", expected, sub.DunningState)
+			}
+			if granted := dunning.AccessGranted(sub); granted != accessGranted {
+				t.Errorf("Expected access granted %v, got %v", accessGranted, granted)
+			}
+		}
+
+		t.Run("payment failure starts grace", func(t *testing.T) {
+			sendInvoiceEvent(t, "in_test_dunning", "invoice.payment_failed", `"status": "open", "attempt_count": 1`, start)
+			expectState(t, database.DunningStateGrace, true)
+		})
+
+		t.Run("grace period ends", func(t *testing.T) {
+			if _, err := evaluator.Evaluate(ctx, start.AddDate(0, 0, 1)); err != nil {
+				t.Fatalf("Failed to evaluate dunning: %v", err)
+			}
+			expectState(t, database.DunningStateGrace, true)
+
+			if _, err := evaluator.Evaluate(ctx, start.AddDate(0, 0, 4)); err != nil {
+				t.Fatalf("Failed to evaluate dunning: %v", err)
+			}
+			expectState(t, database.DunningStateRetrying, false)
+		})
+
+		t.Run("retries exhausted suspends", func(t *testing.T) {
+			sendInvoiceEvent(t, "in_test_dunning", "invoice.payment_failed", `"status": "open", "attempt_count": 2`, start.Add(time.Minute))
+			if _, err := evaluator.Evaluate(ctx, start.AddDate(0, 0, 5)); err != nil {
+				t.Fatalf("Failed to evaluate dunning: %v", err)
+			}
+			expectState(t, database.DunningStateSuspended, false)
+		})
+
+		t.Run("payment recovers", func(t *testing.T) {
+			paidAt := start.Add(2 * time.Minute)
+			sendInvoiceEvent(t, "in_test_dunning", "invoice.paid", `"status": "paid", "attempt_count": 3, "amount_paid": 2900,
+				"status_transitions": {"paid_at": `+createTimestamp(paidAt)+`}`, paidAt)
+			expectState(t, database.DunningStateRecovered, true)
+		})
+
+		t.Run("late failure of the paid invoice is ignored", func(t *testing.T) {
+			sendInvoiceEvent(t, "in_test_dunning", "invoice.payment_failed", `"status": "open", "attempt_count": 3`, start.Add(90*time.Second))
+			expectState(t, database.DunningStateRecovered, true)
+		})
+
+		t.Run("failure from before the payment is ignored", func(t *testing.T) {
+			sendInvoiceEvent(t, "in_test_dunning_earlier", "invoice.payment_failed", `"status": "open", "attempt_count": 1`, start.Add(90*time.Second))
+			expectState(t, database.DunningStateRecovered, true)
+		})
+
+		t.Run("failure after the payment starts grace again", func(t *testing.T) {
+			sendInvoiceEvent(t, "in_test_dunning_next", "invoice.payment_failed", `"status": "open", "attempt_count": 1`, start.Add(time.Hour))
+			expectState(t, database.DunningStateGrace, true)
+		})
+	})
+}
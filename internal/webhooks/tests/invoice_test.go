@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestInvoiceLifecycleEvents(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		_, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+		tests := []struct {
+			eventType      string
+			fields         string
+			expectedStatus string
+			expectFailure  bool
+		}{
+			{"invoice.finalized", `"status": "open", "attempt_count": 0`, "open", false},
+			{"invoice.payment_failed", `"status": "open", "attempt_count": 1, "next_payment_attempt": ` + createTimestamp(start.Add(72*time.Hour)), "open", true},
+			{"invoice.paid", `"status": "paid", "attempt_count": 2, "amount_paid": 2900, "status_transitions": {"paid_at": ` + createTimestamp(start.Add(2*time.Minute)) + `}`, "paid", true},
+		}
+
+		for i, tt := range tests {
+			t.Run(tt.eventType, func(t *testing.T) {
+				event := stripe.Event{
+					ID:      "evt_test_invoice_" + tt.eventType,
+					Type:    tt.eventType,
+					Created: start.Add(time.Duration(i) * time.Minute).Unix(),
+					Data: &stripe.EventData{
+						Raw: json.RawMessage(`{
+							"id": "in_test_lifecycle",
+							"customer": "` + customer.StripeCustomerID + `",
+							"subscription": "sub_test_invoice",
+							"amount_due": 2900,
+							"currency": "usd",
+							"hosted_invoice_url": "https://invoice.stripe.com/i/test",
+							"invoice_pdf": "https://pay.stripe.com/invoice/test/pdf",
+							"period_start": ` + createTimestamp(start) + `,
+							"period_end": ` + createTimestamp(start.Add(30*24*time.Hour)) + `,
+							` + tt.fields + `
+						}`),
+					},
+				}
+
+				w := httptest.NewRecorder()
+				handler.HandleWebhook(w, newSignedWebhookRequest(event))
+				if w.Code != http.StatusOK {
+					t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+				}
+				processQueuedEvents(t, handler)
+
+				invoice, err := testDB.Repo.GetInvoiceByStripeID(ctx, "in_test_lifecycle")
+				if err != nil {
+					t.Fatalf("Failed to get invoice: %v", err)
+				}
+				if invoice.Status != tt.expectedStatus {
+					t.Errorf("Expected status '%s', got '%s'", tt.expectedStatus, invoice.Status)
+				}
+				if (invoice.PaymentFailedAt != nil) != tt.expectFailure {
+					t.Errorf("Expected payment failure recorded: %v, got %v", tt.expectFailure, invoice.PaymentFailedAt)
+				}
+				if invoice.StripeSubscriptionID != "sub_test_invoice" || invoice.InvoicePDF == "" || invoice.HostedInvoiceURL == "" {
+					t.Errorf("Expected subscription and URLs to be stored, got %+v", invoice)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// subscriptionStep is one Stripe event in a subscription's history
+type subscriptionStep struct {
+	eventType    string
+	status       string
+	offset       time.Duration // Event creation time relative to the start of the sequence
+	subscription string        // Suffix of the event's Stripe subscription ID, empty for the sequence's subscription
+	product      string        // Suffix of the subscribed product ID, empty for the sequence's product
+}
+
+func TestSubscriptionEventsAppliedOutOfOrder(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		_, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+
+		tests := []struct {
+			name                 string
+			steps                []subscriptionStep
+			expectedSubscription string // Suffix of the subscription checked at the end
+			expectedProduct      string // Suffix of its expected product
+			expectedStatus       string
+		}{
+			{
+				name: "late update after cancellation",
+				steps: []subscriptionStep{
+					{"customer.subscription.created", "active", 0, "", ""},
+					{"customer.subscription.updated", "past_due", time.Minute, "", ""},
+					{"customer.subscription.deleted", "canceled", 2 * time.Minute, "", ""},
+				},
+				expectedStatus: "canceled",
+			},
+			{
+				name: "recovery after failed payment",
+				steps: []subscriptionStep{
+					{"customer.subscription.created", "incomplete", 0, "", ""},
+					{"customer.subscription.updated", "active", time.Minute, "", ""},
+					{"customer.subscription.updated", "past_due", 2 * time.Minute, "", ""},
+					{"customer.subscription.updated", "active", 3 * time.Minute, "", ""},
+				},
+				expectedStatus: "active",
+			},
+			{
+				name: "update in the same second as cancellation",
+				steps: []subscriptionStep{
+					{"customer.subscription.created", "active", 0, "", ""},
+					{"customer.subscription.updated", "active", time.Minute, "", ""},
+					{"customer.subscription.deleted", "canceled", time.Minute, "", ""},
+				},
+				expectedStatus: "canceled",
+			},
+			{
+				name: "plan change to another product",
+				steps: []subscriptionStep{
+					{"customer.subscription.created", "active", 0, "", ""},
+					{"customer.subscription.updated", "active", time.Minute, "", "_upgraded"},
+					{"customer.subscription.updated", "past_due", 2 * time.Minute, "", "_upgraded"},
+				},
+				expectedProduct: "_upgraded",
+				expectedStatus:  "past_due",
+			},
+			{
+				name: "late events for a replaced subscription",
+				steps: []subscriptionStep{
+					{"customer.subscription.created", "active", 0, "", ""},
+					{"customer.subscription.updated", "past_due", time.Minute, "", ""},
+					{"customer.subscription.created", "active", 2 * time.Minute, "_replacement", ""},
+				},
+				expectedSubscription: "_replacement",
+				expectedStatus:       "active",
+			},
+		}
+
+		start := time.Now().Add(-time.Hour).Truncate(time.Second)
+		for i, tt := range tests {
+			for p, order := range permutations(len(tt.steps)) {
+				t.Run(fmt.Sprintf("%s/%v", tt.name, order), func(t *testing.T) {
+					subID := fmt.Sprintf("sub_test_order_%d_%d", i, p)
+					productID := fmt.Sprintf("prod_test_order_%d_%d", i, p)
+
+					for _, idx := range order {
+						step := tt.steps[idx]
+						eventID := fmt.Sprintf("evt_%s_%d", subID, idx)
+						event := subscriptionEvent(eventID, step, start, subID+step.subscription, productID+step.product, customer.StripeCustomerID)
+
+						w := httptest.NewRecorder()
+						handler.HandleWebhook(w, newSignedWebhookRequest(event))
+						if w.Code != http.StatusOK {
+							t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+						}
+						processQueuedEvents(t, handler)
+
+						// Stale events are skipped, not left to be retried
+						stored, err := testDB.Repo.GetStripeEvent(ctx, eventID)
+						if err != nil {
+							t.Fatalf("Failed to get event %s: %v", eventID, err)
+						}
+						if stored.Status != database.EventStatusProcessed {
+							t.Fatalf("Expected event %s to be processed, got %s: %s", eventID, stored.Status, stored.LastError)
+						}
+					}
+
+					sub, err := testDB.Repo.GetSubscriptionByStripeID(ctx, subID+tt.expectedSubscription)
+					if err != nil {
+						t.Fatalf("Failed to get subscription: %v", err)
+					}
+					if sub.Status != tt.expectedStatus {
+						t.Errorf("Expected final status '%s', got '%s'", tt.expectedStatus, sub.Status)
+					}
+					if sub.ProductID != productID+tt.expectedProduct || sub.PriceID != "price_"+productID+tt.expectedProduct {
+						t.Errorf("Expected product %s, got %s with price %s", productID+tt.expectedProduct, sub.ProductID, sub.PriceID)
+					}
+				})
+			}
+		}
+	})
+}
+
+// subscriptionEvent builds a full subscription event for a step of a sequence
+func subscriptionEvent(eventID string, step subscriptionStep, start time.Time, subID, productID, stripeCustomerID string) stripe.Event {
+	created := start.Add(step.offset)
+	return stripe.Event{
+		ID:      eventID,
+		Type:    step.eventType,
+		Created: created.Unix(),
+		Data: &stripe.EventData{
+			Raw: json.RawMessage(`{
+				"id": "` + subID + `",
+				"customer": {"id": "` + stripeCustomerID + `"},
+				"status": "` + step.status + `",
+				"current_period_start": ` + createTimestamp(start) + `,
+				"current_period_end": ` + createTimestamp(start.Add(30*24*time.Hour)) + `,
+				"items": {"data": [{"price": {"id": "price_` + productID + `", "product": "` + productID + `"}}]}
+			}`),
+		},
+	}
+}
+
+// permutations returns every ordering of the indexes 0..n-1
+func permutations(n int) [][]int {
+	if n == 0 {
+		return [][]int{{}}
+	}
+
+	var result [][]int
+	for _, rest := range permutations(n - 1) {
+		for pos := 0; pos <= len(rest); pos++ {
+			order := make([]int, 0, n)
+			order = append(order, rest[:pos]...)
+			order = append(order, n-1)
+			order = append(order, rest[pos:]...)
+			result = append(result, order)
+		}
+	}
+	return result
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// TestProjectWebhookIsolation checks that events signed with one project's webhook secret cannot
+// touch another project's subscriptions, invoices or orders, even when they name them by Stripe ID
+func TestProjectWebhookIsolation(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+
+		// The victim project uses the service-wide Stripe account
+		victimProject, victim, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+		victimSubID, _, _, _, err := testDB.Repo.GetSubscriptionStatus(ctx, victimProject.ID, victim.UserID, "premium_plan")
+		if err != nil {
+			t.Fatalf("Failed to get subscription status: %v", err)
+		}
+		victimSub, err := testDB.Repo.GetSubscriptionByStripeID(ctx, victimSubID)
+		if err != nil {
+			t.Fatalf("Failed to get subscription: %v", err)
+		}
+		order := &database.Order{
+			ProjectID:               victimProject.ID,
+			CustomerID:              victim.ID,
+			UserID:                  victim.UserID,
+			StripeCheckoutSessionID: "cs_test_isolation",
+			StripePaymentIntentID:   "pi_test_isolation",
+			PaymentType:             "item",
+			PaymentStatus:           "paid",
+			AmountTotal:             3000,
+			Currency:                "usd",
+		}
+		if err := testDB.Repo.CreateOrder(ctx, order); err != nil {
+			t.Fatalf("Failed to create order: %v", err)
+		}
+		invoiceAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+		if _, err := testDB.Repo.UpsertInvoice(ctx, &database.Invoice{
+			ProjectID:            victimProject.ID,
+			CustomerID:           victim.ID,
+			UserID:               victim.UserID,
+			StripeInvoiceID:      "in_test_isolation",
+			StripeSubscriptionID: victimSub.StripeSubscriptionID,
+			Status:               "paid",
+			AmountDue:            2900,
+			AmountPaid:           2900,
+			Currency:             "usd",
+			PeriodStart:          invoiceAt,
+			PeriodEnd:            invoiceAt.Add(30 * 24 * time.Hour),
+			LastEventAt:          invoiceAt,
+		}); err != nil {
+			t.Fatalf("Failed to create invoice: %v", err)
+		}
+
+		// The attacking project signs events with its own webhook secret
+		project, err := testDB.Repo.CreateProject(ctx, "Attacking Stripe Account", "")
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+		if err := testDB.Repo.UpdateProjectStripeSettings(ctx, project.ID, "sk_test_project", testProjectWebhookSecret); err != nil {
+			t.Fatalf("Failed to set Stripe settings: %v", err)
+		}
+		customer := &database.Customer{
+			ID:               uuid.New(),
+			ProjectID:        project.ID,
+			UserID:           "test_user_isolation",
+			Email:            "isolation@example.com",
+			StripeCustomerID: "cus_test_isolation",
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+		if err := testDB.CreateTestCustomer(customer); err != nil {
+			t.Fatalf("Failed to create customer: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		mux := http.NewServeMux()
+		handler.SetupRoutes(mux)
+		queue := webhooks.NewQueue(handler, webhooks.QueueConfig{MaxAttempts: 1})
+
+		send := func(t *testing.T, id, eventType, raw string) {
+			t.Helper()
+			event := stripe.Event{ID: id, Type: eventType, Created: time.Now().Unix(), Data: &stripe.EventData{Raw: json.RawMessage(raw)}}
+			body, _ := json.Marshal(event)
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe/"+project.ID.String(), bytes.NewReader(body))
+			req.Header.Set("Stripe-Signature", webhooks.SignPayload(body, testProjectWebhookSecret, time.Now()))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+			}
+			if _, err := queue.ProcessPending(ctx); err != nil {
+				t.Fatalf("Failed to process queued events: %v", err)
+			}
+		}
+
+		expectVictimSubscription := func(t *testing.T) {
+			t.Helper()
+			sub, err := testDB.Repo.GetSubscriptionByStripeID(ctx, victimSub.StripeSubscriptionID)
+			if err != nil {
+				t.Fatalf("Failed to get subscription: %v", err)
+			}
+			if sub.Status != victimSub.Status || sub.DunningState != "" {
+				t.Errorf("Expected the other project's subscription untouched, got status '%s' and dunning state '%s'", sub.Status, sub.DunningState)
+			}
+		}
+
+		t.Run("subscription", func(t *testing.T) {
+			// A payload without a customer only names the subscription
+			send(t, "evt_test_isolation_sub", "customer.subscription.deleted",
+				`{"id": "`+victimSub.StripeSubscriptionID+`", "status": "canceled"}`)
+			expectVictimSubscription(t)
+
+			stored, err := testDB.Repo.GetStripeEvent(ctx, "evt_test_isolation_sub")
+			if err != nil {
+				t.Fatalf("Failed to get stored event: %v", err)
+			}
+			if stored.Status != database.EventStatusDeadLetter {
+				t.Errorf("Expected the event to be rejected, got status '%s'", stored.Status)
+			}
+		})
+
+		t.Run("invoice", func(t *testing.T) {
+			send(t, "evt_test_isolation_invoice", "invoice.payment_failed", `{
+				"id": "in_test_isolation_attack",
+				"customer": "`+customer.StripeCustomerID+`",
+				"subscription": "`+victimSub.StripeSubscriptionID+`",
+				"status": "open",
+				"amount_due": 2900,
+				"currency": "usd",
+				"attempt_count": 1
+			}`)
+			expectVictimSubscription(t)
+
+			// Another project's invoice ID is not overwritten
+			send(t, "evt_test_isolation_invoice_id", "invoice.voided", `{
+				"id": "in_test_isolation",
+				"customer": "`+customer.StripeCustomerID+`",
+				"status": "void",
+				"currency": "usd"
+			}`)
+			invoice, err := testDB.Repo.GetInvoiceByStripeID(ctx, "in_test_isolation")
+			if err != nil {
+				t.Fatalf("Failed to get invoice: %v", err)
+			}
+			if invoice.ProjectID != victimProject.ID || invoice.Status != "paid" {
+				t.Errorf("Expected the other project's invoice untouched, got status '%s'", invoice.Status)
+			}
+		})
+
+		t.Run("charge", func(t *testing.T) {
+			send(t, "evt_test_isolation_charge", "charge.refunded", `{
+				"id": "ch_test_isolation",
+				"object": "charge",
+				"amount": 3000,
+				"amount_refunded": 3000,
+				"refunded": true,
+				"payment_intent": "pi_test_isolation",
+				"refunds": {"data": [{"id": "re_test_isolation", "amount": 3000, "currency": "usd", "status": "succeeded"}]}
+			}`)
+
+			orders, err := testDB.Repo.GetOrdersByUserID(ctx, victimProject.ID, victim.UserID)
+			if err != nil {
+				t.Fatalf("Failed to get orders: %v", err)
+			}
+			if len(orders) != 1 || orders[0].AmountRefunded != 0 || orders[0].RefundedAt != nil {
+				t.Errorf("Expected the other project's order not refunded, got %+v", orders)
+			}
+			if _, err := testDB.Repo.GetRefundByStripeID(ctx, "re_test_isolation"); err == nil {
+				t.Error("Expected no refund recorded against the other project's order")
+			}
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v72"
+)
+
+const testProjectWebhookSecret = "whsec_test_project"
+
+func TestProjectWebhookEndpoint(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+
+		// The shared test customer belongs to another project
+		_, otherCustomer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		project, err := testDB.Repo.CreateProject(ctx, "Separate Stripe Account", "")
+		if err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+		if err := testDB.Repo.UpdateProjectStripeSettings(ctx, project.ID, "sk_test_project", testProjectWebhookSecret); err != nil {
+			t.Fatalf("Failed to set Stripe settings: %v", err)
+		}
+		customer := &database.Customer{
+			ID:               uuid.New(),
+			ProjectID:        project.ID,
+			UserID:           "test_user_project_webhook",
+			Email:            "project_webhook@example.com",
+			StripeCustomerID: "cus_test_project_webhook",
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+		if err := testDB.CreateTestCustomer(customer); err != nil {
+			t.Fatalf("Failed to create customer: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		mux := http.NewServeMux()
+		handler.SetupRoutes(mux)
+
+		post := func(projectID string, event stripe.Event, secret string) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(event)
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe/"+projectID, bytes.NewReader(body))
+			req.Header.Set("Stripe-Signature", webhooks.SignPayload(body, secret, time.Now()))
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			return w
+		}
+
+		ownEvent := subscriptionCreatedEvent("evt_test_project_own", "sub_test_project_own", customer.StripeCustomerID)
+		foreignEvent := subscriptionCreatedEvent("evt_test_project_foreign", "sub_test_project_foreign", otherCustomer.StripeCustomerID)
+
+		// The service-wide secret is not accepted on a project endpoint
+		if w := post(project.ID.String(), ownEvent, testWebhookSecret); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for service secret, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := post(uuid.New().String(), ownEvent, testProjectWebhookSecret); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for unknown project, got %d", http.StatusNotFound, w.Code)
+		}
+
+		for _, event := range []stripe.Event{ownEvent, foreignEvent} {
+			if w := post(project.ID.String(), event, testProjectWebhookSecret); w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d for %s, got %d", http.StatusOK, event.ID, w.Code)
+			}
+		}
+
+		queue := webhooks.NewQueue(handler, webhooks.QueueConfig{MaxAttempts: 1})
+		if _, err := queue.ProcessPending(ctx); err != nil {
+			t.Fatalf("Failed to process queued events: %v", err)
+		}
+
+		stored, err := testDB.Repo.GetStripeEvent(ctx, ownEvent.ID)
+		if err != nil {
+			t.Fatalf("Failed to get stored event: %v", err)
+		}
+		if stored.ProjectID == nil || *stored.ProjectID != project.ID {
+			t.Errorf("Expected event to be attributed to project %s, got %v", project.ID, stored.ProjectID)
+		}
+		if stored.Status != database.EventStatusProcessed {
+			t.Errorf("Expected event status '%s', got '%s' (%s)", database.EventStatusProcessed, stored.Status, stored.LastError)
+		}
+
+		subscription, err := testDB.Repo.GetSubscriptionByStripeID(ctx, "sub_test_project_own")
+		if err != nil {
+			t.Fatalf("Failed to get subscription: %v", err)
+		}
+		if subscription.ProjectID != project.ID {
+			t.Errorf("Expected subscription of project %s, got %s", project.ID, subscription.ProjectID)
+		}
+
+		// Customers of other projects are not matched
+		stored, err = testDB.Repo.GetStripeEvent(ctx, foreignEvent.ID)
+		if err != nil {
+			t.Fatalf("Failed to get stored event: %v", err)
+		}
+		if stored.Status != database.EventStatusDeadLetter {
+			t.Errorf("Expected event for another project's customer to be dead-lettered, got '%s'", stored.Status)
+		}
+	})
+}
+
+// subscriptionCreatedEvent builds a customer.subscription.created event for a Stripe customer
+func subscriptionCreatedEvent(eventID, subID, stripeCustomerID string) stripe.Event {
+	return stripe.Event{
+		ID:      eventID,
+		Type:    "customer.subscription.created",
+		Created: time.Now().Unix(),
+		Data: &stripe.EventData{
+			Raw: json.RawMessage(`{
+				"id": "` + subID + `",
+				"customer": {"id": "` + stripeCustomerID + `"},
+				"status": "active",
+				"current_period_start": ` + createTimestamp(time.Now()) + `,
+				"current_period_end": ` + createTimestamp(time.Now().Add(30*24*time.Hour)) + `,
+				"items": {"data": [{"price": {"id": "price_test_project", "product": "prod_test_project"}}]}
+			}`),
+		},
+	}
+}
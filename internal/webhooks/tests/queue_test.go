@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestWebhookEventLogIdempotency(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		_, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+
+		event := stripe.Event{
+			ID:      "evt_test_duplicate",
+			Type:    "customer.subscription.created",
+			Created: time.Now().Unix(),
+			Data: &stripe.EventData{
+				Raw: json.RawMessage(`{
+					"id": "sub_test_duplicate",
+					"customer": {"id": "` + customer.StripeCustomerID + `"},
+					"status": "active",
+					"current_period_start": ` + createTimestamp(time.Now()) + `,
+					"current_period_end": ` + createTimestamp(time.Now().Add(30*24*time.Hour)) + `,
+					"items": {"data": [{"price": {"id": "price_test_dup", "product": "prod_test_dup"}}]}
+				}`),
+			},
+		}
+
+		// Deliver the same event twice, as Stripe does on retries
+		expectedStatuses := []string{"queued", "duplicate"}
+		for i, expected := range expectedStatuses {
+			w := httptest.NewRecorder()
+			handler.HandleWebhook(w, newSignedWebhookRequest(event))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Delivery %d: expected status code %d, got %d", i+1, http.StatusOK, w.Code)
+			}
+
+			var response map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Delivery %d: failed to unmarshal response: %v", i+1, err)
+			}
+			if response["status"] != expected {
+				t.Errorf("Delivery %d: expected status '%s', got '%s'", i+1, expected, response["status"])
+			}
+		}
+
+		stored, err := testDB.Repo.GetStripeEvent(ctx, event.ID)
+		if err != nil {
+			t.Fatalf("Failed to get stored event: %v", err)
+		}
+		if stored.Status != database.EventStatusPending {
+			t.Errorf("Expected event status '%s' before processing, got '%s'", database.EventStatusPending, stored.Status)
+		}
+
+		processQueuedEvents(t, handler)
+
+		stored, err = testDB.Repo.GetStripeEvent(ctx, event.ID)
+		if err != nil {
+			t.Fatalf("Failed to get stored event: %v", err)
+		}
+		if stored.Status != database.EventStatusProcessed {
+			t.Errorf("Expected event status '%s', got '%s'", database.EventStatusProcessed, stored.Status)
+		}
+		if stored.Attempts != 1 {
+			t.Errorf("Expected 1 processing attempt, got %d", stored.Attempts)
+		}
+
+		events, err := testDB.Repo.ListStripeEvents(ctx, "customer.subscription.created",
+			time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 10)
+		if err != nil {
+			t.Fatalf("Failed to list events: %v", err)
+		}
+		if len(events) != 1 || events[0].ID != event.ID {
+			t.Errorf("Expected exactly event %s in listing, got %d events", event.ID, len(events))
+		}
+	})
+}
+
+func TestWebhookQueueRetriesAndDeadLetters(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		queue := webhooks.NewQueue(handler, webhooks.QueueConfig{
+			MaxAttempts: 2,
+			BaseBackoff: time.Millisecond,
+		})
+
+		// The customer does not exist, so processing fails every time
+		event := stripe.Event{
+			ID:      "evt_test_unknown_customer",
+			Type:    "customer.subscription.created",
+			Created: time.Now().Unix(),
+			Data: &stripe.EventData{
+				Raw: json.RawMessage(`{"id": "sub_test_unknown", "customer": {"id": "cus_unknown"}, "status": "active"}`),
+			},
+		}
+
+		w := httptest.NewRecorder()
+		handler.HandleWebhook(w, newSignedWebhookRequest(event))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+
+		expected := []struct {
+			status   string
+			attempts int
+		}{
+			{database.EventStatusFailed, 1},
+			{database.EventStatusDeadLetter, 2},
+		}
+		for _, want := range expected {
+			// Let the backoff elapse so the event is due again
+			time.Sleep(20 * time.Millisecond)
+			if _, err := queue.ProcessPending(ctx); err != nil {
+				t.Fatalf("Failed to process queued events: %v", err)
+			}
+
+			stored, err := testDB.Repo.GetStripeEvent(ctx, event.ID)
+			if err != nil {
+				t.Fatalf("Failed to get stored event: %v", err)
+			}
+			if stored.Status != want.status {
+				t.Errorf("Expected event status '%s', got '%s'", want.status, stored.Status)
+			}
+			if stored.Attempts != want.attempts {
+				t.Errorf("Expected %d attempts, got %d", want.attempts, stored.Attempts)
+			}
+			if stored.LastError == "" {
+				t.Error("Expected last error to be recorded")
+			}
+		}
+
+		// Dead-lettered events are never claimed again
+		processed, err := queue.ProcessPending(ctx)
+		if err != nil {
+			t.Fatalf("Failed to process queued events: %v", err)
+		}
+		if processed != 0 {
+			t.Errorf("Expected no events to be processed, got %d", processed)
+		}
+	})
+}
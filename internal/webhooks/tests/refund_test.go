@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestRefundsAndDisputes(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		for _, id := range []string{"refund", "dispute"} {
+			order := &database.Order{
+				ProjectID:               project.ID,
+				CustomerID:              customer.ID,
+				UserID:                  customer.UserID,
+				StripeCheckoutSessionID: "cs_test_" + id,
+				StripePaymentIntentID:   "pi_test_" + id,
+				PaymentType:             "item",
+				PaymentStatus:           "paid",
+				AmountTotal:             3000,
+				Currency:                "usd",
+			}
+			if err := testDB.Repo.CreateOrder(ctx, order); err != nil {
+				t.Fatalf("Failed to create order: %v", err)
+			}
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+		send := func(t *testing.T, id, eventType string, created time.Time, raw string) {
+			t.Helper()
+			event := stripe.Event{
+				ID:      id,
+				Type:    eventType,
+				Created: created.Unix(),
+				Data:    &stripe.EventData{Raw: json.RawMessage(raw)},
+			}
+
+			w := httptest.NewRecorder()
+			handler.HandleWebhook(w, newSignedWebhookRequest(event))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+			}
+			processQueuedEvents(t, handler)
+		}
+
+		orderFor := func(t *testing.T, sessionID string) *database.Order {
+			t.Helper()
+			orders, err := testDB.Repo.GetOrdersByUserID(ctx, project.ID, customer.UserID)
+			if err != nil {
+				t.Fatalf("Failed to get orders: %v", err)
+			}
+			for _, order := range orders {
+				if order.StripeCheckoutSessionID == sessionID {
+					return order
+				}
+			}
+			t.Fatalf("Order %s not found", sessionID)
+			return nil
+		}
+
+		charge := func(refunded bool, amountRefunded string, refunds string) string {
+			return `{
+				"id": "ch_test_refund",
+				"object": "charge",
+				"amount": 3000,
+				"currency": "usd",
+				"customer": "` + customer.StripeCustomerID + `",
+				"payment_intent": "pi_test_refund",
+				"refunded": ` + strconv.FormatBool(refunded) + `,
+				"amount_refunded": ` + amountRefunded + `,
+				"refunds": {"object": "list", "data": [` + refunds + `]}
+			}`
+		}
+		firstRefund := `{"id": "re_test_1", "object": "refund", "amount": 1000, "currency": "usd", "charge": "ch_test_refund", "status": "succeeded", "reason": "requested_by_customer"}`
+		secondRefund := `{"id": "re_test_2", "object": "refund", "amount": 2000, "currency": "usd", "charge": "ch_test_refund", "status": "pending"}`
+
+		t.Run("partial refund keeps entitlement", func(t *testing.T) {
+			send(t, "evt_test_refund_partial", "charge.refunded", start, charge(false, "1000", firstRefund))
+
+			order := orderFor(t, "cs_test_refund")
+			if order.AmountRefunded != 1000 || order.RefundedAt != nil || !order.Entitled {
+				t.Errorf("Expected partially refunded entitled order, got refunded=%d at=%v entitled=%v", order.AmountRefunded, order.RefundedAt, order.Entitled)
+			}
+
+			refund, err := testDB.Repo.GetRefundByStripeID(ctx, "re_test_1")
+			if err != nil {
+				t.Fatalf("Failed to get refund: %v", err)
+			}
+			if refund.OrderID == nil || *refund.OrderID != order.ID || refund.StripeChargeID != "ch_test_refund" || refund.Amount != 1000 {
+				t.Errorf("Unexpected refund: %+v", refund)
+			}
+		})
+
+		t.Run("full refund removes entitlement", func(t *testing.T) {
+			send(t, "evt_test_refund_full", "charge.refunded", start.Add(time.Minute), charge(true, "3000", firstRefund+","+secondRefund))
+
+			order := orderFor(t, "cs_test_refund")
+			if order.AmountRefunded != 3000 || order.RefundedAt == nil || order.Entitled {
+				t.Errorf("Expected fully refunded order without entitlement, got refunded=%d at=%v entitled=%v", order.AmountRefunded, order.RefundedAt, order.Entitled)
+			}
+		})
+
+		t.Run("late partial refund keeps the full refund", func(t *testing.T) {
+			send(t, "evt_test_refund_partial_late", "charge.refunded", start.Add(30*time.Second), charge(false, "1000", firstRefund))
+
+			order := orderFor(t, "cs_test_refund")
+			if order.AmountRefunded != 3000 || order.RefundedAt == nil || order.Entitled {
+				t.Errorf("Expected the order to stay fully refunded, got refunded=%d at=%v entitled=%v", order.AmountRefunded, order.RefundedAt, order.Entitled)
+			}
+		})
+
+		t.Run("refund status update", func(t *testing.T) {
+			send(t, "evt_test_refund_updated", "charge.refund.updated", start.Add(2*time.Minute),
+				`{"id": "re_test_2", "object": "refund", "amount": 2000, "currency": "usd", "charge": "ch_test_refund", "payment_intent": "pi_test_refund", "status": "succeeded"}`)
+
+			refund, err := testDB.Repo.GetRefundByStripeID(ctx, "re_test_2")
+			if err != nil {
+				t.Fatalf("Failed to get refund: %v", err)
+			}
+			if refund.Status != "succeeded" {
+				t.Errorf("Expected refund status 'succeeded', got '%s'", refund.Status)
+			}
+		})
+
+		dispute := func(status string) string {
+			return `{
+				"id": "dp_test_1",
+				"object": "dispute",
+				"amount": 3000,
+				"currency": "usd",
+				"charge": "ch_test_dispute",
+				"payment_intent": "pi_test_dispute",
+				"reason": "fraudulent",
+				"status": "` + status + `"
+			}`
+		}
+
+		t.Run("lost dispute removes entitlement", func(t *testing.T) {
+			send(t, "evt_test_dispute_created", "charge.dispute.created", start, dispute("needs_response"))
+			if order := orderFor(t, "cs_test_dispute"); !order.Entitled {
+				t.Errorf("Expected order with open dispute to stay entitled")
+			}
+
+			send(t, "evt_test_dispute_closed", "charge.dispute.closed", start.Add(2*time.Minute), dispute("lost"))
+			// Redelivered older update must not reopen the dispute
+			send(t, "evt_test_dispute_updated", "charge.dispute.updated", start.Add(time.Minute), dispute("under_review"))
+
+			stored, err := testDB.Repo.GetDisputeByStripeID(ctx, "dp_test_1")
+			if err != nil {
+				t.Fatalf("Failed to get dispute: %v", err)
+			}
+			order := orderFor(t, "cs_test_dispute")
+			if stored.Status != "lost" || stored.OrderID == nil || *stored.OrderID != order.ID {
+				t.Errorf("Unexpected dispute: status=%s order=%v", stored.Status, stored.OrderID)
+			}
+			if order.Entitled {
+				t.Errorf("Expected order with lost dispute to lose entitlement")
+			}
+		})
+	})
+}
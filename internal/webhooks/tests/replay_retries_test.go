@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestFailedReplayKeepsRetries(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		queue := webhooks.NewQueue(handler, webhooks.QueueConfig{MaxAttempts: 2})
+
+		// No customer is linked to cus_unknown, so every attempt fails
+		event := stripe.Event{
+			ID:      "evt_test_replay_retries",
+			Type:    "customer.subscription.created",
+			Created: time.Now().Unix(),
+			Data: &stripe.EventData{
+				Raw: json.RawMessage(`{"id": "sub_test_replay_retries", "customer": "cus_unknown", "status": "active"}`),
+			},
+		}
+		w := httptest.NewRecorder()
+		handler.HandleWebhook(w, newSignedWebhookRequest(event))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+
+		expectReplay := func(t *testing.T, expected string) {
+			t.Helper()
+			replayed, err := queue.ReplayEvent(ctx, event.ID, "ops@example.com")
+			if err != nil {
+				t.Fatalf("Replay failed: %v", err)
+			}
+			if replayed.Status != expected || replayed.LastError == "" {
+				t.Errorf("Expected status '%s' with an error, got '%s' (%q)", expected, replayed.Status, replayed.LastError)
+			}
+			actions, err := testDB.Repo.ListStripeEventActions(ctx, event.ID)
+			if err != nil {
+				t.Fatalf("Failed to list event actions: %v", err)
+			}
+			if last := actions[len(actions)-1]; last.Result != expected || last.Error == "" {
+				t.Errorf("Expected the replay recorded as '%s' with an error, got '%s' (%q)", expected, last.Result, last.Error)
+			}
+		}
+
+		t.Run("pending event returns to the queue", func(t *testing.T) {
+			expectReplay(t, database.EventStatusFailed)
+		})
+
+		t.Run("queue exhausts the remaining attempts", func(t *testing.T) {
+			// Make the scheduled retry due and let the queue use up both attempts
+			for i := 0; i < 2; i++ {
+				if err := testDB.Repo.MarkStripeEventFailed(ctx, event.ID, "retry", time.Now().Add(-time.Second)); err != nil {
+					t.Fatalf("Failed to schedule retry: %v", err)
+				}
+				if _, err := queue.ProcessPending(ctx); err != nil {
+					t.Fatalf("Failed to process queued events: %v", err)
+				}
+			}
+			stored, err := testDB.Repo.GetStripeEvent(ctx, event.ID)
+			if err != nil {
+				t.Fatalf("Failed to get stored event: %v", err)
+			}
+			if stored.Status != database.EventStatusDeadLetter || stored.Attempts != 2 {
+				t.Fatalf("Expected the event dead-lettered after 2 attempts, got '%s' after %d", stored.Status, stored.Attempts)
+			}
+		})
+
+		t.Run("dead-lettered event stays dead-lettered", func(t *testing.T) {
+			expectReplay(t, database.EventStatusDeadLetter)
+		})
+	})
+}
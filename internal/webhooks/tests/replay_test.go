@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestReplayDeadLetteredEvent(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		queue := webhooks.NewQueue(handler, webhooks.QueueConfig{MaxAttempts: 1})
+
+		// The customer is not linked to cus_test_replay yet, so processing fails
+		event := stripe.Event{
+			ID:      "evt_test_replay",
+			Type:    "customer.subscription.created",
+			Created: time.Now().Unix(),
+			Data: &stripe.EventData{
+				Raw: json.RawMessage(`{
+					"id": "sub_test_replay",
+					"customer": {"id": "cus_test_replay"},
+					"status": "active",
+					"current_period_start": ` + createTimestamp(time.Now()) + `,
+					"current_period_end": ` + createTimestamp(time.Now().Add(30*24*time.Hour)) + `,
+					"items": {"data": [{"price": {"id": "price_test_replay", "product": "prod_test_replay"}}]}
+				}`),
+			},
+		}
+
+		w := httptest.NewRecorder()
+		handler.HandleWebhook(w, newSignedWebhookRequest(event))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		if _, err := queue.ProcessPending(ctx); err != nil {
+			t.Fatalf("Failed to process queued events: %v", err)
+		}
+
+		failed, err := testDB.Repo.ListStripeEventsByStatus(ctx, []string{database.EventStatusDeadLetter}, "",
+			time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 10)
+		if err != nil {
+			t.Fatalf("Failed to list dead-lettered events: %v", err)
+		}
+		if len(failed) != 1 || failed[0].ID != event.ID || failed[0].LastError == "" {
+			t.Fatalf("Expected event %s dead-lettered with an error, got %d events", event.ID, len(failed))
+		}
+
+		// Fix the data and replay twice; the second replay must leave the same result
+		if err := testDB.Repo.UpdateCustomerStripeID(ctx, project.ID, customer.UserID, "cus_test_replay"); err != nil {
+			t.Fatalf("Failed to link customer: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			replayed, err := queue.ReplayEvent(ctx, event.ID, "ops@example.com")
+			if err != nil {
+				t.Fatalf("Replay %d failed: %v", i+1, err)
+			}
+			if replayed.Status != database.EventStatusProcessed {
+				t.Errorf("Replay %d: expected status '%s', got '%s'", i+1, database.EventStatusProcessed, replayed.Status)
+			}
+		}
+
+		subscription, err := testDB.Repo.GetSubscriptionByStripeID(ctx, "sub_test_replay")
+		if err != nil {
+			t.Fatalf("Failed to get replayed subscription: %v", err)
+		}
+		if subscription.Status != "active" {
+			t.Errorf("Expected subscription status 'active', got '%s'", subscription.Status)
+		}
+
+		actions, err := testDB.Repo.ListStripeEventActions(ctx, event.ID)
+		if err != nil {
+			t.Fatalf("Failed to list event actions: %v", err)
+		}
+		if len(actions) != 2 {
+			t.Fatalf("Expected 2 recorded replays, got %d", len(actions))
+		}
+		for _, action := range actions {
+			if action.Action != database.EventActionReplay || action.TriggeredBy != "ops@example.com" {
+				t.Errorf("Expected replay by ops@example.com, got %s by %s", action.Action, action.TriggeredBy)
+			}
+		}
+
+		// Processed events cannot be ignored, and unknown events cannot be replayed
+		ignored, err := testDB.Repo.MarkStripeEventIgnored(ctx, event.ID)
+		if err != nil {
+			t.Fatalf("Failed to ignore event: %v", err)
+		}
+		if ignored {
+			t.Error("Expected processed event not to be ignored")
+		}
+		if _, err := queue.ReplayEvent(ctx, "evt_test_missing", "ops@example.com"); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for unknown event, got %v", err)
+		}
+	})
+}
+
+func TestIgnoredEventsAreNotProcessed(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+
+		event := stripe.Event{
+			ID:      "evt_test_ignored",
+			Type:    "customer.subscription.created",
+			Created: time.Now().Unix(),
+			Data: &stripe.EventData{
+				Raw: json.RawMessage(`{"id": "sub_test_ignored", "customer": {"id": "cus_unknown"}, "status": "active"}`),
+			},
+		}
+
+		w := httptest.NewRecorder()
+		handler.HandleWebhook(w, newSignedWebhookRequest(event))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+
+		ignored, err := testDB.Repo.MarkStripeEventIgnored(ctx, event.ID)
+		if err != nil {
+			t.Fatalf("Failed to ignore event: %v", err)
+		}
+		if !ignored {
+			t.Fatal("Expected pending event to be ignored")
+		}
+
+		queue := webhooks.NewQueue(handler, webhooks.QueueConfig{})
+		processed, err := queue.ProcessPending(ctx)
+		if err != nil {
+			t.Fatalf("Failed to process queued events: %v", err)
+		}
+		if processed != 0 {
+			t.Errorf("Expected ignored event not to be processed, got %d processed", processed)
+		}
+
+		stored, err := testDB.Repo.GetStripeEvent(ctx, event.ID)
+		if err != nil {
+			t.Fatalf("Failed to get stored event: %v", err)
+		}
+		if stored.Status != database.EventStatusIgnored {
+			t.Errorf("Expected event status '%s', got '%s'", database.EventStatusIgnored, stored.Status)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+)
+
+const (
+	testWebhookSecret    = "whsec_test_current"
+	testOldWebhookSecret = "whsec_test_previous"
+)
+
+func TestSignatureVerifier(t *testing.T) {
+	payload := []byte(`{"id":"evt_test_signature","type":"customer.subscription.updated"}`)
+	verifier := webhooks.NewSignatureVerifier([]string{testWebhookSecret, testOldWebhookSecret}, 5*time.Minute)
+
+	tests := []struct {
+		name        string
+		header      string
+		expectedErr error
+	}{
+		{
+			name:   "Valid signature with current secret",
+			header: webhooks.SignPayload(payload, testWebhookSecret, time.Now()),
+		},
+		{
+			name:   "Valid signature with rotated secret",
+			header: webhooks.SignPayload(payload, testOldWebhookSecret, time.Now()),
+		},
+		{
+			name:        "Signature from unknown secret",
+			header:      webhooks.SignPayload(payload, "whsec_attacker", time.Now()),
+			expectedErr: webhooks.ErrInvalidSignature,
+		},
+		{
+			name:        "Signature for different payload",
+			header:      webhooks.SignPayload([]byte(`{"id":"evt_other"}`), testWebhookSecret, time.Now()),
+			expectedErr: webhooks.ErrInvalidSignature,
+		},
+		{
+			name:        "Expired timestamp",
+			header:      webhooks.SignPayload(payload, testWebhookSecret, time.Now().Add(-10*time.Minute)),
+			expectedErr: webhooks.ErrTimestampExpired,
+		},
+		{
+			name:        "Timestamp too far in the future",
+			header:      webhooks.SignPayload(payload, testWebhookSecret, time.Now().Add(10*time.Minute)),
+			expectedErr: webhooks.ErrTimestampExpired,
+		},
+		{
+			name:        "Missing header",
+			header:      "",
+			expectedErr: webhooks.ErrMalformedSignatureHeader,
+		},
+		{
+			name:        "Missing timestamp",
+			header:      "v1=abcdef",
+			expectedErr: webhooks.ErrMalformedSignatureHeader,
+		},
+		{
+			name:        "Missing v1 signature",
+			header:      "t=1700000000,v0=abcdef",
+			expectedErr: webhooks.ErrMalformedSignatureHeader,
+		},
+		{
+			name:        "Garbage header",
+			header:      "not-a-signature",
+			expectedErr: webhooks.ErrMalformedSignatureHeader,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifier.Verify(payload, tt.header)
+			if tt.expectedErr == nil && err != nil {
+				t.Fatalf("Expected signature to verify, got %v", err)
+			}
+			if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestWebhookRejectsInvalidSignatures(t *testing.T) {
+	// A nil repository is fine here: rejected requests never reach the database
+	handler := webhooks.NewStripeWebhookHandler(nil, "sk_test_dummy", testWebhookSecret+","+testOldWebhookSecret)
+	payload := []byte(`{"id":"evt_test_reject","type":"customer.subscription.updated","data":{"object":{}}}`)
+
+	tests := []struct {
+		name         string
+		header       string
+		expectedCode string
+	}{
+		{
+			name:         "Forged signature",
+			header:       webhooks.SignPayload(payload, "whsec_attacker", time.Now()),
+			expectedCode: "INVALID_SIGNATURE",
+		},
+		{
+			name:         "Expired signature",
+			header:       webhooks.SignPayload(payload, testWebhookSecret, time.Now().Add(-time.Hour)),
+			expectedCode: "SIGNATURE_EXPIRED",
+		},
+		{
+			name:         "Malformed header",
+			header:       "t=abc",
+			expectedCode: "MALFORMED_SIGNATURE_HEADER",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", bytes.NewReader(payload))
+			req.Header.Set("Stripe-Signature", tt.header)
+
+			w := httptest.NewRecorder()
+			handler.HandleWebhook(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+			}
+
+			var response struct {
+				Error struct {
+					Code string `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal error response: %v", err)
+			}
+			if response.Error.Code != tt.expectedCode {
+				t.Errorf("Expected error code '%s', got '%s'", tt.expectedCode, response.Error.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestSubscriptionHistoryRecordsChanges(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+
+		start := time.Now().Truncate(time.Second)
+		steps := []struct {
+			eventID string
+			step    subscriptionStep
+		}{
+			{"evt_test_history_created", subscriptionStep{"customer.subscription.created", "active", 0, "", ""}},
+			{"evt_test_history_past_due", subscriptionStep{"customer.subscription.updated", "past_due", 2 * time.Minute, "", ""}},
+			// Changes nothing the history tracks
+			{"evt_test_history_repeat", subscriptionStep{"customer.subscription.updated", "past_due", 3 * time.Minute, "", ""}},
+			// Older than the applied past_due event, so it is skipped and not recorded
+			{"evt_test_history_stale", subscriptionStep{"customer.subscription.updated", "active", time.Minute, "", ""}},
+		}
+		for _, s := range steps {
+			deliverEvent(t, handler, subscriptionEvent(s.eventID, s.step, start, "sub_test_history", "prod_test_history", customer.StripeCustomerID))
+			processQueuedEvents(t, handler)
+		}
+
+		deliverEvent(t, handler, stripe.Event{
+			ID:      "evt_test_history_customer_deleted",
+			Type:    "customer.deleted",
+			Created: start.Add(5 * time.Minute).Unix(),
+			Data:    &stripe.EventData{Raw: json.RawMessage(`{"id": "` + customer.StripeCustomerID + `", "deleted": true}`)},
+		})
+		processQueuedEvents(t, handler)
+
+		events, err := testDB.Repo.ListSubscriptionEvents(ctx, project.ID, customer.UserID, "prod_test_history", 10, 0)
+		if err != nil {
+			t.Fatalf("Failed to list subscription history: %v", err)
+		}
+
+		expected := []struct {
+			sourceEventID  string
+			previousStatus string
+			status         string
+		}{
+			{"evt_test_history_customer_deleted", "past_due", database.SubscriptionStatusCanceled},
+			{"evt_test_history_past_due", "active", "past_due"},
+			{"evt_test_history_created", "", "active"},
+		}
+		if len(events) != len(expected) {
+			t.Fatalf("Expected %d history entries, got %d", len(expected), len(events))
+		}
+		for i, want := range expected {
+			got := events[i]
+			if got.SourceEventID != want.sourceEventID || got.PreviousStatus != want.previousStatus || got.Status != want.status {
+				t.Errorf("Entry %d: expected %s moving %q to %q, got %s moving %q to %q",
+					i, want.sourceEventID, want.previousStatus, want.status, got.SourceEventID, got.PreviousStatus, got.Status)
+			}
+			if got.StripeSubscriptionID != "sub_test_history" {
+				t.Errorf("Entry %d: expected subscription sub_test_history, got %s", i, got.StripeSubscriptionID)
+			}
+		}
+		if events[0].EventType != "customer.deleted" || !events[0].OccurredAt.Equal(start.Add(5*time.Minute)) {
+			t.Errorf("Expected the cancellation attributed to customer.deleted at its creation time, got %s at %v",
+				events[0].EventType, events[0].OccurredAt)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestSubscriptionItemsSync(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+
+		itemsEvent := func(eventID, eventType string, created time.Time, items string) stripe.Event {
+			return stripe.Event{
+				ID:      eventID,
+				Type:    eventType,
+				Created: created.Unix(),
+				Data: &stripe.EventData{
+					Raw: json.RawMessage(`{
+						"id": "sub_test_multi",
+						"customer": {"id": "` + customer.StripeCustomerID + `"},
+						"status": "active",
+						"current_period_start": ` + createTimestamp(created) + `,
+						"current_period_end": ` + createTimestamp(created.Add(30*24*time.Hour)) + `,
+						"items": {"data": [` + items + `]}
+					}`),
+				},
+			}
+		}
+
+		baseItem := `{"id": "si_test_base", "quantity": 1, "price": {"id": "price_test_base", "product": "prod_test_base"}}`
+		addonItem := `{"id": "si_test_addon", "quantity": 3, "price": {"id": "price_test_addon", "product": "prod_test_addon"}}`
+
+		tests := []struct {
+			name          string
+			event         stripe.Event
+			expectedItems int
+			addonActive   bool
+		}{
+			{
+				name:          "created with add-on",
+				event:         itemsEvent("evt_test_multi_created", "customer.subscription.created", time.Now().Add(-time.Minute), baseItem+","+addonItem),
+				expectedItems: 2,
+				addonActive:   true,
+			},
+			{
+				name:          "add-on removed",
+				event:         itemsEvent("evt_test_multi_updated", "customer.subscription.updated", time.Now(), baseItem),
+				expectedItems: 1,
+				addonActive:   false,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				handler.HandleWebhook(w, newSignedWebhookRequest(tt.event))
+				if w.Code != http.StatusOK {
+					t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+				}
+				processQueuedEvents(t, handler)
+
+				items, err := testDB.Repo.GetSubscriptionItems(ctx, "sub_test_multi")
+				if err != nil {
+					t.Fatalf("Failed to get subscription items: %v", err)
+				}
+				if len(items) != tt.expectedItems {
+					t.Fatalf("Expected %d items, got %d", tt.expectedItems, len(items))
+				}
+
+				// The base product always matches, the add-on only while it is part of the subscription
+				_, _, _, exists, err := testDB.Repo.GetSubscriptionStatus(ctx, project.ID, customer.UserID, "prod_test_base")
+				if err != nil || !exists {
+					t.Errorf("Expected base product to match, exists=%v err=%v", exists, err)
+				}
+
+				_, _, _, exists, err = testDB.Repo.GetSubscriptionStatus(ctx, project.ID, customer.UserID, "prod_test_addon")
+				if err != nil && !errors.Is(err, database.ErrNotFound) {
+					t.Fatalf("Failed to get add-on status: %v", err)
+				}
+				if exists != tt.addonActive {
+					t.Errorf("Expected add-on match %v, got %v", tt.addonActive, exists)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DraconDev/go-stripe-ms/internal/database"
+	"github.com/DraconDev/go-stripe-ms/internal/webhooks"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestSubscriptionLifecycleFields(t *testing.T) {
+	database.WithTestDatabase(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		_, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+
+		now := time.Now().Truncate(time.Second)
+		trialEnd := now.Add(3 * 24 * time.Hour)
+		resumesAt := now.Add(7 * 24 * time.Hour)
+
+		tests := []struct {
+			name   string
+			fields string
+			check  func(t *testing.T, sub *database.Subscription)
+		}{
+			{
+				name:   "trialing",
+				fields: `"status": "trialing", "trial_start": ` + createTimestamp(now) + `, "trial_end": ` + createTimestamp(trialEnd),
+				check: func(t *testing.T, sub *database.Subscription) {
+					if sub.TrialEnd == nil || !sub.TrialEnd.Equal(trialEnd) {
+						t.Errorf("Expected trial end %v, got %v", trialEnd, sub.TrialEnd)
+					}
+					if sub.CancelAtPeriodEnd {
+						t.Error("Expected cancel_at_period_end to be false")
+					}
+				},
+			},
+			{
+				name:   "cancels at period end",
+				fields: `"status": "active", "cancel_at_period_end": true, "canceled_at": ` + createTimestamp(now),
+				check: func(t *testing.T, sub *database.Subscription) {
+					if !sub.CancelAtPeriodEnd {
+						t.Error("Expected cancel_at_period_end to be true")
+					}
+					if sub.CanceledAt == nil || !sub.CanceledAt.Equal(now) {
+						t.Errorf("Expected canceled at %v, got %v", now, sub.CanceledAt)
+					}
+					if sub.TrialEnd != nil {
+						t.Errorf("Expected trial end to be cleared, got %v", sub.TrialEnd)
+					}
+				},
+			},
+			{
+				name:   "paused",
+				fields: `"status": "active", "pause_collection": {"behavior": "void", "resumes_at": ` + createTimestamp(resumesAt) + `}`,
+				check: func(t *testing.T, sub *database.Subscription) {
+					if sub.PauseBehavior != "void" {
+						t.Errorf("Expected pause behavior 'void', got '%s'", sub.PauseBehavior)
+					}
+					if sub.PauseResumesAt == nil || !sub.PauseResumesAt.Equal(resumesAt) {
+						t.Errorf("Expected resumes at %v, got %v", resumesAt, sub.PauseResumesAt)
+					}
+				},
+			},
+		}
+
+		for i, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				event := stripe.Event{
+					ID:      "evt_test_lifecycle_" + tt.name,
+					Type:    "customer.subscription.updated",
+					Created: now.Add(time.Duration(i) * time.Second).Unix(),
+					Data: &stripe.EventData{
+						Raw: json.RawMessage(`{
+							"id": "sub_test_lifecycle",
+							"customer": {"id": "` + customer.StripeCustomerID + `"},
+							"current_period_start": ` + createTimestamp(now) + `,
+							"current_period_end": ` + createTimestamp(now.Add(30*24*time.Hour)) + `,
+							"items": {"data": [{"id": "si_test_lifecycle", "price": {"id": "price_test_lifecycle", "product": "prod_test_lifecycle"}}]},
+							` + tt.fields + `
+						}`),
+					},
+				}
+
+				w := httptest.NewRecorder()
+				handler.HandleWebhook(w, newSignedWebhookRequest(event))
+				if w.Code != http.StatusOK {
+					t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+				}
+				processQueuedEvents(t, handler)
+
+				sub, err := testDB.Repo.GetSubscriptionByStripeID(ctx, "sub_test_lifecycle")
+				if err != nil {
+					t.Fatalf("Failed to get subscription: %v", err)
+				}
+				tt.check(t, sub)
+			})
+		}
+	})
+}
+
+// TestSubscriptionCustomerForms checks that the customer of a subscription is read both as the
+// string ID Stripe sends by default and as an expanded customer object
+func TestSubscriptionCustomerForms(t *testing.T) {
+	database.WithTestRepository(t, func(t *testing.T, testDB *database.TestDatabase) {
+		ctx := context.Background()
+		project, customer, err := testDB.CreateTestData()
+		if err != nil {
+			t.Fatalf("Failed to create test data: %v", err)
+		}
+
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
+		now := time.Now().Truncate(time.Second)
+
+		tests := []struct {
+			name     string
+			customer string
+		}{
+			{name: "string", customer: `"` + customer.StripeCustomerID + `"`},
+			{name: "expanded", customer: `{"id": "` + customer.StripeCustomerID + `", "object": "customer"}`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				subID := "sub_test_customer_" + tt.name
+				event := stripe.Event{
+					ID:      "evt_test_customer_" + tt.name,
+					Type:    "customer.subscription.created",
+					Created: now.Unix(),
+					Data: &stripe.EventData{
+						Raw: json.RawMessage(`{
+							"id": "` + subID + `",
+							"object": "subscription",
+							"customer": ` + tt.customer + `,
+							"status": "active",
+							"current_period_start": ` + createTimestamp(now) + `,
+							"current_period_end": ` + createTimestamp(now.Add(30*24*time.Hour)) + `,
+							"items": {"data": [{"id": "si_test_customer_` + tt.name + `", "quantity": 1,
+								"price": {"id": "price_test_customer", "product": "prod_test_customer_` + tt.name + `"}}]}
+						}`),
+					},
+				}
+
+				w := httptest.NewRecorder()
+				handler.HandleWebhook(w, newSignedWebhookRequest(event))
+				if w.Code != http.StatusOK {
+					t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+				}
+				processQueuedEvents(t, handler)
+
+				stored, err := testDB.Repo.GetStripeEvent(ctx, event.ID)
+				if err != nil {
+					t.Fatalf("Failed to get stored event: %v", err)
+				}
+				if stored.Status != database.EventStatusProcessed {
+					t.Fatalf("Expected event status '%s', got '%s' (%s)", database.EventStatusProcessed, stored.Status, stored.LastError)
+				}
+				sub, err := testDB.Repo.GetSubscriptionByStripeID(ctx, subID)
+				if err != nil {
+					t.Fatalf("Failed to get subscription: %v", err)
+				}
+				if sub.ProjectID != project.ID || sub.CustomerID != customer.ID {
+					t.Errorf("Expected the subscription of customer %s, got customer %s", customer.ID, sub.CustomerID)
+				}
+			})
+		}
+	})
+}
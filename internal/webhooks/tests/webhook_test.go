@@ -2,6 +2,7 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -22,14 +23,15 @@ func TestWebhookIntegration(t *testing.T) {
 			t.Fatalf("Failed to create test data: %v", err)
 		}
 
-		// Initialize webhook handler
-		// We use a dummy secret for testing since we're not verifying signatures in this test
-		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", "whsec_dummy")
+		// Initialize webhook handler with a known secret so fixtures can be signed locally
+		handler := webhooks.NewStripeWebhookHandler(testDB.Repo, "sk_test_dummy", testWebhookSecret)
 
 		t.Run("Handle customer.subscription.created", func(t *testing.T) {
 			// Create a mock event
 			event := stripe.Event{
-				Type: "customer.subscription.created",
+				ID:      "evt_test_sub_created",
+				Type:    "customer.subscription.created",
+				Created: time.Now().Unix(),
 				Data: &stripe.EventData{
 					Raw: json.RawMessage(`{
 						"id": "sub_test_created",
@@ -49,16 +51,8 @@ func TestWebhookIntegration(t *testing.T) {
 				},
 			}
 
-			// Create request
-			bodyBytes, _ := json.Marshal(event)
-			req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", bytes.NewReader(bodyBytes))
-			req.Header.Set("Content-Type", "application/json")
-
-			// We skip signature verification in the handler if secret is empty,
-			// but here we initialized it. However, the handler logic for verification
-			// is currently: "if !h.verifySignature(...)".
-			// And verifySignature returns true in the current implementation.
-			// So we don't need to generate a valid signature for now.
+			// Create signed request
+			req := newSignedWebhookRequest(event)
 
 			w := httptest.NewRecorder()
 			handler.HandleWebhook(w, req)
@@ -66,6 +60,7 @@ func TestWebhookIntegration(t *testing.T) {
 			if w.Code != http.StatusOK {
 				t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
 			}
+			processQueuedEvents(t, handler)
 
 			// Check if subscription exists
 			_, _, _, exists, err := testDB.Repo.GetSubscriptionStatus(req.Context(), project.ID, customer.UserID, "prod_test_123")
@@ -83,7 +78,9 @@ func TestWebhookIntegration(t *testing.T) {
 			// Let's update the one created above "sub_test_created"
 
 			event := stripe.Event{
-				Type: "customer.subscription.updated",
+				ID:      "evt_test_sub_updated",
+				Type:    "customer.subscription.updated",
+				Created: time.Now().Unix(),
 				Data: &stripe.EventData{
 					Raw: json.RawMessage(`{
 						"id": "sub_test_created",
@@ -93,9 +90,7 @@ func TestWebhookIntegration(t *testing.T) {
 				},
 			}
 
-			bodyBytes, _ := json.Marshal(event)
-			req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", bytes.NewReader(bodyBytes))
-			req.Header.Set("Content-Type", "application/json")
+			req := newSignedWebhookRequest(event)
 
 			w := httptest.NewRecorder()
 			handler.HandleWebhook(w, req)
@@ -103,6 +98,7 @@ func TestWebhookIntegration(t *testing.T) {
 			if w.Code != http.StatusOK {
 				t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
 			}
+			processQueuedEvents(t, handler)
 
 			// Verify status update
 			// Note: GetSubscriptionStatus returns exists=true for any status if record exists.
@@ -118,7 +114,9 @@ func TestWebhookIntegration(t *testing.T) {
 
 		t.Run("Handle customer.subscription.deleted", func(t *testing.T) {
 			event := stripe.Event{
-				Type: "customer.subscription.deleted",
+				ID:      "evt_test_sub_deleted",
+				Type:    "customer.subscription.deleted",
+				Created: time.Now().Unix(),
 				Data: &stripe.EventData{
 					Raw: json.RawMessage(`{
 						"id": "sub_test_created",
@@ -127,9 +125,7 @@ func TestWebhookIntegration(t *testing.T) {
 				},
 			}
 
-			bodyBytes, _ := json.Marshal(event)
-			req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", bytes.NewReader(bodyBytes))
-			req.Header.Set("Content-Type", "application/json")
+			req := newSignedWebhookRequest(event)
 
 			w := httptest.NewRecorder()
 			handler.HandleWebhook(w, req)
@@ -137,6 +133,7 @@ func TestWebhookIntegration(t *testing.T) {
 			if w.Code != http.StatusOK {
 				t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
 			}
+			processQueuedEvents(t, handler)
 
 			// Verify cancellation
 			sub, err := testDB.Repo.GetSubscriptionByStripeID(req.Context(), "sub_test_created")
@@ -150,6 +147,24 @@ func TestWebhookIntegration(t *testing.T) {
 	})
 }
 
+// newSignedWebhookRequest builds a webhook request signed with the test secret
+func newSignedWebhookRequest(event stripe.Event) *http.Request {
+	bodyBytes, _ := json.Marshal(event)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", bytes.NewReader(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", webhooks.SignPayload(bodyBytes, testWebhookSecret, time.Now()))
+	return req
+}
+
+// processQueuedEvents runs every due queued event through the handler
+func processQueuedEvents(t *testing.T, handler *webhooks.StripeWebhookHandler) {
+	t.Helper()
+	queue := webhooks.NewQueue(handler, webhooks.QueueConfig{})
+	if _, err := queue.ProcessPending(context.Background()); err != nil {
+		t.Fatalf("Failed to process queued events: %v", err)
+	}
+}
+
 func createTimestamp(t time.Time) string {
 	return fmt.Sprintf("%d", t.Unix())
 }